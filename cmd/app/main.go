@@ -1,22 +1,46 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"itfest-2025/internal/handler/rest"
 	"itfest-2025/internal/repository"
 	"itfest-2025/internal/service"
 	"itfest-2025/pkg/bcrypt"
+	"itfest-2025/pkg/captcha"
 	"itfest-2025/pkg/config"
 	"itfest-2025/pkg/database/mariadb"
+	"itfest-2025/pkg/google"
 	"itfest-2025/pkg/jwt"
+	"itfest-2025/pkg/mail"
 	"itfest-2025/pkg/middleware"
-	"itfest-2025/pkg/supabase"
+	"itfest-2025/pkg/scheduler"
+	"itfest-2025/pkg/storage"
+	"itfest-2025/pkg/webhook"
+	"itfest-2025/pkg/whatsapp"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
+	// The standard log package writes through the same handler, so the packages
+	// that still use it end up in the same JSON stream.
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
 	config.LoadEnvironment()
 
-	db, err := mariadb.ConnectDatabase()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
+	db, err := mariadb.ConnectDatabase(cfg.Database)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -27,14 +51,38 @@ func main() {
 	}
 
 	repo := repository.NewRepository(db)
-	supabase := supabase.Init()
-	bcrypt := bcrypt.Init()
-	jwt := jwt.Init()
-	svc := service.NewService(repo, bcrypt, jwt, supabase)
-	middleware := middleware.Init(svc, jwt)
+	storage, err := storage.Init(cfg.Storage)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	r := rest.NewRest(svc, middleware)
+	bcrypt := bcrypt.Init(cfg.BcryptCost)
+	jwt := jwt.Init(cfg.JWT)
+	whatsapp := whatsapp.Init(cfg.WhatsApp)
+	google := google.Init(cfg.Google)
+	captcha := captcha.Init(cfg.Captcha)
+	webhook := webhook.Init(cfg.Webhook)
+	mailer := mail.Init(cfg.SMTP)
+	svc := service.NewService(cfg, db, repo, bcrypt, jwt, storage, whatsapp, google, captcha, webhook, mailer, logger)
+	middleware := middleware.Init(svc, jwt, cfg.Server.Timeout)
+
+	r := rest.NewRest(svc, middleware, db)
 	r.MountEndpoint()
-	r.Run()
+	if cfg.Storage.Backend == config.StorageLocal {
+		r.MountFiles(cfg.Storage.Local.Dir)
+	}
+	if cfg.Server.MetricsPort == "" {
+		r.MountMetrics()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	go scheduler.Every(ctx, "deadline reminders", 24*time.Hour, svc.ReminderService.SendDeadlineReminders)
+	go scheduler.Every(ctx, "login history pruning", 24*time.Hour, svc.UserService.PruneLoginHistory)
+
+	err = r.Run(ctx, cfg.Server)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
 }
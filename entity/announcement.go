@@ -7,10 +7,11 @@ import (
 )
 
 type Announcement struct {
-	AnnouncementID uuid.UUID `json:"announcement_id" gorm:"varchar(36);primaryKey"`
-	Title          string    `json:"title" gorm:"varchar(255);not null;not null"`
-	Description    string    `json:"description" gorm:"text;not null"`
-	CompetitionID  int       `json:"competition_id" gorm:"default:null"`
-	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	AnnouncementID uuid.UUID  `json:"announcement_id" gorm:"varchar(36);primaryKey"`
+	Title          string     `json:"title" gorm:"varchar(255);not null;not null"`
+	Description    string     `json:"description" gorm:"text;not null"`
+	CompetitionID  *int       `json:"competition_id" gorm:"default:null"`
+	PublishedAt    *time.Time `json:"published_at" gorm:"type:datetime;index"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 }
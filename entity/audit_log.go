@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog records a security-relevant action. ActorID is nil when nobody was
+// signed in, and TargetID is the ID of the TargetType row the action touched.
+type AuditLog struct {
+	AuditLogID uuid.UUID       `json:"audit_log_id" gorm:"type:varchar(36);primaryKey"`
+	ActorID    *uuid.UUID      `json:"actor_id" gorm:"type:varchar(36);index"`
+	Action     string          `json:"action" gorm:"type:varchar(50);not null;index"`
+	TargetType string          `json:"target_type" gorm:"type:varchar(30);not null"`
+	TargetID   string          `json:"target_id" gorm:"type:varchar(36);not null"`
+	Metadata   json.RawMessage `json:"metadata" gorm:"type:json"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"autoCreateTime;index"`
+}
@@ -1,12 +1,24 @@
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Competition struct {
-	CompetitionID   int       `json:"competition_id" gorm:"type:int;primaryKey"`
-	CompetitionName string    `json:"competition_name" gorm:"type:varchar(70);not null"`
-	Description     string    `json:"description" gorm:"type:text;not null"`
-	Deadline        time.Time `json:"deadline" gorm:"type:datetime"`
+	CompetitionID      int             `json:"competition_id" gorm:"type:int;primaryKey"`
+	CompetitionName    string          `json:"competition_name" gorm:"type:varchar(70);not null"`
+	Category           string          `json:"category" gorm:"type:varchar(50)"`
+	MinMembers         int             `json:"min_members" gorm:"type:int;not null;default:1"`
+	MaxMembers         int             `json:"max_members" gorm:"type:int;not null;default:2"`
+	Fee                int             `json:"fee" gorm:"type:int;not null;default:0"`
+	Capacity           int             `json:"capacity" gorm:"type:int;not null;default:0"`
+	Description        string          `json:"description" gorm:"type:text;not null"`
+	Deadline           time.Time       `json:"deadline" gorm:"type:datetime"`
+	RegistrationOpen   *time.Time      `json:"registration_open" gorm:"type:datetime;default:null"`
+	RegistrationClose  *time.Time      `json:"registration_close" gorm:"type:datetime;default:null"`
+	IsRegistrationOpen bool            `json:"is_registration_open" gorm:"not null;default:true"`
+	ExtraFields        json.RawMessage `json:"extra_fields" gorm:"type:json"`
 
 	Teams         []Team         `gorm:"foreignKey:CompetitionID"`
 	Announcements []Announcement `gorm:"foreignKey:CompetitionID"`
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Coupon discounts a competition fee by either DiscountPercent or
+// DiscountAmount. A nil CompetitionID makes it valid for every competition.
+type Coupon struct {
+	CouponID        uuid.UUID  `json:"coupon_id" gorm:"type:varchar(36);primaryKey"`
+	Code            string     `json:"code" gorm:"type:varchar(30);not null;uniqueIndex"`
+	CompetitionID   *int       `json:"competition_id" gorm:"default:null"`
+	DiscountPercent int        `json:"discount_percent" gorm:"type:int;not null;default:0"`
+	DiscountAmount  int        `json:"discount_amount" gorm:"type:int;not null;default:0"`
+	UsageLimit      int        `json:"usage_limit" gorm:"type:int;not null"`
+	UsedCount       int        `json:"used_count" gorm:"type:int;not null;default:0"`
+	ExpiresAt       *time.Time `json:"expires_at" gorm:"type:datetime;default:null"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IdempotencyKey struct {
+	IdempotencyKeyID uuid.UUID `gorm:"type:varchar(36);primaryKey"`
+	Key              string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_idempotency_scope_key"`
+	Scope            string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_idempotency_scope_key"`
+	UserID           uuid.UUID `gorm:"type:varchar(36);not null;uniqueIndex:idx_idempotency_scope_key"`
+	Response         string    `gorm:"type:text"`
+	CreatedAt        time.Time `gorm:"autoCreateTime;not null"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LoginFingerprint struct {
+	LoginFingerprintID uuid.UUID `gorm:"type:varchar(36);primaryKey"`
+	UserID             uuid.UUID `gorm:"type:varchar(36);not null;index:idx_login_fingerprint_user_ip"`
+	IPAddress          string    `gorm:"type:varchar(45);not null;index:idx_login_fingerprint_user_ip"`
+	UserAgent          string    `gorm:"type:varchar(255)"`
+	LastSeenAt         time.Time `gorm:"not null"`
+	CreatedAt          time.Time `gorm:"autoCreateTime;not null"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginHistory is one successful login. Unlike LoginFingerprint, which keeps
+// one row per IP address, every login gets a row.
+type LoginHistory struct {
+	LoginHistoryID uuid.UUID `gorm:"type:varchar(36);primaryKey"`
+	UserID         uuid.UUID `gorm:"type:varchar(36);not null;index:idx_login_history_user_created"`
+	IPAddress      string    `gorm:"type:varchar(45);not null"`
+	UserAgent      string    `gorm:"type:varchar(255)"`
+	CreatedAt      time.Time `gorm:"autoCreateTime;not null;index:idx_login_history_user_created;index"`
+}
+
+func (LoginHistory) TableName() string {
+	return "login_history"
+}
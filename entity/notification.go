@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification is an in-app message for a user. ReadAt is nil until they mark
+// it read.
+type Notification struct {
+	NotificationID uuid.UUID  `json:"notification_id" gorm:"type:varchar(36);primaryKey"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:varchar(36);not null;index:idx_notifications_user_created"`
+	Type           string     `json:"type" gorm:"type:varchar(30);not null"`
+	Message        string     `json:"message" gorm:"type:text;not null"`
+	ReadAt         *time.Time `json:"read_at" gorm:"type:datetime"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime;index:idx_notifications_user_created"`
+}
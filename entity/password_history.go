@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PasswordHistory struct {
+	PasswordHistoryID uuid.UUID `gorm:"type:varchar(36);primaryKey"`
+	UserID            uuid.UUID `gorm:"type:varchar(36);not null;index"`
+	PasswordHash      string    `gorm:"type:varchar(80);not null"`
+	CreatedAt         time.Time `gorm:"autoCreateTime;not null"`
+}
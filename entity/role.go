@@ -1,7 +1,60 @@
 package entity
 
+// Role IDs are fixed so code can check them without a lookup. Migrate keeps
+// the roles table in line with them.
+const (
+	RoleAdmin   = 1
+	RoleUser    = 2
+	RoleJudge   = 3
+	RoleFinance = 4
+)
+
+const (
+	RoleNameAdmin   = "admin"
+	RoleNameUser    = "user"
+	RoleNameJudge   = "judge"
+	RoleNameFinance = "finance"
+)
+
+// Permissions guard the admin routes. A role can do what its role_permissions
+// rows grant.
+const (
+	PermissionTeamsRead          = "teams:read"
+	PermissionPaymentsVerify     = "payments:verify"
+	PermissionSubmissionsGrade   = "submissions:grade"
+	PermissionCompetitionsManage = "competitions:manage"
+	PermissionUsersManage        = "users:manage"
+	PermissionAuditRead          = "audit:read"
+)
+
+// DefaultRolePermissions is what Migrate grants each role. Grants added to the
+// table by hand are kept.
+var DefaultRolePermissions = map[int][]string{
+	RoleAdmin: {
+		PermissionTeamsRead,
+		PermissionPaymentsVerify,
+		PermissionSubmissionsGrade,
+		PermissionCompetitionsManage,
+		PermissionUsersManage,
+		PermissionAuditRead,
+	},
+	RoleJudge: {
+		PermissionSubmissionsGrade,
+	},
+	RoleFinance: {
+		PermissionTeamsRead,
+		PermissionPaymentsVerify,
+	},
+}
+
 type Role struct {
-	RoleID   int    `json:"role_id" gorm:"type:int;primaryKey"`
-	RoleName string `json:"role_name" gorm:"type:varchar(20);not null"`
-	Users    []User `gorm:"foreignKey:RoleID"`
+	RoleID      int              `json:"role_id" gorm:"type:int;primaryKey"`
+	RoleName    string           `json:"role_name" gorm:"type:varchar(20);not null"`
+	Users       []User           `json:"-" gorm:"foreignKey:RoleID"`
+	Permissions []RolePermission `json:"permissions" gorm:"foreignKey:RoleID"`
+}
+
+type RolePermission struct {
+	RoleID     int    `json:"role_id" gorm:"type:int;primaryKey"`
+	Permission string `json:"permission" gorm:"type:varchar(50);primaryKey"`
 }
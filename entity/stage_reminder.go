@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StageReminder marks that a team was reminded about a stage deadline, so the
+// reminder is only sent once.
+type StageReminder struct {
+	TeamID  uuid.UUID `gorm:"type:varchar(36);primaryKey"`
+	StageID int       `gorm:"primaryKey"`
+	SentAt  time.Time `gorm:"autoCreateTime;not null"`
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SupportMessage struct {
+	SupportMessageID uuid.UUID  `gorm:"type:varchar(36);primaryKey"`
+	UserID           *uuid.UUID `gorm:"type:varchar(36);index"`
+	Name             string     `gorm:"type:varchar(70);not null"`
+	Email            string     `gorm:"type:varchar(50);not null"`
+	Subject          string     `gorm:"type:varchar(150);not null"`
+	Body             string     `gorm:"type:text;not null"`
+	IPAddress        string     `gorm:"type:varchar(45)"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime;not null"`
+}
@@ -1,13 +1,26 @@
 package entity
 
-import "github.com/google/uuid"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
 
 type Team struct {
-	TeamID        uuid.UUID `json:"team_id" gorm:"type:varchar(36);primaryKey"`
-	TeamName      string    `json:"team_name" gorm:"type:varchar(50);not null"`
-	TeamStatus    string    `json:"team_status" gorm:"type:enum('belum terverifikasi', 'terverifikasi', 'ditolak');not null"`
-	UserID        uuid.UUID `json:"user_id"`
-	CompetitionID int       `json:"competition_id"`
+	TeamID                    uuid.UUID       `json:"team_id" gorm:"type:varchar(36);primaryKey"`
+	TeamName                  string          `json:"team_name" gorm:"type:varchar(50);not null"`
+	TeamStatus                string          `json:"team_status" gorm:"type:enum('belum terverifikasi', 'terverifikasi', 'ditolak');not null"`
+	UserID                    uuid.UUID       `json:"user_id" gorm:"type:varchar(36);index"`
+	CompetitionID             int             `json:"competition_id"`
+	CouponID                  *uuid.UUID      `json:"coupon_id" gorm:"type:varchar(36);default:null"`
+	PaymentConfirmationSentAt *time.Time      `json:"-"`
+	PaymentApprovedAt         *time.Time      `json:"payment_approved_at"`
+	RegisteredAt              *time.Time      `json:"registered_at"`
+	WaitlistedAt              *time.Time      `json:"waitlisted_at" gorm:"index"`
+	ExtraFields               json.RawMessage `json:"extra_fields" gorm:"type:json"`
+	DeletedAt                 gorm.DeletedAt  `json:"-" gorm:"index"`
 
 	TeamMembers    []TeamMember   `json:"team_members" gorm:"foreignKey:TeamID"`
 	TeamProgresses []TeamProgress `json:"team_progresses" gorm:"foreignKey:TeamID"`
@@ -5,6 +5,6 @@ import "github.com/google/uuid"
 type TeamMember struct {
 	TeamMemberID  uuid.UUID `json:"team_member_id" gorm:"varchar(36);primaryKey"`
 	MemberName    string    `json:"member_name" gorm:"varchar(70);not null"`
-	StudentNumber string    `json:"student_number" gorm:"type:varchar(20);"`
+	StudentNumber string    `json:"student_number" gorm:"type:varchar(20);index"`
 	TeamID        uuid.UUID `json:"team_id"`
 }
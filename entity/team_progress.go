@@ -7,11 +7,14 @@ import (
 )
 
 type TeamProgress struct {
-	TeamProgressID int       `json:"team_progress_id" gorm:"int;primaryKey;autoIncrement"`
-	StageID        int       `json:"stage_id"`
-	Status         string    `json:"status" gorm:"type:enum('diproses', 'lolos', 'tidak lolos');not null"`
-	TeamID         uuid.UUID `json:"team_id"`
-	GdriveLink     string    `json:"gdrive_link" gorm:"varchar(100);not null"`
-	CreatedAt      time.Time `json:"created_at"  gorm:"autoCreateTime"`
-	UpdatedAt      time.Time `json:"updated_at"  gorm:"autoUpdateTime"`
+	TeamProgressID int        `json:"team_progress_id" gorm:"int;primaryKey;autoIncrement"`
+	StageID        int        `json:"stage_id"`
+	Status         string     `json:"status" gorm:"type:enum('diproses', 'lolos', 'tidak lolos');not null"`
+	TeamID         uuid.UUID  `json:"team_id"`
+	GdriveLink     string     `json:"gdrive_link" gorm:"varchar(100);not null"`
+	Score          *float64   `json:"score" gorm:"type:decimal(6,2);default:null"`
+	Feedback       string     `json:"feedback" gorm:"type:text"`
+	GradedAt       *time.Time `json:"graded_at" gorm:"type:datetime;default:null"`
+	CreatedAt      time.Time  `json:"created_at"  gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at"  gorm:"autoUpdateTime"`
 }
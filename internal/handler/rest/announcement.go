@@ -3,14 +3,18 @@ package rest
 import (
 	"errors"
 	"itfest-2025/model"
+	"itfest-2025/pkg/middleware"
 	"itfest-2025/pkg/response"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func (r *Rest) GetAnnouncement(c *gin.Context) {
-	data, err := r.service.AnnouncementService.GetAnnouncement()
+	data, err := r.service.AnnouncementService.GetAnnouncement(c.Request.Context())
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to get announcement", err)
 		return
@@ -23,11 +27,11 @@ func (r *Rest) CreateAnnouncement(c *gin.Context) {
 	var req model.RequestAnnouncement
 	err := c.ShouldBindJSON(&req)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 	
-	err = r.service.AnnouncementService.SendAnnouncement(req)
+	err = r.service.AnnouncementService.SendAnnouncement(c.Request.Context(), req)
 	if err != nil {
 		if errors.Is(err, model.ErrUserRecordNotFound) {
 			response.Error(c, http.StatusNotFound, "User not found", err)
@@ -38,4 +42,125 @@ func (r *Rest) CreateAnnouncement(c *gin.Context) {
 	}
 
 	response.Success(c, http.StatusOK, "success to send announcement", nil)
-}
\ No newline at end of file
+}
+
+func (r *Rest) UpdateAnnouncement(c *gin.Context) {
+	announcementID, err := uuid.Parse(c.Param("announcement_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "announcement ID is invalid", err)
+		return
+	}
+
+	var req model.RequestAnnouncement
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	err = r.service.AnnouncementService.UpdateAnnouncement(c.Request.Context(), announcementID, req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "announcement not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to update announcement", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to update announcement", nil)
+}
+
+func (r *Rest) DeleteAnnouncement(c *gin.Context) {
+	announcementID, err := uuid.Parse(c.Param("announcement_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "announcement ID is invalid", err)
+		return
+	}
+
+	err = r.service.AnnouncementService.DeleteAnnouncement(c.Request.Context(), announcementID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "announcement not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to delete announcement", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to delete announcement", nil)
+}
+
+func (r *Rest) ListAnnouncements(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var query model.AnnouncementQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	data, err := r.service.AnnouncementService.ListAnnouncements(c.Request.Context(), userID, query)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to get announcement", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get announcement", data)
+}
+
+func (r *Rest) GetUnreadAnnouncements(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	data, err := r.service.AnnouncementService.UnreadAnnouncements(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to count unread announcements", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to count unread announcements", data)
+}
+
+func (r *Rest) MarkAnnouncementsRead(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	err := r.service.AnnouncementService.MarkAnnouncementsRead(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to mark announcements as read", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to mark announcements as read", nil)
+}
+
+func (r *Rest) BroadcastEmail(c *gin.Context) {
+	competitionID, err := strconv.Atoi(c.Param("competition_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "failed to convert competition id", err)
+		return
+	}
+
+	var req model.BroadcastEmailRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	summary, err := r.service.AnnouncementService.BroadcastEmail(c.Request.Context(), competitionID, req)
+	if err != nil {
+		var validationErr model.ValidationErrors
+		if errors.As(err, &validationErr) {
+			response.ValidationError(c, http.StatusUnprocessableEntity, "invalid email template", validationErr)
+			return
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "competition not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to broadcast email", err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "broadcast email queued", summary)
+}
@@ -0,0 +1,26 @@
+package rest
+
+import (
+	"itfest-2025/model"
+	"itfest-2025/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (r *Rest) ListAuditLogs(c *gin.Context) {
+	var query model.AuditLogQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	data, err := r.service.AuditService.ListAuditLogs(c.Request.Context(), query)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to get audit logs", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get audit logs", data)
+}
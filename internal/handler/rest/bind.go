@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"itfest-2025/model"
+	"itfest-2025/pkg/response"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// useJSONFieldNames makes the binding validator report fields by their JSON
+// (or form) name, which is what clients send.
+func useJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+
+		return field.Name
+	})
+}
+
+// bindError responds to a failed ShouldBind. A request that fails its binding
+// rules gets 422 with a message per field; anything else, such as malformed
+// JSON, gets 400.
+func bindError(c *gin.Context, err error) {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		return
+	}
+
+	fields := model.ValidationErrors{}
+	for _, fieldErr := range fieldErrs {
+		// The namespace starts with the struct's type name, which means
+		// nothing to the client.
+		_, field, _ := strings.Cut(fieldErr.Namespace(), ".")
+		fields[field] = fieldMessage(fieldErr)
+	}
+
+	response.ValidationError(c, http.StatusUnprocessableEntity, "invalid input", fields)
+}
+
+func fieldMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "uuid":
+		return "must be a valid UUID"
+	case "url":
+		return "must be a valid URL"
+	case "eqfield":
+		return fmt.Sprintf("must match %s", strings.ToLower(param))
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", param)
+	case "min":
+		return fmt.Sprintf("must be at least %s%s", param, unit(fieldErr.Kind()))
+	case "max":
+		return fmt.Sprintf("must be at most %s%s", param, unit(fieldErr.Kind()))
+	case "len":
+		return fmt.Sprintf("must be exactly %s%s", param, unit(fieldErr.Kind()))
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", param)
+	case "gte":
+		return fmt.Sprintf("must be at least %s", param)
+	case "lt":
+		return fmt.Sprintf("must be less than %s", param)
+	case "lte":
+		return fmt.Sprintf("must be at most %s", param)
+	}
+
+	return "is invalid"
+}
+
+// unit is what a min, max or len limit counts for a field of the given kind.
+func unit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	}
+
+	return ""
+}
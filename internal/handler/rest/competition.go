@@ -1,14 +1,19 @@
 package rest
 
 import (
+	"errors"
+	"itfest-2025/model"
+	"itfest-2025/pkg/middleware"
 	"itfest-2025/pkg/response"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func (r *Rest) GetAllCompetitions(c *gin.Context) {
-	competition, err := r.service.CompetitionService.GetAllCompetitions()
+	competition, err := r.service.CompetitionService.GetAllCompetitions(c.Request.Context())
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to get competitions", err)
 		return
@@ -16,3 +21,163 @@ func (r *Rest) GetAllCompetitions(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, "success to get all competitions", competition)
 }
+
+func (r *Rest) GetCompetition(c *gin.Context) {
+	competitionID, err := strconv.Atoi(c.Param("competition_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "failed to convert competition id", err)
+		return
+	}
+
+	competition, err := r.service.CompetitionService.GetCompetition(c.Request.Context(), competitionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "competition not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to get competition", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get competition", competition)
+}
+
+func (r *Rest) GetCompetitionSchedule(c *gin.Context) {
+	competitionID, err := strconv.Atoi(c.Param("competition_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "failed to convert competition id", err)
+		return
+	}
+
+	schedule, err := r.service.CompetitionService.GetCompetitionSchedule(c.Request.Context(), competitionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "competition not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to get competition schedule", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get competition schedule", schedule)
+}
+
+func (r *Rest) UpdateCompetitionFee(c *gin.Context) {
+	competitionID, err := strconv.Atoi(c.Param("competition_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "failed to convert competition id", err)
+		return
+	}
+
+	var req model.ReqUpdateCompetitionFee
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+
+	err = r.service.CompetitionService.UpdateCompetitionFee(c.Request.Context(), adminID, competitionID, req.Fee)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "competition not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to update competition fee", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to update competition fee", nil)
+}
+
+func (r *Rest) UpdateCompetitionCapacity(c *gin.Context) {
+	competitionID, err := strconv.Atoi(c.Param("competition_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "failed to convert competition id", err)
+		return
+	}
+
+	var req model.ReqUpdateCompetitionCapacity
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+
+	err = r.service.CompetitionService.UpdateCompetitionCapacity(c.Request.Context(), adminID, competitionID, req.Capacity)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "competition not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to update competition capacity", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to update competition capacity", nil)
+}
+
+func (r *Rest) UpdateRegistrationStatus(c *gin.Context) {
+	competitionID, err := strconv.Atoi(c.Param("competition_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "failed to convert competition id", err)
+		return
+	}
+
+	var req model.ReqUpdateRegistrationStatus
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+
+	err = r.service.CompetitionService.UpdateRegistrationStatus(c.Request.Context(), adminID, competitionID, *req.IsRegistrationOpen)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "competition not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to update registration status", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to update registration status", nil)
+}
+
+func (r *Rest) UpdateExtraFields(c *gin.Context) {
+	competitionID, err := strconv.Atoi(c.Param("competition_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "failed to convert competition id", err)
+		return
+	}
+
+	var req model.ReqUpdateExtraFields
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+
+	err = r.service.CompetitionService.UpdateExtraFields(c.Request.Context(), adminID, competitionID, req.ExtraFields)
+	if err != nil {
+		var validationErr model.ValidationErrors
+		if errors.As(err, &validationErr) {
+			response.ValidationError(c, http.StatusUnprocessableEntity, "invalid extra fields", validationErr)
+			return
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "competition not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to update extra fields", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to update extra fields", nil)
+}
@@ -8,7 +8,7 @@ import (
 )
 
 func (r *Rest) GetCount(c *gin.Context) {
-	count, err := r.service.CountService.GetAllCount()
+	count, err := r.service.CountService.GetAllCount(c.Request.Context())
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to export team", err)
 		return
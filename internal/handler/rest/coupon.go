@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"errors"
+	"itfest-2025/model"
+	"itfest-2025/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (r *Rest) ValidateCoupon(c *gin.Context) {
+	competitionID, err := strconv.Atoi(c.Param("competition_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "failed to convert competition id", err)
+		return
+	}
+
+	result, err := r.service.CouponService.ValidateCoupon(c.Request.Context(), c.Param("code"), competitionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "competition not found", err)
+			return
+		} else if errors.Is(err, model.ErrCouponNotFound) {
+			response.Error(c, http.StatusNotFound, "coupon not found", err)
+			return
+		} else if errors.Is(err, model.ErrCouponExpired) || errors.Is(err, model.ErrCouponExhausted) {
+			response.Error(c, http.StatusGone, "coupon can no longer be used", err)
+			return
+		} else if errors.Is(err, model.ErrCouponCompetitionMismatch) {
+			response.Error(c, http.StatusUnprocessableEntity, "coupon is not valid for this competition", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to validate coupon", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "coupon is valid", result)
+}
+
+func (r *Rest) CreateCoupon(c *gin.Context) {
+	var req model.CreateCouponRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	coupon, err := r.service.CouponService.CreateCoupon(c.Request.Context(), req)
+	if err != nil {
+		var validationErr model.ValidationErrors
+		if errors.As(err, &validationErr) {
+			response.ValidationError(c, http.StatusUnprocessableEntity, "invalid coupon", validationErr)
+			return
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "competition not found", err)
+			return
+		} else if errors.Is(err, model.ErrCouponCodeTaken) {
+			response.Error(c, http.StatusConflict, "cannot use this coupon code", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to create coupon", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "success to create coupon", coupon)
+}
@@ -10,7 +10,7 @@ import (
 )
 
 func (r *Rest) GetExportPayment(c *gin.Context) {
-	fileName, err := r.service.ExcelService.ExportExcelPayment()
+	fileName, err := r.service.ExcelService.ExportExcelPayment(c.Request.Context())
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to export team", err)
 		return
@@ -27,7 +27,7 @@ func (r *Rest) GetExportPayment(c *gin.Context) {
 }
 
 func (r *Rest) GetExportTeam(c *gin.Context) {
-	fileName, err := r.service.ExcelService.ExportExcelTeam()
+	fileName, err := r.service.ExcelService.ExportExcelTeam(c.Request.Context())
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to export team", err)
 		return
@@ -56,7 +56,7 @@ func (r *Rest) GetExportCompetitionID(c *gin.Context) {
 		return
 	}
 
-	fileName, err := r.service.ExcelService.ExportExcelCompetitionByID(id)
+	fileName, err := r.service.ExcelService.ExportExcelCompetitionByID(c.Request.Context(), id)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to export team", err)
 		return
@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"context"
+	"itfest-2025/pkg/database/mariadb"
+	"itfest-2025/pkg/response"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds the database ping so a hung connection fails the
+// probe instead of hanging it.
+const readinessTimeout = 2 * time.Second
+
+func (r *Rest) Healthz(c *gin.Context) {
+	response.Success(c, http.StatusOK, "service is alive", nil)
+}
+
+func (r *Rest) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	err := mariadb.Ping(ctx, r.db)
+	if err != nil {
+		response.Error(c, http.StatusServiceUnavailable, "database is unreachable", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "service is ready", nil)
+}
@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		pingErr error
+		want    int
+	}{
+		{name: "database reachable", want: http.StatusOK},
+		{name: "database unreachable", pingErr: errors.New("connection refused"), want: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer sqlDB.Close()
+
+			// gorm pings once when it opens the connection.
+			mock.ExpectPing()
+			db, err := gorm.Open(mysql.New(mysql.Config{
+				Conn:                      sqlDB,
+				SkipInitializeWithVersion: true,
+			}), &gorm.Config{
+				Logger: logger.Discard,
+			})
+			if err != nil {
+				t.Fatalf("failed to open gorm: %v", err)
+			}
+
+			mock.ExpectPing().WillReturnError(tt.pingErr)
+
+			r := &Rest{db: db}
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+			r.Readyz(c)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
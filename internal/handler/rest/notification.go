@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"errors"
+	"itfest-2025/model"
+	"itfest-2025/pkg/middleware"
+	"itfest-2025/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (r *Rest) ListNotifications(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var query model.PaginationQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	data, err := r.service.NotificationService.ListNotifications(c.Request.Context(), userID, query)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to get notifications", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get notifications", data)
+}
+
+func (r *Rest) MarkNotificationRead(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	notificationID, err := uuid.Parse(c.Param("notification_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "notification ID is invalid", err)
+		return
+	}
+
+	err = r.service.NotificationService.MarkAsRead(c.Request.Context(), userID, notificationID)
+	if err != nil {
+		if errors.Is(err, model.ErrNotificationNotFound) {
+			response.Error(c, http.StatusNotFound, "notification not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to mark notification as read", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to mark notification as read", nil)
+}
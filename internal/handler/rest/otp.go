@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"errors"
 	"itfest-2025/model"
 	"itfest-2025/pkg/response"
 	"net/http"
@@ -16,13 +17,14 @@ func (r *Rest) ResendOtp(c *gin.Context) {
 		return
 	}
 
-	err = r.service.OtpService.ResendOtp(req)
+	err = r.service.OtpService.ResendOtp(c.Request.Context(), req)
 	if err != nil {
+		var cooldownErr *model.CooldownError
 		if err.Error() == "your account is already active" {
 			response.Error(c, http.StatusForbidden, "user already verified", err)
 			return
-		} else if err.Error() == "you can only resend otp every 5 minutes" {
-			response.Error(c, http.StatusForbidden, "resend otp failed", err)
+		} else if errors.As(err, &cooldownErr) {
+			response.TooManyRequests(c, "resend otp failed", err, cooldownErr.RetryAfterSeconds())
 			return
 		} else {
 			response.Error(c, http.StatusInternalServerError, "failed to resend otp", err)
@@ -34,17 +36,18 @@ func (r *Rest) ResendOtp(c *gin.Context) {
 }
 
 func (r *Rest) ResendOtpChangePassword(c *gin.Context) {
-	var req model.GetOtp
+	var req model.ForgotPasswordRequest
 	err := c.ShouldBindJSON(&req)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	err = r.service.OtpService.ResendOtpChangePassword(req)
+	err = r.service.OtpService.ResendOtpChangePassword(c.Request.Context(), req)
 	if err != nil {
-		if err.Error() == "you can only resend otp every 5 minutes" {
-			response.Error(c, http.StatusForbidden, "failed to resend token", err)
+		var cooldownErr *model.CooldownError
+		if errors.As(err, &cooldownErr) {
+			response.TooManyRequests(c, "failed to resend token", err, cooldownErr.RetryAfterSeconds())
 			return
 		} else {
 			response.Error(c, http.StatusInternalServerError, "failed to resend token", err)
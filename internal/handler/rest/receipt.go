@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"errors"
+	"itfest-2025/model"
+	"itfest-2025/pkg/middleware"
+	"itfest-2025/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (r *Rest) GetMyReceipt(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	receipt, err := r.service.ReceiptService.GenerateMyReceipt(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, model.ErrNoTeam) {
+			response.Error(c, http.StatusNotFound, "you don't have a team", err)
+			return
+		} else if errors.Is(err, model.ErrPaymentNotApproved) {
+			response.Error(c, http.StatusConflict, "payment has not been approved yet", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to generate receipt", err)
+		return
+	}
+
+	sendReceipt(c, "kwitansi-pembayaran.pdf", receipt)
+}
+
+func (r *Rest) GetTeamReceipt(c *gin.Context) {
+	teamID, err := uuid.Parse(c.Param("team_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "team ID is invalid", err)
+		return
+	}
+
+	receipt, err := r.service.ReceiptService.GenerateReceipt(c.Request.Context(), teamID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "team not found", err)
+			return
+		} else if errors.Is(err, model.ErrPaymentNotApproved) {
+			response.Error(c, http.StatusConflict, "payment has not been approved yet", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to generate receipt", err)
+		return
+	}
+
+	sendReceipt(c, "kwitansi-"+teamID.String()+".pdf", receipt)
+}
+
+func sendReceipt(c *gin.Context, fileName string, receipt []byte) {
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Data(http.StatusOK, "application/pdf", receipt)
+}
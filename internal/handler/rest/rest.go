@@ -1,93 +1,237 @@
 package rest
 
 import (
+	"context"
 	"fmt"
+	"itfest-2025/entity"
 	"itfest-2025/internal/service"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/metrics"
 	"itfest-2025/pkg/middleware"
-	"os"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// These replace the global TIME_OUT_LIMIT on route groups that need a different
+// budget: uploads stream files to storage, while auth requests should fail fast.
+const (
+	uploadTimeout = 30 * time.Second
+	authTimeout   = 5 * time.Second
+)
+
+// supportRateLimit is how many support messages one user or IP may send per hour.
+const supportRateLimit = 5
+
+// emailCheckRateLimit is how many emails one IP may check per hour. It is enough
+// for someone filling in the registration form, but makes the endpoint slow to
+// use for finding out who is registered.
+const emailCheckRateLimit = 20
+
+// shutdownTimeout is how long Run waits for in-flight requests on shutdown.
+const shutdownTimeout = 10 * time.Second
+
 type Rest struct {
 	router     *gin.Engine
 	service    *service.Service
 	middleware middleware.Interface
+	// db is only used by Readyz to ping the database.
+	db *gorm.DB
 }
 
-func NewRest(service *service.Service, middleware middleware.Interface) *Rest {
+func NewRest(service *service.Service, middleware middleware.Interface, db *gorm.DB) *Rest {
+	useJSONFieldNames()
+
 	return &Rest{
 		router:     gin.Default(),
 		service:    service,
 		middleware: middleware,
+		db:         db,
 	}
 }
 
 func (r *Rest) MountEndpoint() {
-	r.router.Use(r.middleware.Cors())
-	r.router.Use(r.middleware.Timeout())
+	r.router.Use(r.middleware.Metrics(), r.middleware.Cors())
 
-	routerGroup := r.router.Group("api/v1")
+	r.router.GET("/healthz", r.Healthz)
+	r.router.GET("/readyz", r.Readyz)
+
+	v1 := r.router.Group("api/v1")
+	routerGroup := v1.Group("", r.middleware.Timeout())
 	routerGroup.GET("/competitions", r.GetAllCompetitions)
+	routerGroup.GET("/competitions/:competition_id", r.GetCompetition)
+	routerGroup.GET("/competitions/:competition_id/schedule", r.GetCompetitionSchedule)
+
+	support := routerGroup.Group("/support")
+	support.Use(r.middleware.OptionalAuthenticateUser, r.middleware.RateLimit(supportRateLimit, time.Hour))
+	support.POST("", r.SubmitSupportMessage)
 
-	auth := routerGroup.Group("/auth")
+	auth := v1.Group("/auth", r.middleware.TimeoutWithDuration(authTimeout))
 	auth.POST("/register", r.Register)
+	auth.GET("/email-available", r.middleware.RateLimit(emailCheckRateLimit, time.Hour), r.IsEmailAvailable)
 	auth.PATCH("/register", r.VerifyUser)
+	auth.GET("/verify", r.VerifyUserByToken)
 	auth.PATCH("/register/resend", r.ResendOtp)
 	auth.POST("/login", r.Login)
+	auth.POST("/google", r.LoginWithGoogle)
 	auth.POST("/forgot-password", r.ChangePassword)
 	auth.POST("/verify-otp", r.VerifyOtpChangePassword)
 	auth.POST("/reset-password", r.ChangePasswordAfterVerify)
 	auth.PATCH("/resend-token", r.ResendOtpChangePassword)
 
 	user := routerGroup.Group("/users")
-	user.Use(r.middleware.AuthenticateUser)
+	user.Use(r.middleware.Authenticate())
 	user.GET("/profile", r.GetUserProfile)
 	user.GET("/my-team-info", r.GetTeamInfo)
 	user.GET("/my-team-profile", r.GetMyTeamProfile)
+	user.GET("/export-data", r.ExportUserData)
+	user.GET("/login-history", r.GetLoginHistory)
 	user.GET("/progress", r.GetProgressByUserID)
-	user.POST("/upload-payment", r.UploadPayment)
+	user.GET("/announcements", r.ListAnnouncements)
+	user.GET("/announcements/unread", r.GetUnreadAnnouncements)
+	user.POST("/announcements/read", r.MarkAnnouncementsRead)
+	user.GET("/notifications", r.ListNotifications)
+	user.PATCH("/notifications/:notification_id/read", r.MarkNotificationRead)
 	user.POST("/change-password", r.ChangePassword)
 	user.POST("/verify-token", r.VerifyOtpChangePassword)
 	user.PATCH("/update-profile", r.UpdateProfile)
+	user.POST("/change-email", r.RequestEmailChange)
+	user.PATCH("/change-email", r.ConfirmEmailChange)
+	user.DELETE("/account", r.DeleteAccount)
 	user.PATCH("/upsert-team", r.UpsertTeam)
+	user.PATCH("/team-name", r.SetTeamName)
+	user.POST("/payment-confirmation/resend", r.ResendPaymentConfirmation)
+	user.GET("/payment-receipt", r.GetMyReceipt)
 	user.PATCH("/change-password", r.ChangePasswordAfterVerify)
 
 	submission := routerGroup.Group("/submissions")
-	submission.Use(r.middleware.AuthenticateUser)
+	submission.Use(r.middleware.Authenticate())
 	submission.GET("/", r.GetSubmission)
 	submission.GET("/stage", r.GetCurrentStage)
+	submission.GET("/progress", r.GetMyProgress)
 	submission.POST("/", r.CreateSubmission)
 
 	competition := routerGroup.Group("/competitions")
-	competition.Use(r.middleware.AuthenticateUser)
-	competition.POST("/upload-ktm", r.UploadKTM)
+	competition.Use(r.middleware.Authenticate())
 	competition.POST("/register/:competition_id", r.CompetitionRegistration)
+	competition.GET("/:competition_id/coupons/:code", r.ValidateCoupon)
+	competition.GET("/:competition_id/leaderboard", r.GetLeaderboard)
 
-	admin := routerGroup.Group("/admin")
-	admin.Use(r.middleware.AuthenticateUser, r.middleware.OnlyAdmin)
-	admin.GET("/payment-status", r.GetUserPaymentStatus)
-	admin.GET("/total-participants", r.GetTotalParticipant)
-	admin.GET("/count", r.GetCount)
-	admin.GET("/teams", r.GetAllTeam)
-	admin.GET("/teams/:team_id", r.GetTeamByID)
-	admin.GET("/teams/:team_id/progress", r.GetTeamByIDProgress)
-	admin.PATCH("/teams/:team_id/progress/:stage_id", r.UpdateStatusSubmission)
-	admin.PATCH("/teams/:team_id", r.UpdateTeamStatus)
-
-	announcement := admin.Group("/announcement")
-	announcement.GET("/", r.GetAnnouncement)
-	announcement.POST("/", r.CreateAnnouncement)
+	// Admin routes share the /admin prefix and are grouped by the permission
+	// they need, so staff roles only reach their part of it.
+	teams := routerGroup.Group("/admin")
+	teams.Use(r.middleware.Authenticate(), r.middleware.RequirePermission(entity.PermissionTeamsRead))
+	teams.GET("/payment-status", r.GetUserPaymentStatus)
+	teams.GET("/total-participants", r.GetTotalParticipant)
+	teams.GET("/count", r.GetCount)
+	teams.GET("/teams", r.GetAllTeam)
+	teams.GET("/teams/:team_id", r.GetTeamByID)
+	teams.GET("/teams/:team_id/progress", r.GetTeamByIDProgress)
 
-	excel := admin.Group("/excel")
+	excel := teams.Group("/excel")
 	excel.GET("/data-payment", r.GetExportPayment)
 	excel.GET("/data-team", r.GetExportTeam)
 	excel.GET("/data-competition", r.GetExportCompetitionID)
+
+	payments := routerGroup.Group("/admin")
+	payments.Use(r.middleware.Authenticate(), r.middleware.RequirePermission(entity.PermissionPaymentsVerify))
+	payments.PATCH("/teams/:team_id", r.UpdateTeamStatus)
+	payments.POST("/teams/approve-payments", r.BulkApprovePayments)
+	payments.GET("/teams/:team_id/receipt", r.GetTeamReceipt)
+
+	judging := routerGroup.Group("/admin")
+	judging.Use(r.middleware.Authenticate(), r.middleware.RequirePermission(entity.PermissionSubmissionsGrade))
+	judging.PATCH("/teams/:team_id/progress/:stage_id", r.UpdateStatusSubmission)
+	judging.PATCH("/submissions/:team_progress_id/grade", r.GradeSubmission)
+	judging.PUT("/stages/:stage_id/teams/:team_id/score", r.ScoreSubmission)
+	judging.GET("/stages/:stage_id/leaderboard", r.GetStageLeaderboard)
+
+	competitions := routerGroup.Group("/admin")
+	competitions.Use(r.middleware.Authenticate(), r.middleware.RequirePermission(entity.PermissionCompetitionsManage))
+	competitions.PATCH("/teams/:team_id/competition", r.UpdateTeamCompetition)
+	competitions.PATCH("/competitions/:competition_id/fee", r.UpdateCompetitionFee)
+	competitions.PATCH("/competitions/:competition_id/capacity", r.UpdateCompetitionCapacity)
+	competitions.POST("/competitions/:competition_id/waitlist/promote", r.PromoteWaitlistedTeams)
+	competitions.PATCH("/competitions/:competition_id/registration", r.UpdateRegistrationStatus)
+	competitions.PUT("/competitions/:competition_id/extra-fields", r.UpdateExtraFields)
+	competitions.POST("/competitions/:competition_id/broadcast", r.BroadcastEmail)
+	competitions.POST("/coupons", r.CreateCoupon)
+
+	announcement := competitions.Group("/announcement")
+	announcement.GET("/", r.GetAnnouncement)
+	announcement.POST("/", r.CreateAnnouncement)
+	announcement.PATCH("/:announcement_id", r.UpdateAnnouncement)
+	announcement.DELETE("/:announcement_id", r.DeleteAnnouncement)
+
+	accounts := routerGroup.Group("/admin")
+	accounts.Use(r.middleware.Authenticate(), r.middleware.RequirePermission(entity.PermissionUsersManage))
+	accounts.PATCH("/users/:user_id/restore", r.RestoreAccount)
+	accounts.GET("/roles", r.ListRoles)
+	accounts.GET("/users/:user_id/role", r.GetUserRole)
+	accounts.GET("/users/:user_id/login-history", r.GetUserLoginHistory)
+	accounts.PATCH("/users/:user_id/role", r.AssignRole)
+
+	audit := routerGroup.Group("/admin")
+	audit.Use(r.middleware.Authenticate(), r.middleware.RequirePermission(entity.PermissionAuditRead))
+	audit.GET("/audit-logs", r.ListAuditLogs)
+
+	upload := v1.Group("", r.middleware.TimeoutWithDuration(uploadTimeout))
+	upload.Use(r.middleware.Authenticate())
+	upload.POST("/users/upload-payment", r.UploadPayment)
+	upload.POST("/competitions/upload-ktm", r.UploadKTM)
+}
+
+// MountFiles serves the local storage directory at /files, without directory
+// listings.
+func (r *Rest) MountFiles(dir string) {
+	r.router.StaticFS("/files", gin.Dir(dir, false))
+}
+
+// MountMetrics serves the Prometheus metrics at /metrics, without
+// authentication. Use it when METRICS_PORT isn't set; otherwise Run serves them
+// on that port.
+func (r *Rest) MountMetrics() {
+	r.router.GET("/metrics", gin.WrapH(metrics.Handler()))
 }
 
-func (r *Rest) Run() {
-	addr := os.Getenv("ADDRESS")
-	port := os.Getenv("PORT")
+// Run serves until ctx is cancelled, then shuts the servers down gracefully.
+// When METRICS_PORT is set, the metrics get a server of their own on it.
+func (r *Rest) Run(ctx context.Context, cfg config.Server) error {
+	servers := []*http.Server{{
+		Addr:    fmt.Sprintf("%s:%s", cfg.Address, cfg.Port),
+		Handler: r.router,
+	}}
+	if cfg.MetricsPort != "" {
+		servers = append(servers, &http.Server{
+			Addr:    fmt.Sprintf("%s:%s", cfg.Address, cfg.MetricsPort),
+			Handler: metrics.Handler(),
+		})
+	}
+
+	errCh := make(chan error, len(servers))
+	for _, server := range servers {
+		go func() {
+			errCh <- server.ListenAndServe()
+		}()
+	}
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, server := range servers {
+		shutdownErr := server.Shutdown(shutdownCtx)
+		if err == nil {
+			err = shutdownErr
+		}
+	}
 
-	r.router.Run(fmt.Sprintf("%s:%s", addr, port))
+	return err
 }
@@ -2,23 +2,26 @@ package rest
 
 import (
 	"errors"
-	"itfest-2025/entity"
 	"itfest-2025/model"
+	"itfest-2025/pkg/middleware"
 	"itfest-2025/pkg/response"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func (r *Rest) GetSubmission(c *gin.Context) {
 	param := &model.ReqFilterSubmission{}
 	err := c.ShouldBindQuery(param)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	data, err := r.service.SubmissionService.GetSubmission(param)
+	data, err := r.service.SubmissionService.GetSubmission(c.Request.Context(), param)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to get submission", err)
 		return
@@ -28,10 +31,14 @@ func (r *Rest) GetSubmission(c *gin.Context) {
 }
 
 func (r *Rest) GetCurrentStage(c *gin.Context) {
-	user := c.MustGet("user").(*entity.User)
+	userID := middleware.GetUserID(c)
 	
-	data, err := r.service.SubmissionService.GetCurrentStage(user.UserID)
+	data, err := r.service.SubmissionService.GetCurrentStage(c.Request.Context(), userID)
 	if err != nil {
+		if errors.Is(err, model.ErrNoTeam) {
+			response.Error(c, http.StatusNotFound, "you don't have a team", err)
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "failed to get current stage", err)
 		return
 	}
@@ -39,19 +46,39 @@ func (r *Rest) GetCurrentStage(c *gin.Context) {
 	response.Success(c, http.StatusCreated, "success to get current stage", data)
 }
 
+func (r *Rest) GetMyProgress(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	data, err := r.service.SubmissionService.GetMyProgress(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, model.ErrNoTeam) {
+			response.Error(c, http.StatusNotFound, "you don't have a team", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to get progress", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get progress", data)
+}
+
 func (r *Rest) CreateSubmission(c *gin.Context) {
 	param := model.ReqSubmission{}
-	user := c.MustGet("user").(*entity.User)
+	userID := middleware.GetUserID(c)
 	
 	err := c.ShouldBind(&param)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	err = r.service.SubmissionService.CreateSubmission(user.UserID, &param)
+	err = r.service.SubmissionService.CreateSubmission(c.Request.Context(), userID, &param)
 	if err != nil {
-		if errors.Is(err, model.ErrUnverifiedAccount) {
+		var teamSizeErr *model.TeamSizeError
+		if errors.Is(err, model.ErrNoTeam) {
+			response.Error(c, http.StatusNotFound, "you don't have a team", err)
+			return
+		} else if errors.Is(err, model.ErrUnverifiedAccount) {
 			response.Error(c, http.StatusForbidden, "cannot add another team member", err)
 			return
 		} else if errors.Is(err, model.ErrNotPassedPrevious) {
@@ -63,6 +90,9 @@ func (r *Rest) CreateSubmission(c *gin.Context) {
 		} else if errors.Is(err, model.ErrPassedDeadline) {
 			response.Error(c, http.StatusGone, "submission melewati deadline", err)
 			return
+		} else if errors.As(err, &teamSizeErr) {
+			response.Error(c, http.StatusUnprocessableEntity, "the team does not have enough members", err)
+			return
 		}
 		response.Error(c, http.StatusInternalServerError, "failed to create submission", err)
 		return
@@ -87,7 +117,7 @@ func (r *Rest) UpdateStatusSubmission(c *gin.Context) {
 
 	err := c.ShouldBindJSON(&req)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
@@ -96,11 +126,128 @@ func (r *Rest) UpdateStatusSubmission(c *gin.Context) {
 		return
 	}
 
-	err = r.service.SubmissionService.UpdateStatusSubmission(teamID, stageID, &req)
+	adminID := middleware.GetUserID(c)
+
+	err = r.service.SubmissionService.UpdateStatusSubmission(c.Request.Context(), adminID, teamID, stageID, &req)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to update team status", err)
 		return
 	}
 
 	response.Success(c, http.StatusOK, "success update team status", nil)
-}
\ No newline at end of file
+}
+
+func (r *Rest) GradeSubmission(c *gin.Context) {
+	teamProgressID, err := strconv.Atoi(c.Param("team_progress_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "submission ID is invalid", err)
+		return
+	}
+
+	var req model.GradeSubmissionRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	judgeID := middleware.GetUserID(c)
+
+	err = r.service.SubmissionService.GradeSubmission(c.Request.Context(), judgeID, teamProgressID, req)
+	if err != nil {
+		var validationErr model.ValidationErrors
+		if errors.As(err, &validationErr) {
+			response.ValidationError(c, http.StatusUnprocessableEntity, "invalid grade", validationErr)
+			return
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "submission not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to grade submission", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to grade submission", nil)
+}
+
+func (r *Rest) ScoreSubmission(c *gin.Context) {
+	stageID, err := strconv.Atoi(c.Param("stage_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "stage ID is invalid", err)
+		return
+	}
+
+	teamID, err := uuid.Parse(c.Param("team_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "team ID is invalid", err)
+		return
+	}
+
+	var req model.GradeSubmissionRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	judgeID := middleware.GetUserID(c)
+
+	err = r.service.SubmissionService.ScoreSubmission(c.Request.Context(), judgeID, stageID, teamID, req)
+	if err != nil {
+		var validationErr model.ValidationErrors
+		if errors.As(err, &validationErr) {
+			response.ValidationError(c, http.StatusUnprocessableEntity, "invalid grade", validationErr)
+			return
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "the team has no submission for this stage", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to score submission", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to score submission", nil)
+}
+
+func (r *Rest) GetStageLeaderboard(c *gin.Context) {
+	stageID, err := strconv.Atoi(c.Param("stage_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "stage ID is invalid", err)
+		return
+	}
+
+	data, err := r.service.SubmissionService.GetStageLeaderboard(c.Request.Context(), stageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "stage not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to get stage leaderboard", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get stage leaderboard", data)
+}
+
+func (r *Rest) GetLeaderboard(c *gin.Context) {
+	competitionID, err := strconv.Atoi(c.Param("competition_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "failed to convert competition id", err)
+		return
+	}
+
+	var query model.LeaderboardQuery
+	err = c.ShouldBindQuery(&query)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	data, err := r.service.SubmissionService.GetLeaderboard(c.Request.Context(), competitionID, query)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to get leaderboard", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get leaderboard", data)
+}
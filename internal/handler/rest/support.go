@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"errors"
+	"itfest-2025/model"
+	"itfest-2025/pkg/middleware"
+	"itfest-2025/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (r *Rest) SubmitSupportMessage(c *gin.Context) {
+	var param model.SupportMessage
+	err := c.ShouldBindJSON(&param)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	param.IPAddress = c.ClientIP()
+	if userID, ok := c.Get(middleware.UserIDKey); ok {
+		id := userID.(uuid.UUID)
+		param.UserID = &id
+	}
+
+	err = r.service.SupportService.SubmitSupportMessage(c.Request.Context(), param)
+	if err != nil {
+		var validationErr model.ValidationErrors
+		if errors.As(err, &validationErr) {
+			response.ValidationError(c, http.StatusUnprocessableEntity, "invalid support message", validationErr)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to send support message", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "support message sent", nil)
+}
@@ -2,10 +2,11 @@ package rest
 
 import (
 	"errors"
-	"itfest-2025/entity"
 	"itfest-2025/model"
+	"itfest-2025/pkg/middleware"
 	"itfest-2025/pkg/response"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -16,19 +17,38 @@ func (r *Rest) UpsertTeam(c *gin.Context) {
 	var param model.UpsertTeamRequest
 	err := c.ShouldBindJSON(&param)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	user := c.MustGet("user").(*entity.User)
+	userID := middleware.GetUserID(c)
 
-	res, err := r.service.TeamService.UpsertTeam(user.UserID, &param)
+	res, err := r.service.TeamService.UpsertTeam(c.Request.Context(), userID, &param)
 	if err != nil {
-		if err.Error() == "maximum of 2 team members allowed" {
+		var validationErr model.ValidationErrors
+		var teamSizeErr *model.TeamSizeError
+		var duplicateErr *model.DuplicateMemberError
+		if errors.As(err, &validationErr) {
+			response.ValidationError(c, http.StatusUnprocessableEntity, "invalid team data", validationErr)
+			return
+		} else if errors.Is(err, model.ErrDuplicateStudentNumber) {
+			response.Error(c, http.StatusBadRequest, "cannot add the same student twice", err)
+			return
+		} else if errors.As(err, &duplicateErr) {
+			response.ErrorWithData(c, http.StatusConflict, "student is already registered in another team", err, gin.H{
+				"student_number": duplicateErr.StudentNumber,
+				"team_id":        duplicateErr.TeamID,
+				"team_name":      duplicateErr.TeamName,
+			})
+			return
+		} else if errors.As(err, &teamSizeErr) {
 			response.Error(c, http.StatusBadRequest, "cannot add another team member", err)
 			return
-		} else if err.Error() == "team name already exists" {
-			response.Error(c, http.StatusBadRequest, "cannot use this team name", err)
+		} else if errors.Is(err, model.ErrTeamNameTaken) {
+			response.Error(c, http.StatusConflict, "cannot use this team name", err)
+			return
+		} else if errors.Is(err, model.ErrTeamNameNotAllowed) {
+			response.Error(c, http.StatusUnprocessableEntity, "cannot use this team name", err)
 			return
 		} else {
 			response.Error(c, http.StatusInternalServerError, "failed to upsert team", err)
@@ -39,11 +59,48 @@ func (r *Rest) UpsertTeam(c *gin.Context) {
 	response.Success(c, http.StatusOK, "success upsert team", res)
 }
 
+func (r *Rest) SetTeamName(c *gin.Context) {
+	var param model.SetTeamNameRequest
+	err := c.ShouldBindJSON(&param)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+
+	res, err := r.service.TeamService.SetTeamName(c.Request.Context(), userID, param)
+	if err != nil {
+		var validationErr model.ValidationErrors
+		if errors.As(err, &validationErr) {
+			response.ValidationError(c, http.StatusUnprocessableEntity, "invalid team name", validationErr)
+			return
+		} else if errors.Is(err, model.ErrNoTeam) {
+			response.Error(c, http.StatusNotFound, "you don't have a team", err)
+			return
+		} else if errors.Is(err, model.ErrTeamNameTaken) {
+			response.Error(c, http.StatusConflict, "cannot use this team name", err)
+			return
+		} else if errors.Is(err, model.ErrTeamNameNotAllowed) {
+			response.Error(c, http.StatusUnprocessableEntity, "cannot use this team name", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to set team name", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to set team name", res)
+}
+
 func (r *Rest) GetTeamInfo(c *gin.Context) {
-	user := c.MustGet("user").(*entity.User)
+	userID := middleware.GetUserID(c)
 
-	teamInfo, err := r.service.TeamService.GetMembersByUserID(user.UserID)
+	teamInfo, err := r.service.TeamService.GetMembersByUserID(c.Request.Context(), userID)
 	if err != nil {
+		if errors.Is(err, model.ErrNoTeam) {
+			response.Error(c, http.StatusNotFound, "you don't have a team", err)
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "failed to get team members", err)
 		return
 	}
@@ -53,7 +110,14 @@ func (r *Rest) GetTeamInfo(c *gin.Context) {
 }
 
 func (r *Rest) GetAllTeam(c *gin.Context) {
-	res, err := r.service.TeamService.GetAllTeam()
+	var page model.PaginationQuery
+	err := c.ShouldBindQuery(&page)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	res, err := r.service.TeamService.GetAllTeam(c.Request.Context(), page)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to get all team informations", err)
 		return
@@ -73,16 +137,18 @@ func (r *Rest) UpdateTeamStatus(c *gin.Context) {
 
 	err := c.ShouldBindJSON(&req)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	if req.PaymentStatus != "belum terverifikasi" && req.PaymentStatus != "terverifikasi" {
+	if req.PaymentStatus != "belum terverifikasi" && req.PaymentStatus != "terverifikasi" && req.PaymentStatus != "ditolak" {
 		response.Error(c, http.StatusBadRequest, "invalid payment status", nil)
 		return
 	}
 
-	err = r.service.TeamService.UpdateTeamStatus(teamID, req)
+	adminID := middleware.GetUserID(c)
+
+	err = r.service.TeamService.UpdateTeamStatus(c.Request.Context(), adminID, teamID, req)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to update team status", err)
 		return
@@ -91,6 +157,102 @@ func (r *Rest) UpdateTeamStatus(c *gin.Context) {
 	response.Success(c, http.StatusOK, "success update team status", nil)
 }
 
+func (r *Rest) BulkApprovePayments(c *gin.Context) {
+	var req model.BulkApprovePaymentsRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+
+	res, err := r.service.TeamService.BulkApprovePayments(c.Request.Context(), adminID, req.TeamIDs)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to approve payments", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success approve payments", res)
+}
+
+func (r *Rest) ResendPaymentConfirmation(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	err := r.service.TeamService.ResendPaymentConfirmation(c.Request.Context(), userID)
+	if err != nil {
+		var cooldownErr *model.CooldownError
+		if errors.Is(err, model.ErrNoTeam) {
+			response.Error(c, http.StatusNotFound, "you don't have a team", err)
+			return
+		} else if errors.Is(err, model.ErrPaymentNotApproved) {
+			response.Error(c, http.StatusConflict, "payment has not been approved yet", err)
+			return
+		} else if errors.As(err, &cooldownErr) {
+			response.TooManyRequests(c, "failed to resend payment confirmation", err, cooldownErr.RetryAfterSeconds())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to resend payment confirmation", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success resend payment confirmation", nil)
+}
+
+func (r *Rest) UpdateTeamCompetition(c *gin.Context) {
+	teamID, err := uuid.Parse(c.Param("team_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "team ID is invalid", err)
+		return
+	}
+
+	var req model.ReqUpdateTeamCompetition
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+
+	err = r.service.TeamService.UpdateTeamCompetition(c.Request.Context(), adminID, teamID, req.CompetitionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "team or competition not found", err)
+			return
+		} else if errors.Is(err, model.ErrTeamNameTaken) {
+			response.Error(c, http.StatusConflict, "another team in that competition has the same name", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to update team competition", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success update team competition", nil)
+}
+
+func (r *Rest) PromoteWaitlistedTeams(c *gin.Context) {
+	competitionID, err := strconv.Atoi(c.Param("competition_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "failed to convert competition id", err)
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+
+	data, err := r.service.TeamService.PromoteWaitlistedTeams(c.Request.Context(), adminID, competitionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "competition not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to promote waitlisted teams", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to promote waitlisted teams", data)
+}
+
 func (r *Rest) GetTeamByID(c *gin.Context) {
 	teamIDParam := c.Param("team_id")
 
@@ -100,7 +262,7 @@ func (r *Rest) GetTeamByID(c *gin.Context) {
 	}
 	teamID, _ := uuid.Parse(teamIDParam)
 
-	data, err := r.service.TeamService.GetTeamByID(teamID)
+	data, err := r.service.TeamService.GetTeamByID(c.Request.Context(), teamID)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to get team", err)
 		return
@@ -118,7 +280,7 @@ func (r *Rest) GetTeamByIDProgress(c *gin.Context) {
 	}
 	teamID, _ := uuid.Parse(teamIDParam)
 
-	data, err := r.service.TeamService.GetDetailTeam(teamID)
+	data, err := r.service.TeamService.GetDetailTeam(c.Request.Context(), teamID)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to get progress team", err)
 		return
@@ -128,11 +290,14 @@ func (r *Rest) GetTeamByIDProgress(c *gin.Context) {
 }
 
 func (r *Rest) GetProgressByUserID(c *gin.Context) {
-	userID := c.MustGet("user").(*entity.User)
+	userID := middleware.GetUserID(c)
 
-	data, err := r.service.TeamService.GetProgressByUserID(userID.UserID)
+	data, err := r.service.TeamService.GetProgressByUserID(c.Request.Context(), userID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, model.ErrNoTeam) {
+			response.Error(c, http.StatusNotFound, "you don't have a team", err)
+			return
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
 			response.Error(c, http.StatusNotFound, "failed to get progress team", err)
 			return
 		}
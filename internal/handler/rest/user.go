@@ -1,53 +1,101 @@
 package rest
 
 import (
-	"itfest-2025/entity"
+	"errors"
 	"itfest-2025/model"
+	"itfest-2025/pkg/captcha"
+	"itfest-2025/pkg/google"
+	"itfest-2025/pkg/middleware"
 	"itfest-2025/pkg/response"
+	"itfest-2025/pkg/storage"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func (r *Rest) Register(c *gin.Context) {
 	param := model.UserRegister{}
 	err := c.ShouldBind(&param)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	token, err := r.service.UserService.Register(&param)
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if len(idempotencyKey) > 100 {
+		response.Error(c, http.StatusBadRequest, "invalid idempotency key", errors.New("idempotency key must be at most 100 characters"))
+		return
+	}
+
+	param.IPAddress = c.ClientIP()
+
+	token, err := r.service.UserService.Register(c.Request.Context(), &param, idempotencyKey)
 	if err != nil {
-		if err.Error() == "email already registered" {
+		if errors.Is(err, model.ErrEmailAlreadyRegistered) {
 			response.Error(c, http.StatusBadRequest, "failed to register new user", err)
 			return
-		} else if err.Error() == "password doesn't match" {
-			response.Error(c, http.StatusBadRequest, "failed to register new user", err)
+		} else if errors.Is(err, captcha.ErrVerificationFailed) {
+			response.Error(c, http.StatusForbidden, "captcha verification failed", err)
 			return
 		}
-
+		response.Error(c, http.StatusInternalServerError, "failed to register new user", err)
+		return
 	}
 
 	response.Success(c, http.StatusCreated, "success to register new user", token)
 
 }
 
+func (r *Rest) IsEmailAvailable(c *gin.Context) {
+	var query model.EmailAvailabilityQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	available, err := r.service.UserService.IsEmailAvailable(c.Request.Context(), query.Email)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to check email", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to check email", model.EmailAvailabilityResponse{
+		Email:     model.NormalizeEmail(query.Email),
+		Available: available,
+	})
+}
+
 func (r *Rest) Login(c *gin.Context) {
 	param := model.UserLogin{}
 
 	err := c.ShouldBindJSON(&param)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	result, err := r.service.UserService.Login(param)
+	param.IPAddress = c.ClientIP()
+	param.UserAgent = c.Request.UserAgent()
+
+	result, err := r.service.UserService.Login(c.Request.Context(), param)
 	if err != nil {
+		var unverifiedErr *model.UnverifiedAccountError
 		if err.Error() == "email or password is wrong" {
 			response.Error(c, http.StatusUnauthorized, "email or password is wrong", err)
 			return
+		} else if errors.As(err, &unverifiedErr) {
+			response.ErrorWithData(c, http.StatusForbidden, "please verify your email", err, gin.H{
+				"user_id":        unverifiedErr.UserID,
+				"status_account": "inactive",
+			})
+			return
+		} else if errors.Is(err, captcha.ErrVerificationFailed) {
+			response.Error(c, http.StatusForbidden, "captcha verification failed", err)
+			return
 		} else {
 			response.Error(c, http.StatusInternalServerError, "failed to login user", err)
 			return
@@ -57,8 +105,35 @@ func (r *Rest) Login(c *gin.Context) {
 	response.Success(c, http.StatusOK, "success to login user", result)
 }
 
+func (r *Rest) LoginWithGoogle(c *gin.Context) {
+	var param model.GoogleLoginRequest
+	err := c.ShouldBindJSON(&param)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	param.IPAddress = c.ClientIP()
+	param.UserAgent = c.Request.UserAgent()
+
+	result, err := r.service.UserService.LoginWithGoogle(c.Request.Context(), param)
+	if err != nil {
+		if errors.Is(err, google.ErrInvalidIDToken) {
+			response.Error(c, http.StatusUnauthorized, "google token is invalid", err)
+			return
+		} else if errors.Is(err, model.ErrEmailRegisteredWithPassword) {
+			response.Error(c, http.StatusConflict, "failed to login with google", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to login with google", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to login user", result)
+}
+
 func (r *Rest) UploadPayment(c *gin.Context) {
-	user := c.MustGet("user").(*entity.User)
+	userID := middleware.GetUserID(c)
 
 	paymentFile, err := c.FormFile("payment")
 	if err != nil {
@@ -66,10 +141,29 @@ func (r *Rest) UploadPayment(c *gin.Context) {
 		return
 	}
 
-	publicURL, err := r.service.UserService.UploadPayment(user.UserID, paymentFile)
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if len(idempotencyKey) > 100 {
+		response.Error(c, http.StatusBadRequest, "invalid idempotency key", errors.New("idempotency key must be at most 100 characters"))
+		return
+	}
+
+	publicURL, err := r.service.UserService.UploadPayment(c.Request.Context(), userID, paymentFile, idempotencyKey)
 	if err != nil {
 		if err.Error() == "file size exceeds maximum limit of 1MB" {
 			response.Error(c, http.StatusBadRequest, "please reduce the file size", err)
+			return
+		} else if errors.Is(err, model.ErrInvalidImage) {
+			response.Error(c, http.StatusBadRequest, "the uploaded image could not be read", err)
+			return
+		} else if errors.Is(err, model.ErrUnsupportedFileType) {
+			response.Error(c, http.StatusUnsupportedMediaType, "the uploaded file type is not allowed", err)
+			return
+		} else if errors.Is(err, storage.ErrUploadCanceled) {
+			response.Error(c, http.StatusRequestTimeout, "the upload was cancelled before it finished", err)
+			return
+		} else if errors.Is(err, model.ErrTeamWaitlisted) {
+			response.Error(c, http.StatusConflict, "your team is on the waitlist", err)
+			return
 		} else {
 			response.Error(c, http.StatusInternalServerError, "failed to upload payment", err)
 			return
@@ -81,7 +175,7 @@ func (r *Rest) UploadPayment(c *gin.Context) {
 }
 
 func (r *Rest) UploadKTM(c *gin.Context) {
-	user := c.MustGet("user").(*entity.User)
+	userID := middleware.GetUserID(c)
 
 	ktmFile, err := c.FormFile("ktm")
 	if err != nil {
@@ -89,10 +183,20 @@ func (r *Rest) UploadKTM(c *gin.Context) {
 		return
 	}
 
-	err = r.service.UserService.UploadKTM(user.UserID, ktmFile)
+	err = r.service.UserService.UploadKTM(c.Request.Context(), userID, ktmFile)
 	if err != nil {
 		if err.Error() == "file size exceeds maximum limit of 1MB" {
 			response.Error(c, http.StatusBadRequest, "please reduce the file size", err)
+			return
+		} else if errors.Is(err, model.ErrInvalidImage) {
+			response.Error(c, http.StatusBadRequest, "the uploaded image could not be read", err)
+			return
+		} else if errors.Is(err, model.ErrUnsupportedFileType) {
+			response.Error(c, http.StatusUnsupportedMediaType, "the uploaded file type is not allowed", err)
+			return
+		} else if errors.Is(err, storage.ErrUploadCanceled) {
+			response.Error(c, http.StatusRequestTimeout, "the upload was cancelled before it finished", err)
+			return
 		} else {
 			response.Error(c, http.StatusInternalServerError, "failed to upload payment", err)
 			return
@@ -106,18 +210,21 @@ func (r *Rest) VerifyUser(c *gin.Context) {
 	var param model.VerifyUser
 	err := c.ShouldBindJSON(&param)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	err = r.service.UserService.VerifyUser(param)
+	err = r.service.UserService.VerifyUser(c.Request.Context(), param)
 	if err != nil {
-		if err.Error() == "invalid otp code" {
+		if errors.Is(err, model.ErrInvalidOtpCode) {
 			response.Error(c, http.StatusUnauthorized, "otp code is wrong", err)
 			return
-		} else if err.Error() == "otp expired" {
+		} else if errors.Is(err, model.ErrOtpExpired) {
 			response.Error(c, http.StatusUnauthorized, "otp code is expired", err)
 			return
+		} else if errors.Is(err, model.ErrAccountAlreadyVerified) {
+			response.Error(c, http.StatusConflict, "account already verified", err)
+			return
 		} else {
 			response.Error(c, http.StatusInternalServerError, "failed to verify user", err)
 			return
@@ -128,18 +235,147 @@ func (r *Rest) VerifyUser(c *gin.Context) {
 
 }
 
+func (r *Rest) VerifyUserByToken(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		response.Error(c, http.StatusBadRequest, "token is required", nil)
+		return
+	}
+
+	err := r.service.UserService.VerifyUserByToken(c.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidVerificationLink) {
+			response.Error(c, http.StatusUnauthorized, "verification link is invalid", err)
+			return
+		} else if errors.Is(err, model.ErrOtpExpired) {
+			response.Error(c, http.StatusUnauthorized, "verification link is expired", err)
+			return
+		} else if errors.Is(err, model.ErrAccountAlreadyVerified) {
+			response.Error(c, http.StatusConflict, "account already verified", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to verify user", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to verify user", nil)
+}
+
+func (r *Rest) RequestEmailChange(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var param model.RequestEmailChange
+	err := c.ShouldBindJSON(&param)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	err = r.service.UserService.RequestEmailChange(c.Request.Context(), userID, param.NewEmail)
+	if err != nil {
+		if errors.Is(err, model.ErrEmailAlreadyRegistered) {
+			response.Error(c, http.StatusConflict, "email already registered", err)
+			return
+		} else if errors.Is(err, model.ErrEmailManagedByGoogle) {
+			response.Error(c, http.StatusForbidden, "failed to request email change", err)
+			return
+		} else if errors.Is(err, model.ErrSameEmail) {
+			response.Error(c, http.StatusBadRequest, "failed to request email change", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to request email change", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "verification code sent to the new email", nil)
+}
+
+func (r *Rest) ConfirmEmailChange(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var param model.ConfirmEmailChange
+	err := c.ShouldBindJSON(&param)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	err = r.service.UserService.ConfirmEmailChange(c.Request.Context(), userID, param.OtpCode)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidOtpCode) {
+			response.Error(c, http.StatusUnauthorized, "otp code is wrong", err)
+			return
+		} else if errors.Is(err, model.ErrOtpExpired) {
+			response.Error(c, http.StatusUnauthorized, "otp code is expired", err)
+			return
+		} else if errors.Is(err, model.ErrNoPendingEmailChange) {
+			response.Error(c, http.StatusBadRequest, "failed to change email", err)
+			return
+		} else if errors.Is(err, model.ErrEmailAlreadyRegistered) {
+			response.Error(c, http.StatusConflict, "email already registered", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to change email", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to change email, please login again", nil)
+}
+
+func (r *Rest) DeleteAccount(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	err := r.service.UserService.DeleteAccount(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to delete account", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to delete account", nil)
+}
+
+func (r *Rest) RestoreAccount(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "user ID is invalid", err)
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+
+	err = r.service.UserService.RestoreAccount(c.Request.Context(), adminID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "deleted account not found", err)
+			return
+		} else if errors.Is(err, model.ErrEmailAlreadyRegistered) {
+			response.Error(c, http.StatusConflict, "email already registered by another account", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to restore account", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to restore account", nil)
+}
+
 func (r *Rest) UpdateProfile(c *gin.Context) {
-	user := c.MustGet("user").(*entity.User)
+	userID := middleware.GetUserID(c)
 
 	var param model.UpdateProfile
 	err := c.ShouldBindJSON(&param)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	res, err := r.service.UserService.UpdateProfile(user.UserID, param)
+	res, err := r.service.UserService.UpdateProfile(c.Request.Context(), userID, param)
 	if err != nil {
+		var validationErr model.ValidationErrors
+		if errors.As(err, &validationErr) {
+			response.ValidationError(c, http.StatusUnprocessableEntity, "invalid profile data", validationErr)
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "failed to update user profile", err)
 		return
 	}
@@ -148,9 +384,9 @@ func (r *Rest) UpdateProfile(c *gin.Context) {
 }
 
 func (r *Rest) GetUserProfile(c *gin.Context) {
-	user := c.MustGet("user").(*entity.User)
+	userID := middleware.GetUserID(c)
 
-	userProfile, err := r.service.UserService.GetUserProfile(user.UserID)
+	userProfile, err := r.service.UserService.GetUserProfile(c.Request.Context(), userID)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to user profile", err)
 		return
@@ -160,10 +396,14 @@ func (r *Rest) GetUserProfile(c *gin.Context) {
 }
 
 func (r *Rest) GetMyTeamProfile(c *gin.Context) {
-	user := c.MustGet("user").(*entity.User)
+	userID := middleware.GetUserID(c)
 
-	teamProfile, err := r.service.UserService.GetMyTeamProfile(user.UserID)
+	teamProfile, err := r.service.UserService.GetMyTeamProfile(c.Request.Context(), userID)
 	if err != nil {
+		if errors.Is(err, model.ErrNoTeam) {
+			response.Error(c, http.StatusNotFound, "you don't have a team", err)
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "failed to get team profile", err)
 		return
 	}
@@ -171,32 +411,59 @@ func (r *Rest) GetMyTeamProfile(c *gin.Context) {
 	response.Success(c, http.StatusOK, "success to get my team profile", teamProfile)
 }
 
+// ExportUserData sends the caller's data in the usual envelope, marked as an
+// attachment so a browser saves it as a file.
+func (r *Rest) ExportUserData(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	data, err := r.service.UserService.ExportUserData(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to export user data", err)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=user-data.json")
+	response.Success(c, http.StatusOK, "success to export user data", data)
+}
+
+func (r *Rest) GetLoginHistory(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	data, err := r.service.UserService.GetLoginHistory(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to get login history", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get login history", data)
+}
+
 func (r *Rest) ChangePassword(c *gin.Context) {
 	var param model.ForgotPasswordRequest
 	err := c.ShouldBindJSON(&param)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	token, err := r.service.UserService.ChangePassword(param.Email)
+	err = r.service.UserService.ChangePassword(c.Request.Context(), param.Email)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to send email verification", err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "success to send email verification password", token)
+	response.Success(c, http.StatusOK, "success to send email verification password", nil)
 }
 
 func (r *Rest) VerifyOtpChangePassword(c *gin.Context) {
 	var param model.VerifyToken
 	err := c.ShouldBindJSON(&param)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	err = r.service.UserService.VerifyOtpChangePassword(param)
+	err = r.service.UserService.VerifyOtpChangePassword(c.Request.Context(), param)
 
 	if err != nil {
 		if err.Error() == "invalid token" {
@@ -218,16 +485,22 @@ func (r *Rest) ChangePasswordAfterVerify(c *gin.Context) {
 	var param model.ResetPasswordRequest
 	err := c.ShouldBindJSON(&param)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	err = r.service.UserService.ChangePasswordAfterVerify(param)
+	err = r.service.UserService.ChangePasswordAfterVerify(c.Request.Context(), param)
 	if err != nil {
 		if err.Error() == "password mismatch" {
 			response.Error(c, http.StatusBadRequest, "please check your password", err)
 			return
-		} else if err.Error() == "new password cannot be same as old password" {
+		} else if err.Error() == "invalid token" {
+			response.Error(c, http.StatusBadRequest, "token is incorrect", err)
+			return
+		} else if err.Error() == "token expired" {
+			response.Error(c, http.StatusBadRequest, "token is already expired", err)
+			return
+		} else if errors.Is(err, model.ErrPasswordReused) {
 			response.Error(c, http.StatusBadRequest, "please use another password", err)
 			return
 		} else {
@@ -240,7 +513,7 @@ func (r *Rest) ChangePasswordAfterVerify(c *gin.Context) {
 }
 
 func (r *Rest) CompetitionRegistration(c *gin.Context) {
-	user := c.MustGet("user").(*entity.User)
+	userID := middleware.GetUserID(c)
 
 	competitionID := c.Param("competition_id")
 	idInt, err := strconv.Atoi(competitionID)
@@ -252,21 +525,65 @@ func (r *Rest) CompetitionRegistration(c *gin.Context) {
 	var param model.CompetitionRegistrationRequest
 	err = c.ShouldBindJSON(&param)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "failed to bind input", err)
+		bindError(c, err)
 		return
 	}
 
-	err = r.service.UserService.CompetitionRegistration(user.UserID, idInt, param)
+	data, err := r.service.UserService.CompetitionRegistration(c.Request.Context(), userID, idInt, param)
 	if err != nil {
+		var validationErr model.ValidationErrors
+		if errors.As(err, &validationErr) {
+			response.ValidationError(c, http.StatusUnprocessableEntity, "invalid registration data", validationErr)
+			return
+		} else if errors.Is(err, model.ErrNoTeam) {
+			response.Error(c, http.StatusNotFound, "you don't have a team", err)
+			return
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "competition not found", err)
+			return
+		} else if errors.Is(err, model.ErrRegistrationNotOpen) || errors.Is(err, model.ErrRegistrationClosed) || errors.Is(err, model.ErrRegistrationPaused) {
+			response.Error(c, http.StatusForbidden, "registration is not available", err)
+			return
+		} else if errors.Is(err, model.ErrCompetitionLocked) {
+			response.Error(c, http.StatusConflict, "cannot change competition", err)
+			return
+		} else if errors.Is(err, model.ErrTeamNameTaken) {
+			response.Error(c, http.StatusConflict, "another team in that competition has the same name, please rename your team first", err)
+			return
+		} else if errors.Is(err, model.ErrCouponNotFound) {
+			response.Error(c, http.StatusNotFound, "coupon not found", err)
+			return
+		} else if errors.Is(err, model.ErrCouponExpired) || errors.Is(err, model.ErrCouponExhausted) {
+			response.Error(c, http.StatusGone, "coupon can no longer be used", err)
+			return
+		} else if errors.Is(err, model.ErrCouponCompetitionMismatch) {
+			response.Error(c, http.StatusUnprocessableEntity, "coupon is not valid for this competition", err)
+			return
+		} else if errors.Is(err, model.ErrCouponAlreadyApplied) {
+			response.Error(c, http.StatusConflict, "your team has already used a coupon", err)
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "failed to register competition", err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "success to register competition", nil)
+	if data.Waitlisted {
+		response.Success(c, http.StatusOK, "competition is full, your team is on the waitlist", data)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to register competition", data)
 }
 
 func (r *Rest) GetUserPaymentStatus(c *gin.Context) {
-	res, err := r.service.UserService.GetUserPaymentStatus()
+	var page model.PaginationQuery
+	err := c.ShouldBindQuery(&page)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	res, err := r.service.UserService.GetUserPaymentStatus(c.Request.Context(), page)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to get user payment status", err)
 		return
@@ -276,7 +593,7 @@ func (r *Rest) GetUserPaymentStatus(c *gin.Context) {
 }
 
 func (r *Rest) GetTotalParticipant(c *gin.Context) {
-	res, err := r.service.UserService.GetTotalParticipant()
+	res, err := r.service.UserService.GetTotalParticipant(c.Request.Context())
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to get total participant", err)
 		return
@@ -284,3 +601,84 @@ func (r *Rest) GetTotalParticipant(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, "success to get total participant", res)
 }
+
+func (r *Rest) ListRoles(c *gin.Context) {
+	roles, err := r.service.UserService.ListRoles(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to get roles", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get roles", roles)
+}
+
+func (r *Rest) GetUserRole(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "user ID is invalid", err)
+		return
+	}
+
+	userRole, err := r.service.UserService.GetUserRole(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "user not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to get user role", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get user role", userRole)
+}
+
+func (r *Rest) GetUserLoginHistory(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "user ID is invalid", err)
+		return
+	}
+
+	data, err := r.service.UserService.GetLoginHistory(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to get login history", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to get login history", data)
+}
+
+func (r *Rest) AssignRole(c *gin.Context) {
+	actorID := middleware.GetUserID(c)
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "user ID is invalid", err)
+		return
+	}
+
+	var req model.AssignRoleRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+
+	err = r.service.UserService.AssignRole(c.Request.Context(), actorID, userID, req)
+	if err != nil {
+		if errors.Is(err, model.ErrRoleNotFound) {
+			response.Error(c, http.StatusBadRequest, err.Error(), err)
+			return
+		} else if errors.Is(err, model.ErrOwnRoleChange) {
+			response.Error(c, http.StatusForbidden, err.Error(), err)
+			return
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, "user not found", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "failed to assign role", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success to assign role", nil)
+}
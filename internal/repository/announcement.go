@@ -1,16 +1,28 @@
 package repository
 
 import (
+	"context"
 	"itfest-2025/entity"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type IAnnouncementRepository interface {
 	CreateAnnouncement(tx *gorm.DB, req entity.Announcement) error
-	GetAnnouncement() ([]*entity.Announcement, error)
+	GetAnnouncement(ctx context.Context) ([]*entity.Announcement, error)
+	GetAnnouncementByID(tx *gorm.DB, announcementID uuid.UUID) (*entity.Announcement, error)
+	UpdateAnnouncement(tx *gorm.DB, announcement *entity.Announcement) error
+	DeleteAnnouncement(tx *gorm.DB, announcementID uuid.UUID) error
+	ListPublishedAnnouncements(ctx context.Context, competitionID int, now time.Time, since *time.Time, offset int, limit int) ([]*entity.Announcement, int64, error)
+	CountPublishedAnnouncements(ctx context.Context, competitionID int, now time.Time, since *time.Time) (int64, error)
 }
 
+// publishedAtColumn treats announcements created before published_at existed as
+// published when they were created.
+const publishedAtColumn = "COALESCE(published_at, created_at)"
+
 type AnnouncementRepository struct {
 	db *gorm.DB
 }
@@ -30,12 +42,90 @@ func (r *AnnouncementRepository) CreateAnnouncement(tx *gorm.DB, req entity.Anno
 	return nil
 }
 
-func (r *AnnouncementRepository) GetAnnouncement() ([]*entity.Announcement, error) {
+func (r *AnnouncementRepository) GetAnnouncement(ctx context.Context) ([]*entity.Announcement, error) {
 	var announcement []*entity.Announcement
-	err := r.db.Debug().Find(&announcement).Error
+	err := r.db.WithContext(ctx).Debug().Find(&announcement).Error
 	if err != nil {
 		return nil, err
 	}
 
 	return announcement, nil
 }
+
+func (r *AnnouncementRepository) GetAnnouncementByID(tx *gorm.DB, announcementID uuid.UUID) (*entity.Announcement, error) {
+	var announcement entity.Announcement
+	err := tx.Debug().Where("announcement_id = ?", announcementID).First(&announcement).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &announcement, nil
+}
+
+func (r *AnnouncementRepository) UpdateAnnouncement(tx *gorm.DB, announcement *entity.Announcement) error {
+	err := tx.Debug().Save(announcement).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *AnnouncementRepository) DeleteAnnouncement(tx *gorm.DB, announcementID uuid.UUID) error {
+	result := tx.Debug().Where("announcement_id = ?", announcementID).Delete(&entity.Announcement{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ListPublishedAnnouncements returns the newest announcements published by now
+// that target everyone or the given competition, with the total count. A
+// non-nil since leaves out those published at or before it.
+func (r *AnnouncementRepository) ListPublishedAnnouncements(ctx context.Context, competitionID int, now time.Time, since *time.Time, offset int, limit int) ([]*entity.Announcement, int64, error) {
+	var (
+		announcements []*entity.Announcement
+		total         int64
+	)
+
+	query := r.publishedAnnouncements(ctx, competitionID, now, since).Session(&gorm.Session{})
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = query.Order(publishedAtColumn + " DESC").Offset(offset).Limit(limit).Find(&announcements).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return announcements, total, nil
+}
+
+// CountPublishedAnnouncements counts what ListPublishedAnnouncements would list.
+func (r *AnnouncementRepository) CountPublishedAnnouncements(ctx context.Context, competitionID int, now time.Time, since *time.Time) (int64, error) {
+	var total int64
+	err := r.publishedAnnouncements(ctx, competitionID, now, since).Count(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *AnnouncementRepository) publishedAnnouncements(ctx context.Context, competitionID int, now time.Time, since *time.Time) *gorm.DB {
+	query := r.db.WithContext(ctx).Debug().Model(&entity.Announcement{}).
+		Where(publishedAtColumn+" <= ?", now).
+		Where("competition_id IS NULL OR competition_id = ?", competitionID)
+	if since != nil {
+		query = query.Where(publishedAtColumn+" > ?", *since)
+	}
+
+	return query
+}
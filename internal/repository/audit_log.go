@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"itfest-2025/entity"
+	"itfest-2025/model"
+
+	"gorm.io/gorm"
+)
+
+type IAuditLogRepository interface {
+	CreateAuditLog(tx *gorm.DB, auditLog *entity.AuditLog) error
+	ListAuditLogs(ctx context.Context, query model.AuditLogQuery) ([]*entity.AuditLog, int64, error)
+}
+
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) IAuditLogRepository {
+	return &AuditLogRepository{
+		db: db,
+	}
+}
+
+func (a *AuditLogRepository) CreateAuditLog(tx *gorm.DB, auditLog *entity.AuditLog) error {
+	err := tx.Debug().Create(auditLog).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListAuditLogs returns the newest entries matching the query's filters, with
+// the total count.
+func (a *AuditLogRepository) ListAuditLogs(ctx context.Context, query model.AuditLogQuery) ([]*entity.AuditLog, int64, error) {
+	var (
+		auditLogs []*entity.AuditLog
+		total     int64
+	)
+
+	db := a.db.WithContext(ctx).Debug().Model(&entity.AuditLog{})
+	if query.ActorID != "" {
+		db = db.Where("actor_id = ?", query.ActorID)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	db = db.Session(&gorm.Session{})
+
+	err := db.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = db.Order("created_at DESC").Offset(query.Offset()).Limit(query.Limit).Find(&auditLogs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return auditLogs, total, nil
+}
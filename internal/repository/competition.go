@@ -1,14 +1,23 @@
 package repository
 
 import (
+	"encoding/json"
 	"itfest-2025/entity"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ICompetitionRepository interface {
 	GetCompetitionByID(tx *gorm.DB, competitionID int) (*entity.Competition, error)
+	GetCompetitionForUpdate(tx *gorm.DB, competitionID int) (*entity.Competition, error)
 	GetAllCompetitions(tx *gorm.DB) ([]*entity.Competition, error)
+	UpdateCompetitionFee(tx *gorm.DB, competitionID int, fee int) error
+	UpdateCompetitionCapacity(tx *gorm.DB, competitionID int, capacity int) error
+	UpdateRegistrationStatus(tx *gorm.DB, competitionID int, isOpen bool) error
+	UpdateExtraFields(tx *gorm.DB, competitionID int, extraFields json.RawMessage) error
+	CompetitionExists(tx *gorm.DB, competitionID int) (bool, error)
+	GetStagesByCompetition(tx *gorm.DB, competitionID int) ([]entity.Stages, error)
 }
 
 type CompetitionRepository struct {
@@ -32,6 +41,45 @@ func (c *CompetitionRepository) GetCompetitionByID(tx *gorm.DB, competitionID in
 	return competition, nil
 }
 
+// GetCompetitionForUpdate locks the competition row until tx ends, so callers
+// that check and then fill its capacity run one at a time.
+func (c *CompetitionRepository) GetCompetitionForUpdate(tx *gorm.DB, competitionID int) (*entity.Competition, error) {
+	var competition *entity.Competition
+
+	err := tx.Debug().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("competition_id = ?", competitionID).
+		First(&competition).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return competition, nil
+}
+
+func (c *CompetitionRepository) CompetitionExists(tx *gorm.DB, competitionID int) (bool, error) {
+	var count int64
+
+	err := tx.Model(&entity.Competition{}).Where("competition_id = ?", competitionID).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (c *CompetitionRepository) GetStagesByCompetition(tx *gorm.DB, competitionID int) ([]entity.Stages, error) {
+	stages := []entity.Stages{}
+
+	err := tx.Where("competition_id = ?", competitionID).
+		Order("stage_order ASC").
+		Find(&stages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stages, nil
+}
+
 func (c *CompetitionRepository) GetAllCompetitions(tx *gorm.DB) ([]*entity.Competition, error) {
 	var competitions []*entity.Competition
 
@@ -42,3 +90,27 @@ func (c *CompetitionRepository) GetAllCompetitions(tx *gorm.DB) ([]*entity.Compe
 
 	return competitions, nil
 }
+
+func (c *CompetitionRepository) UpdateCompetitionFee(tx *gorm.DB, competitionID int, fee int) error {
+	return tx.Debug().Model(&entity.Competition{}).
+		Where("competition_id = ?", competitionID).
+		Update("fee", fee).Error
+}
+
+func (c *CompetitionRepository) UpdateCompetitionCapacity(tx *gorm.DB, competitionID int, capacity int) error {
+	return tx.Debug().Model(&entity.Competition{}).
+		Where("competition_id = ?", competitionID).
+		Update("capacity", capacity).Error
+}
+
+func (c *CompetitionRepository) UpdateRegistrationStatus(tx *gorm.DB, competitionID int, isOpen bool) error {
+	return tx.Debug().Model(&entity.Competition{}).
+		Where("competition_id = ?", competitionID).
+		Update("is_registration_open", isOpen).Error
+}
+
+func (c *CompetitionRepository) UpdateExtraFields(tx *gorm.DB, competitionID int, extraFields json.RawMessage) error {
+	return tx.Debug().Model(&entity.Competition{}).
+		Where("competition_id = ?", competitionID).
+		Update("extra_fields", extraFields).Error
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"itfest-2025/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ICouponRepository interface {
+	GetCouponByCode(tx *gorm.DB, code string) (*entity.Coupon, error)
+	GetCouponByID(tx *gorm.DB, couponID uuid.UUID) (*entity.Coupon, error)
+	GetCouponsByIDs(tx *gorm.DB, couponIDs []uuid.UUID) ([]*entity.Coupon, error)
+	CreateCoupon(tx *gorm.DB, coupon *entity.Coupon) error
+	RedeemCoupon(tx *gorm.DB, couponID uuid.UUID, now time.Time) (bool, error)
+}
+
+type CouponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository(db *gorm.DB) ICouponRepository {
+	return &CouponRepository{
+		db: db,
+	}
+}
+
+func (c *CouponRepository) GetCouponByCode(tx *gorm.DB, code string) (*entity.Coupon, error) {
+	var coupon entity.Coupon
+	err := tx.Debug().Where("code = ?", code).First(&coupon).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+func (c *CouponRepository) GetCouponByID(tx *gorm.DB, couponID uuid.UUID) (*entity.Coupon, error) {
+	var coupon entity.Coupon
+	err := tx.Debug().Where("coupon_id = ?", couponID).First(&coupon).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+func (c *CouponRepository) GetCouponsByIDs(tx *gorm.DB, couponIDs []uuid.UUID) ([]*entity.Coupon, error) {
+	var coupons []*entity.Coupon
+	if len(couponIDs) == 0 {
+		return coupons, nil
+	}
+
+	err := tx.Debug().Where("coupon_id IN ?", couponIDs).Find(&coupons).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return coupons, nil
+}
+
+func (c *CouponRepository) CreateCoupon(tx *gorm.DB, coupon *entity.Coupon) error {
+	err := tx.Debug().Create(coupon).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RedeemCoupon uses up one redemption of the coupon. The limit and expiry are
+// checked in the same UPDATE so concurrent redemptions can't exceed the limit;
+// it reports false when the coupon had no uses left or had expired.
+func (c *CouponRepository) RedeemCoupon(tx *gorm.DB, couponID uuid.UUID, now time.Time) (bool, error) {
+	result := tx.Debug().Model(&entity.Coupon{}).
+		Where("coupon_id = ? AND used_count < usage_limit AND (expires_at IS NULL OR expires_at > ?)", couponID, now).
+		Update("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
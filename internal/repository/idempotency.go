@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"itfest-2025/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type IIdempotencyRepository interface {
+	GetIdempotencyKey(tx *gorm.DB, scope string, userID uuid.UUID, key string, after time.Time) (*entity.IdempotencyKey, error)
+	CreateIdempotencyKey(tx *gorm.DB, idempotencyKey *entity.IdempotencyKey) error
+	DeleteExpiredIdempotencyKeys(tx *gorm.DB, before time.Time) error
+}
+
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IIdempotencyRepository {
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+func (i *IdempotencyRepository) GetIdempotencyKey(tx *gorm.DB, scope string, userID uuid.UUID, key string, after time.Time) (*entity.IdempotencyKey, error) {
+	var idempotencyKey entity.IdempotencyKey
+	err := tx.Debug().
+		Where("scope = ? AND user_id = ? AND `key` = ? AND created_at > ?", scope, userID, key, after).
+		First(&idempotencyKey).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &idempotencyKey, nil
+}
+
+func (i *IdempotencyRepository) CreateIdempotencyKey(tx *gorm.DB, idempotencyKey *entity.IdempotencyKey) error {
+	err := tx.Debug().Create(idempotencyKey).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (i *IdempotencyRepository) DeleteExpiredIdempotencyKeys(tx *gorm.DB, before time.Time) error {
+	err := tx.Debug().Where("created_at <= ?", before).Delete(&entity.IdempotencyKey{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"itfest-2025/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ILoginFingerprintRepository interface {
+	GetLoginFingerprint(tx *gorm.DB, userID uuid.UUID, ipAddress string) (*entity.LoginFingerprint, error)
+	CountLoginFingerprints(tx *gorm.DB, userID uuid.UUID) (int64, error)
+	GetLoginFingerprints(tx *gorm.DB, userID uuid.UUID) ([]entity.LoginFingerprint, error)
+	SaveLoginFingerprint(tx *gorm.DB, fingerprint *entity.LoginFingerprint) error
+	DeleteStaleLoginFingerprints(tx *gorm.DB, userID uuid.UUID, keep int) error
+}
+
+type LoginFingerprintRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginFingerprintRepository(db *gorm.DB) ILoginFingerprintRepository {
+	return &LoginFingerprintRepository{
+		db: db,
+	}
+}
+
+func (l *LoginFingerprintRepository) GetLoginFingerprint(tx *gorm.DB, userID uuid.UUID, ipAddress string) (*entity.LoginFingerprint, error) {
+	var fingerprint entity.LoginFingerprint
+	err := tx.Debug().Where("user_id = ? AND ip_address = ?", userID, ipAddress).First(&fingerprint).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &fingerprint, nil
+}
+
+func (l *LoginFingerprintRepository) CountLoginFingerprints(tx *gorm.DB, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := tx.Debug().Model(&entity.LoginFingerprint{}).Where("user_id = ?", userID).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetLoginFingerprints returns a user's fingerprints, most recently seen first.
+func (l *LoginFingerprintRepository) GetLoginFingerprints(tx *gorm.DB, userID uuid.UUID) ([]entity.LoginFingerprint, error) {
+	var fingerprints []entity.LoginFingerprint
+	err := tx.Debug().Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&fingerprints).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return fingerprints, nil
+}
+
+func (l *LoginFingerprintRepository) SaveLoginFingerprint(tx *gorm.DB, fingerprint *entity.LoginFingerprint) error {
+	err := tx.Debug().Save(fingerprint).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteStaleLoginFingerprints keeps only the keep most recently seen
+// fingerprints of a user.
+func (l *LoginFingerprintRepository) DeleteStaleLoginFingerprints(tx *gorm.DB, userID uuid.UUID, keep int) error {
+	var recent []uuid.UUID
+	err := tx.Debug().Model(&entity.LoginFingerprint{}).
+		Where("user_id = ?", userID).
+		Order("last_seen_at DESC").
+		Limit(keep).
+		Pluck("login_fingerprint_id", &recent).Error
+	if err != nil {
+		return err
+	}
+
+	if len(recent) == 0 {
+		return nil
+	}
+
+	return tx.Debug().
+		Where("user_id = ? AND login_fingerprint_id NOT IN ?", userID, recent).
+		Delete(&entity.LoginFingerprint{}).Error
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"itfest-2025/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ILoginHistoryRepository interface {
+	CreateLoginHistory(tx *gorm.DB, entry *entity.LoginHistory) error
+	GetLoginHistory(tx *gorm.DB, userID uuid.UUID, limit int) ([]entity.LoginHistory, error)
+	DeleteLoginHistoryBefore(tx *gorm.DB, before time.Time) (int64, error)
+}
+
+type LoginHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginHistoryRepository(db *gorm.DB) ILoginHistoryRepository {
+	return &LoginHistoryRepository{
+		db: db,
+	}
+}
+
+func (l *LoginHistoryRepository) CreateLoginHistory(tx *gorm.DB, entry *entity.LoginHistory) error {
+	return tx.Debug().Create(entry).Error
+}
+
+// GetLoginHistory returns the limit most recent logins of a user, newest first.
+func (l *LoginHistoryRepository) GetLoginHistory(tx *gorm.DB, userID uuid.UUID, limit int) ([]entity.LoginHistory, error) {
+	history := []entity.LoginHistory{}
+	err := tx.Debug().
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&history).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// DeleteLoginHistoryBefore removes logins older than before and returns how many
+// were removed.
+func (l *LoginHistoryRepository) DeleteLoginHistoryBefore(tx *gorm.DB, before time.Time) (int64, error) {
+	result := tx.Debug().Where("created_at < ?", before).Delete(&entity.LoginHistory{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"itfest-2025/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type INotificationRepository interface {
+	CreateNotification(tx *gorm.DB, notification *entity.Notification) error
+	ListNotifications(ctx context.Context, userID uuid.UUID, offset int, limit int) ([]entity.Notification, int64, error)
+	CountUnreadNotifications(tx *gorm.DB, userID uuid.UUID) (int64, error)
+	MarkNotificationRead(tx *gorm.DB, notificationID uuid.UUID, userID uuid.UUID, now time.Time) (bool, error)
+}
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) INotificationRepository {
+	return &NotificationRepository{
+		db: db,
+	}
+}
+
+func (n *NotificationRepository) CreateNotification(tx *gorm.DB, notification *entity.Notification) error {
+	return tx.Debug().Create(notification).Error
+}
+
+// ListNotifications returns a page of the user's notifications, newest first,
+// with the total count.
+func (n *NotificationRepository) ListNotifications(ctx context.Context, userID uuid.UUID, offset int, limit int) ([]entity.Notification, int64, error) {
+	var (
+		notifications []entity.Notification
+		total         int64
+	)
+
+	query := n.db.WithContext(ctx).Debug().Model(&entity.Notification{}).
+		Where("user_id = ?", userID).
+		Session(&gorm.Session{})
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = query.Order("created_at DESC").Order("notification_id DESC").Offset(offset).Limit(limit).Find(&notifications).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+func (n *NotificationRepository) CountUnreadNotifications(tx *gorm.DB, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := tx.Debug().Model(&entity.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// MarkNotificationRead marks one of the user's notifications read, keeping the
+// first read time. It reports false when the user has no such notification.
+func (n *NotificationRepository) MarkNotificationRead(tx *gorm.DB, notificationID uuid.UUID, userID uuid.UUID, now time.Time) (bool, error) {
+	var count int64
+	err := tx.Debug().Model(&entity.Notification{}).
+		Where("notification_id = ? AND user_id = ?", notificationID, userID).
+		Count(&count).Error
+	if err != nil || count == 0 {
+		return false, err
+	}
+
+	err = tx.Debug().Model(&entity.Notification{}).
+		Where("notification_id = ? AND read_at IS NULL", notificationID).
+		Update("read_at", now).Error
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -24,9 +24,11 @@ func NewOtpRepository(db *gorm.DB) IOtpRepository {
 	}
 }
 
+// GetOtp returns the newest OTP matching param, so the result is deterministic
+// even if older rows were left behind.
 func (o *OtpRepository) GetOtp(tx *gorm.DB, param model.GetOtp) (*entity.OtpCode, error) {
 	var otp *entity.OtpCode
-	err := tx.Debug().Where(&param).First(&otp).Error
+	err := tx.Debug().Where(&param).Order("created_at DESC").First(&otp).Error
 	if err != nil {
 		return nil, err
 	}
@@ -34,8 +36,15 @@ func (o *OtpRepository) GetOtp(tx *gorm.DB, param model.GetOtp) (*entity.OtpCode
 	return otp, nil
 }
 
+// CreateOtp replaces any OTP the user already has for the same purpose, keeping
+// at most one code per user and purpose.
 func (o *OtpRepository) CreateOtp(tx *gorm.DB, otp *entity.OtpCode) error {
-	err := tx.Debug().Create(otp).Error
+	err := tx.Debug().Where("user_id = ? AND purpose = ?", otp.UserID, otp.Purpose).Delete(&entity.OtpCode{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Debug().Create(otp).Error
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"itfest-2025/entity"
+	"itfest-2025/model"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestTx opens a gorm transaction on sqlmock, the way services hand one to
+// the repositories.
+func newTestTx(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	t.Cleanup(func() {
+		sqlDB.Close()
+	})
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open gorm: %v", err)
+	}
+
+	mock.ExpectBegin()
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("failed to begin: %v", tx.Error)
+	}
+
+	return tx, mock
+}
+
+func TestCreateOtpReplacesCodeForSamePurpose(t *testing.T) {
+	tx, mock := newTestTx(t)
+	repo := NewOtpRepository(nil)
+	otp := &entity.OtpCode{
+		OtpID:   uuid.New(),
+		UserID:  uuid.New(),
+		Code:    "123456",
+		Purpose: model.OtpPurposeReset,
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `otp_codes` WHERE user_id = ? AND purpose = ?")).
+		WithArgs(otp.UserID, model.OtpPurposeReset).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `otp_codes`")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.CreateOtp(tx, otp); err != nil {
+		t.Fatalf("CreateOtp() error = %v, want nil", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetOtpReturnsNewest(t *testing.T) {
+	tx, mock := newTestTx(t)
+	repo := NewOtpRepository(nil)
+	userID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `otp_codes` WHERE `otp_codes`.`user_id` = ? AND `otp_codes`.`purpose` = ? ORDER BY created_at DESC")).
+		WithArgs(userID, model.OtpPurposeVerify, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"otp_id", "user_id", "code", "purpose"}).
+			AddRow(uuid.New(), userID, "654321", model.OtpPurposeVerify))
+
+	otp, err := repo.GetOtp(tx, model.GetOtp{UserID: userID, Purpose: model.OtpPurposeVerify})
+	if err != nil {
+		t.Fatalf("GetOtp() error = %v, want nil", err)
+	}
+	if otp.Code != "654321" {
+		t.Errorf("GetOtp() code = %q, want %q", otp.Code, "654321")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"itfest-2025/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type IPasswordHistoryRepository interface {
+	GetRecentPasswordHashes(tx *gorm.DB, userID uuid.UUID, limit int) ([]string, error)
+	CreatePasswordHistory(tx *gorm.DB, history *entity.PasswordHistory) error
+	DeleteStalePasswordHistory(tx *gorm.DB, userID uuid.UUID, keep int) error
+}
+
+type PasswordHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) IPasswordHistoryRepository {
+	return &PasswordHistoryRepository{
+		db: db,
+	}
+}
+
+func (p *PasswordHistoryRepository) GetRecentPasswordHashes(tx *gorm.DB, userID uuid.UUID, limit int) ([]string, error) {
+	var hashes []string
+	err := tx.Debug().Model(&entity.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("password_hash", &hashes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+func (p *PasswordHistoryRepository) CreatePasswordHistory(tx *gorm.DB, history *entity.PasswordHistory) error {
+	err := tx.Debug().Create(history).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteStalePasswordHistory keeps only the keep most recent entries of a user.
+func (p *PasswordHistoryRepository) DeleteStalePasswordHistory(tx *gorm.DB, userID uuid.UUID, keep int) error {
+	var recent []uuid.UUID
+	err := tx.Debug().Model(&entity.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(keep).
+		Pluck("password_history_id", &recent).Error
+	if err != nil {
+		return err
+	}
+
+	if len(recent) == 0 {
+		return nil
+	}
+
+	return tx.Debug().
+		Where("user_id = ? AND password_history_id NOT IN ?", userID, recent).
+		Delete(&entity.PasswordHistory{}).Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"itfest-2025/entity"
+	"itfest-2025/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IReminderRepository interface {
+	GetDueDeadlineReminders(ctx context.Context, from time.Time, to time.Time) ([]model.DeadlineReminder, error)
+	CreateStageReminder(tx *gorm.DB, reminder *entity.StageReminder) error
+}
+
+type ReminderRepository struct {
+	db *gorm.DB
+}
+
+func NewReminderRepository(db *gorm.DB) IReminderRepository {
+	return &ReminderRepository{
+		db: db,
+	}
+}
+
+// GetDueDeadlineReminders returns the verified teams that haven't submitted for
+// a stage whose deadline is in (from, to] and haven't been reminded about it.
+func (r *ReminderRepository) GetDueDeadlineReminders(ctx context.Context, from time.Time, to time.Time) ([]model.DeadlineReminder, error) {
+	reminders := []model.DeadlineReminder{}
+
+	err := r.db.WithContext(ctx).Debug().
+		Table("stages").
+		Select("teams.team_id, teams.team_name, users.user_id, users.full_name, users.email, stages.stage_id, stages.stage_name, stages.deadline").
+		Joins("JOIN teams ON teams.competition_id = stages.competition_id AND teams.deleted_at IS NULL").
+		Joins("JOIN users ON users.user_id = teams.user_id AND users.deleted_at IS NULL").
+		Joins("LEFT JOIN team_progresses ON team_progresses.team_id = teams.team_id AND team_progresses.stage_id = stages.stage_id").
+		Joins("LEFT JOIN stage_reminders ON stage_reminders.team_id = teams.team_id AND stage_reminders.stage_id = stages.stage_id").
+		Where("stages.deadline > ? AND stages.deadline <= ?", from, to).
+		Where("teams.team_status = ?", "terverifikasi").
+		Where("team_progresses.team_progress_id IS NULL AND stage_reminders.team_id IS NULL").
+		Scan(&reminders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+func (r *ReminderRepository) CreateStageReminder(tx *gorm.DB, reminder *entity.StageReminder) error {
+	err := tx.Debug().Create(reminder).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -3,21 +3,41 @@ package repository
 import "gorm.io/gorm"
 
 type Repository struct {
-	UserRepository        IUserRepository
-	TeamRepository        ITeamRepository
-	OtpRepository         IOtpRepository
-	CompetitionRepository ICompetitionRepository
-	SubmissionRepository  ISubmissionRepository
-	AnnouncementRepository  IAnnouncementRepository
+	UserRepository             IUserRepository
+	TeamRepository             ITeamRepository
+	OtpRepository              IOtpRepository
+	CompetitionRepository      ICompetitionRepository
+	SubmissionRepository       ISubmissionRepository
+	AnnouncementRepository     IAnnouncementRepository
+	IdempotencyRepository      IIdempotencyRepository
+	LoginFingerprintRepository ILoginFingerprintRepository
+	LoginHistoryRepository     ILoginHistoryRepository
+	SupportMessageRepository   ISupportMessageRepository
+	CouponRepository           ICouponRepository
+	PasswordHistoryRepository  IPasswordHistoryRepository
+	AuditLogRepository         IAuditLogRepository
+	ReminderRepository         IReminderRepository
+	RoleRepository             IRoleRepository
+	NotificationRepository     INotificationRepository
 }
 
 func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{
-		UserRepository:        NewUserRepository(db),
-		TeamRepository:        NewTeamRepository(db),
-		OtpRepository:         NewOtpRepository(db),
-		CompetitionRepository: NewCompetitionRepository(db),
-		SubmissionRepository:  NewSubmissionRepository(db),
-		AnnouncementRepository:  NewAnnouncementRepository(db),
+		UserRepository:             NewUserRepository(db),
+		TeamRepository:             NewTeamRepository(db),
+		OtpRepository:              NewOtpRepository(db),
+		CompetitionRepository:      NewCompetitionRepository(db),
+		SubmissionRepository:       NewSubmissionRepository(db),
+		AnnouncementRepository:     NewAnnouncementRepository(db),
+		IdempotencyRepository:      NewIdempotencyRepository(db),
+		LoginFingerprintRepository: NewLoginFingerprintRepository(db),
+		LoginHistoryRepository:     NewLoginHistoryRepository(db),
+		SupportMessageRepository:   NewSupportMessageRepository(db),
+		CouponRepository:           NewCouponRepository(db),
+		PasswordHistoryRepository:  NewPasswordHistoryRepository(db),
+		AuditLogRepository:         NewAuditLogRepository(db),
+		ReminderRepository:         NewReminderRepository(db),
+		RoleRepository:             NewRoleRepository(db),
+		NotificationRepository:     NewNotificationRepository(db),
 	}
 }
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"itfest-2025/entity"
+
+	"gorm.io/gorm"
+)
+
+type IRoleRepository interface {
+	GetRoles(ctx context.Context) ([]entity.Role, error)
+	GetRoleByID(tx *gorm.DB, roleID int) (*entity.Role, error)
+	GetRoleByName(tx *gorm.DB, roleName string) (*entity.Role, error)
+	HasPermission(ctx context.Context, roleID int, permission string) (bool, error)
+}
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) IRoleRepository {
+	return &RoleRepository{
+		db: db,
+	}
+}
+
+func (r *RoleRepository) GetRoles(ctx context.Context) ([]entity.Role, error) {
+	var roles []entity.Role
+	err := r.db.WithContext(ctx).Debug().Preload("Permissions").Order("role_id ASC").Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepository) GetRoleByID(tx *gorm.DB, roleID int) (*entity.Role, error) {
+	var role entity.Role
+	err := tx.Debug().Where("role_id = ?", roleID).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *RoleRepository) GetRoleByName(tx *gorm.DB, roleName string) (*entity.Role, error) {
+	var role entity.Role
+	err := tx.Debug().Where("role_name = ?", roleName).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *RoleRepository) HasPermission(ctx context.Context, roleID int, permission string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Debug().Model(&entity.RolePermission{}).
+		Where("role_id = ? AND permission = ?", roleID, permission).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -1,21 +1,30 @@
 package repository
 
 import (
+	"context"
 	"itfest-2025/entity"
 	"itfest-2025/model"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type ISubmissionRepository interface {
-	GetSubmission(req *model.ReqFilterSubmission) ([]entity.TeamProgress, error)
-	GetFirstStage(competitionID int) (entity.Stages, error)
-	GetNextStage(currentOrder int, competitionID int) (entity.Stages, error)
-	GetCurrentStage(team *entity.Team) (entity.TeamProgress, error)
+	GetSubmission(ctx context.Context, req *model.ReqFilterSubmission) ([]entity.TeamProgress, error)
+	GetFirstStage(ctx context.Context, competitionID int) (entity.Stages, error)
+	GetNextStage(ctx context.Context, currentOrder int, competitionID int) (entity.Stages, error)
+	GetCurrentStage(ctx context.Context, team *entity.Team) (entity.TeamProgress, error)
 	CreateSubmission(tx *gorm.DB, submission *entity.TeamProgress) error
 	GetStage(tx *gorm.DB, currentID int) (entity.Stages, error)
 	GetSubmissionAllStage(tx *gorm.DB, teamID uuid.UUID, competitionID int) ([]model.Stages, error)
+	GetStageProgress(tx *gorm.DB, teamID uuid.UUID, competitionID int) ([]model.StageProgress, error)
+	GetSubmissionHistory(tx *gorm.DB, teamID uuid.UUID) ([]model.SubmissionHistory, error)
+	GetSubmissionByID(tx *gorm.DB, teamProgressID int) (*entity.TeamProgress, error)
+	GetLatestSubmission(tx *gorm.DB, stageID int, teamID uuid.UUID) (*entity.TeamProgress, error)
+	GradeSubmission(tx *gorm.DB, teamProgressID int, score float64, feedback string, gradedAt time.Time) error
+	GetStageLeaderboard(tx *gorm.DB, stageID int) ([]model.StageLeaderboardEntry, error)
+	GetLeaderboard(tx *gorm.DB, competitionID int, deadlineBefore *time.Time, offset int, limit int) ([]model.LeaderboardEntry, int64, error)
 	UpdateStatusSubmission(tx *gorm.DB, teamID string, stageID string, req model.RequestUpdateStatusSubmission) error
 }
 
@@ -29,9 +38,9 @@ func NewSubmissionRepository(db *gorm.DB) ISubmissionRepository {
 	}
 }
 
-func (r *SubmissionRepository) GetSubmission(req *model.ReqFilterSubmission) ([]entity.TeamProgress, error) {
+func (r *SubmissionRepository) GetSubmission(ctx context.Context, req *model.ReqFilterSubmission) ([]entity.TeamProgress, error) {
 	var dataSubmission []entity.TeamProgress
-	query := r.db
+	query := r.db.WithContext(ctx)
 	if req.Status != "" {
 		query = query.Where("status = ?", req.Status)
 	}
@@ -45,32 +54,32 @@ func (r *SubmissionRepository) GetSubmission(req *model.ReqFilterSubmission) ([]
 	return dataSubmission, err
 }
 
-func (r *SubmissionRepository) GetFirstStage(competitionID int) (entity.Stages, error) {
+func (r *SubmissionRepository) GetFirstStage(ctx context.Context, competitionID int) (entity.Stages, error) {
 	var stage entity.Stages
-	err := r.db.Where("competition_id = ?", competitionID).
+	err := r.db.WithContext(ctx).Where("competition_id = ?", competitionID).
 		Order("stage_order ASC").
 		First(&stage).Error
 	return stage, err
 }
 
-func (r *SubmissionRepository) GetNextStage(currentID int, competitionID int) (entity.Stages, error) {
+func (r *SubmissionRepository) GetNextStage(ctx context.Context, currentID int, competitionID int) (entity.Stages, error) {
 	var stage entity.Stages
 	var currentStage entity.Stages
-	err := r.db.First(&currentStage, currentID).Error
+	err := r.db.WithContext(ctx).First(&currentStage, currentID).Error
 	if err != nil {
 		return entity.Stages{}, err
 	}
 
-	err = r.db.Where("competition_id = ? AND stage_order > ?", competitionID, currentStage.StageOrder).
+	err = r.db.WithContext(ctx).Where("competition_id = ? AND stage_order > ?", competitionID, currentStage.StageOrder).
 		Order("stage_order ASC").
 		First(&stage).Error
 	return stage, err
 }
 
-func (t *SubmissionRepository) GetCurrentStage(team *entity.Team) (entity.TeamProgress, error) {
+func (t *SubmissionRepository) GetCurrentStage(ctx context.Context, team *entity.Team) (entity.TeamProgress, error) {
 	var progress entity.TeamProgress
 
-	if err := t.db.
+	if err := t.db.WithContext(ctx).
 		Joins("JOIN stages ON stages.stage_id = team_progresses.stage_id").
 		Where("team_id = ?", team.TeamID).
 		Order("stages.stage_order DESC").
@@ -118,6 +127,141 @@ func (t *SubmissionRepository) GetSubmissionAllStage(tx *gorm.DB, teamID uuid.UU
 	return stages, nil
 }
 
+func (t *SubmissionRepository) GetStageProgress(tx *gorm.DB, teamID uuid.UUID, competitionID int) ([]model.StageProgress, error) {
+	stages := []model.StageProgress{}
+
+	err := tx.Debug().
+		Table("stages").
+		Select("stages.stage_id, stages.stage_name, stages.stage_order, stages.deadline, "+
+			"team_progresses.gdrive_link AS submission_url, team_progresses.status, team_progresses.created_at AS submitted_at").
+		Joins("LEFT JOIN team_progresses ON team_progresses.stage_id = stages.stage_id AND team_progresses.team_id = ?", teamID).
+		Where("stages.competition_id = ?", competitionID).
+		Order("stages.stage_order ASC").
+		Scan(&stages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stages, nil
+}
+
+// GetSubmissionHistory returns every submission a team made, oldest first.
+func (t *SubmissionRepository) GetSubmissionHistory(tx *gorm.DB, teamID uuid.UUID) ([]model.SubmissionHistory, error) {
+	history := []model.SubmissionHistory{}
+
+	err := tx.Debug().
+		Table("team_progresses").
+		Select("team_progresses.team_progress_id, team_progresses.stage_id, stages.stage_name, stages.stage_order, "+
+			"team_progresses.status, team_progresses.gdrive_link, team_progresses.score, team_progresses.feedback, "+
+			"team_progresses.graded_at, team_progresses.created_at, team_progresses.updated_at").
+		Joins("LEFT JOIN stages ON stages.stage_id = team_progresses.stage_id").
+		Where("team_progresses.team_id = ?", teamID).
+		Order("team_progresses.created_at ASC").
+		Order("team_progresses.team_progress_id ASC").
+		Scan(&history).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (t *SubmissionRepository) GetSubmissionByID(tx *gorm.DB, teamProgressID int) (*entity.TeamProgress, error) {
+	var submission entity.TeamProgress
+	err := tx.Debug().First(&submission, teamProgressID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+// GetLatestSubmission returns the most recent submission a team made for a stage.
+func (t *SubmissionRepository) GetLatestSubmission(tx *gorm.DB, stageID int, teamID uuid.UUID) (*entity.TeamProgress, error) {
+	var submission entity.TeamProgress
+	err := tx.Debug().
+		Where("stage_id = ? AND team_id = ?", stageID, teamID).
+		Order("created_at DESC").
+		Order("team_progress_id DESC").
+		First(&submission).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+func (t *SubmissionRepository) GradeSubmission(tx *gorm.DB, teamProgressID int, score float64, feedback string, gradedAt time.Time) error {
+	return tx.Debug().Model(&entity.TeamProgress{}).
+		Where("team_progress_id = ?", teamProgressID).
+		Updates(map[string]interface{}{
+			"score":     score,
+			"feedback":  feedback,
+			"graded_at": gradedAt,
+		}).Error
+}
+
+// GetStageLeaderboard returns the graded submissions of a stage, highest score
+// first. Ties go to the team that submitted earlier, then to the lower
+// submission ID so the order never changes between requests.
+func (t *SubmissionRepository) GetStageLeaderboard(tx *gorm.DB, stageID int) ([]model.StageLeaderboardEntry, error) {
+	entries := []model.StageLeaderboardEntry{}
+
+	err := tx.Debug().
+		Table("team_progresses").
+		Select("team_progresses.team_progress_id, team_progresses.team_id, teams.team_name, team_progresses.score, "+
+			"team_progresses.status, team_progresses.created_at AS submitted_at").
+		Joins("JOIN teams ON teams.team_id = team_progresses.team_id AND teams.deleted_at IS NULL").
+		Where("team_progresses.stage_id = ? AND team_progresses.score IS NOT NULL", stageID).
+		Order("team_progresses.score DESC").
+		Order("team_progresses.created_at ASC").
+		Order("team_progresses.team_progress_id ASC").
+		Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetLeaderboard sums each team's graded scores across the stages of a
+// competition, highest total first. Ties go to the team whose last counted
+// submission came first. With deadlineBefore set, only stages whose deadline is
+// before it are counted.
+func (t *SubmissionRepository) GetLeaderboard(tx *gorm.DB, competitionID int, deadlineBefore *time.Time, offset int, limit int) ([]model.LeaderboardEntry, int64, error) {
+	var total int64
+	entries := []model.LeaderboardEntry{}
+
+	scores := tx.Table("team_progresses").
+		Select("teams.team_id, teams.team_name, SUM(team_progresses.score) AS total_score, "+
+			"COUNT(*) AS graded_stages, MAX(team_progresses.created_at) AS last_submitted_at").
+		Joins("JOIN teams ON teams.team_id = team_progresses.team_id AND teams.deleted_at IS NULL").
+		Joins("JOIN stages ON stages.stage_id = team_progresses.stage_id").
+		Where("teams.competition_id = ? AND stages.competition_id = ?", competitionID, competitionID).
+		Where("team_progresses.score IS NOT NULL").
+		Group("teams.team_id, teams.team_name")
+	if deadlineBefore != nil {
+		scores = scores.Where("stages.deadline < ?", *deadlineBefore)
+	}
+
+	err := tx.Debug().Table("(?) AS leaderboard", scores).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = tx.Debug().Table("(?) AS leaderboard", scores).
+		Order("total_score DESC").
+		Order("last_submitted_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
 func (t *SubmissionRepository) UpdateStatusSubmission(tx *gorm.DB, teamID string, stageID string, req model.RequestUpdateStatusSubmission) error {
 	return tx.Debug().Model(&entity.TeamProgress{}).
 		Where("team_id = ? AND stage_id = ?", teamID, stageID).
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"itfest-2025/entity"
+
+	"gorm.io/gorm"
+)
+
+type ISupportMessageRepository interface {
+	CreateSupportMessage(tx *gorm.DB, message *entity.SupportMessage) error
+}
+
+type SupportMessageRepository struct {
+	db *gorm.DB
+}
+
+func NewSupportMessageRepository(db *gorm.DB) ISupportMessageRepository {
+	return &SupportMessageRepository{
+		db: db,
+	}
+}
+
+func (s *SupportMessageRepository) CreateSupportMessage(tx *gorm.DB, message *entity.SupportMessage) error {
+	err := tx.Debug().Create(message).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
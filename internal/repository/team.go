@@ -3,14 +3,17 @@ package repository
 import (
 	"itfest-2025/entity"
 	"itfest-2025/model"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ITeamRepository interface {
 	CreateTeam(tx *gorm.DB, team *entity.Team) error
-	GetTeamByName(tx *gorm.DB, teamName string) error
+	TeamNameExists(tx *gorm.DB, competitionID int, teamName string, excludeTeamID uuid.UUID) (bool, error)
+	GetStudentNumberConflicts(tx *gorm.DB, competitionID int, studentNumbers []string, excludeTeamID uuid.UUID) ([]model.StudentNumberConflict, error)
 	GetTeam(tx *gorm.DB) ([]*entity.Team, error)
 	GetTeamByID(tx *gorm.DB, teamID uuid.UUID) (*entity.Team, error)
 	CreateTeamMember(tx *gorm.DB, teamMember *entity.TeamMember) error
@@ -19,7 +22,16 @@ type ITeamRepository interface {
 	DeleteTeamMembers(tx *gorm.DB, teamID uuid.UUID) error
 	GetTeamMemberByTeamID(tx *gorm.DB, teamID uuid.UUID) ([]*entity.TeamMember, error)
 	GetCount(tx *gorm.DB, competitionID string) (int64, error)
-	UpdateTeamStatus(tx *gorm.DB, req model.ReqUpdateStatusTeam) error
+	CountRegisteredTeams(tx *gorm.DB, competitionID int, excludeTeamID uuid.UUID) (int64, error)
+	CountWaitlistPosition(tx *gorm.DB, competitionID int, waitlistedAt time.Time) (int64, error)
+	GetWaitlistedTeams(tx *gorm.DB, competitionID int, limit int) ([]*entity.Team, error)
+	ClearWaitlist(tx *gorm.DB, teamIDs []uuid.UUID) error
+	GetTotalRevenue(tx *gorm.DB) (int64, error)
+	UpdateTeamStatus(tx *gorm.DB, req model.ReqUpdateStatusTeam, now time.Time) error
+	GetPaymentReviewTeams(tx *gorm.DB, teamIDs []uuid.UUID) ([]model.PaymentReviewTeam, error)
+	UpdatePaymentConfirmationSentAt(tx *gorm.DB, teamID uuid.UUID, sentAt time.Time) error
+	DeleteTeamByUserID(tx *gorm.DB, userID uuid.UUID) error
+	RestoreTeamByUserID(tx *gorm.DB, userID uuid.UUID) error
 }
 
 type TeamRepository struct {
@@ -41,13 +53,51 @@ func (t *TeamRepository) CreateTeam(tx *gorm.DB, team *entity.Team) error {
 	return nil
 }
 
-func (t *TeamRepository) GetTeamByName(tx *gorm.DB, teamName string) error {
-	var team entity.Team
-	err := tx.Debug().Where("team_name = ?", teamName).First(&team).Error
+// TeamNameExists reports whether another team in the competition already uses
+// teamName, ignoring case.
+func (t *TeamRepository) TeamNameExists(tx *gorm.DB, competitionID int, teamName string, excludeTeamID uuid.UUID) (bool, error) {
+	var count int64
+	err := tx.Debug().Model(&entity.Team{}).
+		Where("competition_id = ? AND LOWER(team_name) = LOWER(?) AND team_id <> ?", competitionID, teamName, excludeTeamID).
+		Count(&count).Error
 	if err != nil {
-		return err
+		return false, err
 	}
-	return nil
+
+	return count > 0, nil
+}
+
+// GetStudentNumberConflicts returns the teams in a competition, other than
+// excludeTeamID, that already have one of studentNumbers as their leader or as a
+// member.
+func (t *TeamRepository) GetStudentNumberConflicts(tx *gorm.DB, competitionID int, studentNumbers []string, excludeTeamID uuid.UUID) ([]model.StudentNumberConflict, error) {
+	conflicts := []model.StudentNumberConflict{}
+	if len(studentNumbers) == 0 {
+		return conflicts, nil
+	}
+
+	err := tx.Debug().
+		Table("team_members").
+		Select("team_members.student_number, teams.team_id, teams.team_name").
+		Joins("JOIN teams ON teams.team_id = team_members.team_id AND teams.deleted_at IS NULL").
+		Where("teams.competition_id = ? AND teams.team_id <> ? AND team_members.student_number IN ?", competitionID, excludeTeamID, studentNumbers).
+		Scan(&conflicts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var leaders []model.StudentNumberConflict
+	err = tx.Debug().
+		Table("users").
+		Select("users.student_number, teams.team_id, teams.team_name").
+		Joins("JOIN teams ON teams.user_id = users.user_id AND teams.deleted_at IS NULL").
+		Where("users.deleted_at IS NULL AND teams.competition_id = ? AND teams.team_id <> ? AND users.student_number IN ?", competitionID, excludeTeamID, studentNumbers).
+		Scan(&leaders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return append(conflicts, leaders...), nil
 }
 
 func (t *TeamRepository) GetCount(tx *gorm.DB, competitionID string) (int64, error) {
@@ -63,6 +113,71 @@ func (t *TeamRepository) GetCount(tx *gorm.DB, competitionID string) (int64, err
 	return count, nil
 }
 
+// CountRegisteredTeams counts the teams holding a slot in the competition:
+// registered, not waitlisted and not rejected. excludeTeamID is left out.
+func (t *TeamRepository) CountRegisteredTeams(tx *gorm.DB, competitionID int, excludeTeamID uuid.UUID) (int64, error) {
+	var count int64
+	err := tx.Debug().Model(&entity.Team{}).
+		Where("competition_id = ? AND registered_at IS NOT NULL AND waitlisted_at IS NULL AND team_status <> ? AND team_id <> ?", competitionID, "ditolak", excludeTeamID).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CountWaitlistPosition is the 1-based place in the competition's waitlist of a
+// team that joined it at waitlistedAt.
+func (t *TeamRepository) CountWaitlistPosition(tx *gorm.DB, competitionID int, waitlistedAt time.Time) (int64, error) {
+	var count int64
+	err := tx.Debug().Model(&entity.Team{}).
+		Where("competition_id = ? AND waitlisted_at IS NOT NULL AND waitlisted_at <= ?", competitionID, waitlistedAt).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetWaitlistedTeams returns up to limit waitlisted teams of the competition,
+// longest waiting first. A negative limit returns all of them.
+func (t *TeamRepository) GetWaitlistedTeams(tx *gorm.DB, competitionID int, limit int) ([]*entity.Team, error) {
+	var teams []*entity.Team
+	err := tx.Debug().
+		Where("competition_id = ? AND waitlisted_at IS NOT NULL", competitionID).
+		Order("waitlisted_at ASC").
+		Limit(limit).
+		Find(&teams).Error
+	if err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+func (t *TeamRepository) ClearWaitlist(tx *gorm.DB, teamIDs []uuid.UUID) error {
+	return tx.Debug().Model(&entity.Team{}).
+		Where("team_id IN ?", teamIDs).
+		Update("waitlisted_at", nil).Error
+}
+
+// GetTotalRevenue sums what every verified team paid: the competition fee less
+// the discount of the team's coupon, computed the same way as couponDiscount.
+func (t *TeamRepository) GetTotalRevenue(tx *gorm.DB) (int64, error) {
+	var total int64
+	err := tx.Debug().Model(&entity.Team{}).
+		Select(`COALESCE(SUM(competitions.fee - LEAST(competitions.fee,
+			CASE WHEN coupons.discount_percent > 0 THEN competitions.fee * coupons.discount_percent DIV 100
+			ELSE COALESCE(coupons.discount_amount, 0) END)), 0)`).
+		Joins("JOIN competitions ON competitions.competition_id = teams.competition_id").
+		Joins("LEFT JOIN coupons ON coupons.coupon_id = teams.coupon_id").
+		Where("teams.team_status = ?", "terverifikasi").
+		Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
 func (t *TeamRepository) GetTeam(tx *gorm.DB) ([]*entity.Team, error) {
 	var team []*entity.Team
 	err := tx.Debug().Preload("Competition").Preload("TeamMember").Find(&team).Error
@@ -128,8 +243,55 @@ func (t *TeamRepository) GetTeamMemberByTeamID(tx *gorm.DB, teamID uuid.UUID) ([
 	return members, nil
 }
 
-func (t *TeamRepository) UpdateTeamStatus(tx *gorm.DB, req model.ReqUpdateStatusTeam) error {
+// UpdateTeamStatus sets a team's payment status. Approving the payment stamps
+// it with now, and any other status clears the approval time.
+func (t *TeamRepository) UpdateTeamStatus(tx *gorm.DB, req model.ReqUpdateStatusTeam, now time.Time) error {
+	var approvedAt *time.Time
+	if req.PaymentStatus == "terverifikasi" {
+		approvedAt = &now
+	}
+
 	return tx.Debug().Model(&entity.Team{}).
 		Where("team_id = ?", req.TeamID).
-		Update("team_status", req.PaymentStatus).Error
+		Updates(map[string]interface{}{
+			"team_status":         req.PaymentStatus,
+			"payment_approved_at": approvedAt,
+		}).Error
+}
+
+// GetPaymentReviewTeams loads the given teams with their leader and locks the
+// rows until the transaction ends. Missing or deleted teams are left out.
+func (t *TeamRepository) GetPaymentReviewTeams(tx *gorm.DB, teamIDs []uuid.UUID) ([]model.PaymentReviewTeam, error) {
+	teams := []model.PaymentReviewTeam{}
+
+	err := tx.Debug().
+		Table("teams").
+		Select("teams.team_id, teams.team_status, teams.competition_id, competitions.competition_name, "+
+			"users.user_id, users.full_name, users.email, users.phone_number, users.payment_transc").
+		Joins("JOIN users ON users.user_id = teams.user_id AND users.deleted_at IS NULL").
+		Joins("LEFT JOIN competitions ON competitions.competition_id = teams.competition_id").
+		Where("teams.team_id IN ? AND teams.deleted_at IS NULL", teamIDs).
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "teams"}}).
+		Scan(&teams).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+func (t *TeamRepository) UpdatePaymentConfirmationSentAt(tx *gorm.DB, teamID uuid.UUID, sentAt time.Time) error {
+	return tx.Debug().Model(&entity.Team{}).
+		Where("team_id = ?", teamID).
+		Update("payment_confirmation_sent_at", sentAt).Error
+}
+
+func (t *TeamRepository) DeleteTeamByUserID(tx *gorm.DB, userID uuid.UUID) error {
+	return tx.Debug().Where("user_id = ?", userID).Delete(&entity.Team{}).Error
+}
+
+func (t *TeamRepository) RestoreTeamByUserID(tx *gorm.DB, userID uuid.UUID) error {
+	return tx.Debug().Unscoped().Model(&entity.Team{}).
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Update("deleted_at", nil).Error
 }
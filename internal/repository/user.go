@@ -1,18 +1,27 @@
 package repository
 
 import (
+	"context"
 	"itfest-2025/entity"
 	"itfest-2025/model"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type IUserRepository interface {
 	CreateUser(tx *gorm.DB, user *entity.User) (*entity.User, error)
 	UpdateUser(tx *gorm.DB, user *entity.User) error
-	GetUser(param model.UserParam) (*entity.User, error)
-	GetAllUser() ([]*entity.User, error)
-	GetCountPayment() (int64, error)
+	GetUser(ctx context.Context, param model.UserParam) (*entity.User, error)
+	GetUserRole(ctx context.Context, userID uuid.UUID) (*model.UserRole, error)
+	UpdateUserColumns(tx *gorm.DB, userID uuid.UUID, columns map[string]interface{}) error
+	DeleteUser(tx *gorm.DB, userID uuid.UUID) error
+	RestoreUser(tx *gorm.DB, userID uuid.UUID) (*entity.User, error)
+	GetAllUser(ctx context.Context) ([]*entity.User, error)
+	GetUsersWithTeamPage(ctx context.Context, offset int, limit int) ([]*entity.User, int64, error)
+	CountParticipantsByCompetition(ctx context.Context) (map[int]int64, error)
+	GetBroadcastRecipients(ctx context.Context, competitionID int, afterUserID string, limit int) ([]model.BroadcastRecipient, error)
+	GetCountPayment(ctx context.Context) (int64, error)
 }
 
 type UserRepository struct {
@@ -34,9 +43,9 @@ func (u *UserRepository) CreateUser(tx *gorm.DB, user *entity.User) (*entity.Use
 	return user, nil
 }
 
-func (u *UserRepository) GetUser(param model.UserParam) (*entity.User, error) {
+func (u *UserRepository) GetUser(ctx context.Context, param model.UserParam) (*entity.User, error) {
 	user := entity.User{}
-	err := u.db.Debug().Preload("Team").Where(&param).First(&user).Error
+	err := u.db.WithContext(ctx).Debug().Preload("Team").Where(&param).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +53,21 @@ func (u *UserRepository) GetUser(param model.UserParam) (*entity.User, error) {
 	return &user, nil
 }
 
+// GetUserRole returns a user with the name of their role.
+func (u *UserRepository) GetUserRole(ctx context.Context, userID uuid.UUID) (*model.UserRole, error) {
+	var userRole model.UserRole
+	err := u.db.WithContext(ctx).Debug().Model(&entity.User{}).
+		Select("users.user_id, users.full_name, users.email, users.role_id, roles.role_name").
+		Joins("JOIN roles ON roles.role_id = users.role_id").
+		Where("users.user_id = ?", userID).
+		Take(&userRole).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &userRole, nil
+}
+
 func (u *UserRepository) UpdateUser(tx *gorm.DB, user *entity.User) error {
 	err := tx.Where("user_id = ?", user.UserID).Updates(&user).Error
 	if err != nil {
@@ -53,9 +77,49 @@ func (u *UserRepository) UpdateUser(tx *gorm.DB, user *entity.User) error {
 	return nil
 }
 
-func (u *UserRepository) GetAllUser() ([]*entity.User, error) {
+// UpdateUserColumns writes the given columns as-is, including zero values that
+// UpdateUser would skip.
+func (u *UserRepository) UpdateUserColumns(tx *gorm.DB, userID uuid.UUID, columns map[string]interface{}) error {
+	err := tx.Model(&entity.User{}).Where("user_id = ?", userID).Updates(columns).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *UserRepository) DeleteUser(tx *gorm.DB, userID uuid.UUID) error {
+	err := tx.Debug().Where("user_id = ?", userID).Delete(&entity.User{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RestoreUser clears deleted_at on a soft-deleted user and returns it. It fails
+// with gorm.ErrRecordNotFound when no deleted user has the given ID.
+func (u *UserRepository) RestoreUser(tx *gorm.DB, userID uuid.UUID) (*entity.User, error) {
+	var user entity.User
+	err := tx.Debug().Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Debug().Unscoped().Model(&user).Update("deleted_at", nil).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetAllUser loads every user with their team and members in one unbounded
+// query. Only use it for batch jobs such as exports and mass emails; request
+// handlers should use GetUsersWithTeamPage or an aggregate query instead.
+func (u *UserRepository) GetAllUser(ctx context.Context) ([]*entity.User, error) {
 	var users []*entity.User
-	err := u.db.Debug().Preload("Team.TeamMembers").Find(&users).Error
+	err := u.db.WithContext(ctx).Debug().Preload("Team.TeamMembers").Find(&users).Error
 	if err != nil {
 		return nil, err
 	}
@@ -63,9 +127,84 @@ func (u *UserRepository) GetAllUser() ([]*entity.User, error) {
 	return users, nil
 }
 
-func (u *UserRepository) GetCountPayment() (int64, error) {
+// GetUsersWithTeamPage returns one page of the participants that have a team,
+// oldest first, with their team members and the total count. Admins are left
+// out. The join relies on the teams.user_id index and the ordering on
+// users.created_at.
+func (u *UserRepository) GetUsersWithTeamPage(ctx context.Context, offset int, limit int) ([]*entity.User, int64, error) {
+	var (
+		users []*entity.User
+		total int64
+	)
+
+	query := u.db.WithContext(ctx).Debug().Model(&entity.User{}).
+		InnerJoins("Team").
+		Where("users.role_id <> ?", entity.RoleAdmin).
+		Session(&gorm.Session{})
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = query.Preload("Team.TeamMembers").
+		Order("users.created_at ASC").Order("users.user_id ASC").
+		Offset(offset).Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// GetBroadcastRecipients returns the next batch of team leaders in a
+// competition with a user ID greater than afterUserID, in user ID order.
+func (u *UserRepository) GetBroadcastRecipients(ctx context.Context, competitionID int, afterUserID string, limit int) ([]model.BroadcastRecipient, error) {
+	recipients := []model.BroadcastRecipient{}
+
+	err := u.db.WithContext(ctx).Debug().Model(&entity.User{}).
+		Select("users.user_id, users.full_name, users.email, teams.team_name").
+		Joins("JOIN teams ON teams.user_id = users.user_id AND teams.deleted_at IS NULL").
+		Where("teams.competition_id = ? AND users.user_id > ?", competitionID, afterUserID).
+		Order("users.user_id ASC").
+		Limit(limit).
+		Scan(&recipients).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+// CountParticipantsByCompetition counts the users with a team in each
+// competition, keyed by competition ID.
+func (u *UserRepository) CountParticipantsByCompetition(ctx context.Context) (map[int]int64, error) {
+	var rows []struct {
+		CompetitionID int
+		Total         int64
+	}
+
+	err := u.db.WithContext(ctx).Debug().Model(&entity.User{}).
+		Joins("JOIN teams ON teams.user_id = users.user_id AND teams.deleted_at IS NULL").
+		Select("teams.competition_id, COUNT(*) AS total").
+		Group("teams.competition_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int64, len(rows))
+	for _, v := range rows {
+		counts[v.CompetitionID] = v.Total
+	}
+
+	return counts, nil
+}
+
+func (u *UserRepository) GetCountPayment(ctx context.Context) (int64, error) {
 	var count int64
-	err := u.db.Debug().Model(&entity.User{}).Where("payment_transc IS NOT NULL").Count(&count).Error
+	err := u.db.WithContext(ctx).Debug().Model(&entity.User{}).Where("payment_transc IS NOT NULL").Count(&count).Error
 	if err != nil {
 		return 0, err
 	}
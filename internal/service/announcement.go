@@ -1,12 +1,15 @@
 package service
 
 import (
+	"context"
+	"html/template"
 	"itfest-2025/entity"
 	"itfest-2025/internal/repository"
 	"itfest-2025/model"
-	"itfest-2025/pkg/database/mariadb"
 	"itfest-2025/pkg/mail"
+	netmail "net/mail"
 	"strings"
+	texttemplate "text/template"
 
 	"time"
 
@@ -15,45 +18,154 @@ import (
 )
 
 type IAnnouncementService interface {
-	SendAnnouncement(req model.RequestAnnouncement) error
-	GetAnnouncement() ([]*model.ResponseAnnouncement, error)
+	SendAnnouncement(ctx context.Context, req model.RequestAnnouncement) error
+	GetAnnouncement(ctx context.Context) ([]*model.ResponseAnnouncement, error)
+	UpdateAnnouncement(ctx context.Context, announcementID uuid.UUID, req model.RequestAnnouncement) error
+	DeleteAnnouncement(ctx context.Context, announcementID uuid.UUID) error
+	ListAnnouncements(ctx context.Context, userID uuid.UUID, query model.AnnouncementQuery) (*model.Paginated[*model.ResponseAnnouncement], error)
+	UnreadAnnouncements(ctx context.Context, userID uuid.UUID) (*model.UnreadAnnouncements, error)
+	MarkAnnouncementsRead(ctx context.Context, userID uuid.UUID) error
+	BroadcastEmail(ctx context.Context, competitionID int, req model.BroadcastEmailRequest) (*model.BroadcastSummary, error)
 }
 
+const (
+	defaultAnnouncementTitle = "Announcement"
+
+	// broadcastBatchSize is how many recipients BroadcastEmail loads per query.
+	broadcastBatchSize = 200
+)
+
 type AnnouncementService struct {
 	db                     *gorm.DB
 	UserRepository         repository.IUserRepository
 	TeamRepository         repository.ITeamRepository
 	AnnouncementRepository repository.IAnnouncementRepository
+	CompetitionRepository  repository.ICompetitionRepository
+	Mailer                 mail.Mailer
 }
 
-func NewAnnouncementService(userRepository repository.IUserRepository, teamRepository repository.ITeamRepository, announcementRepository repository.IAnnouncementRepository) IAnnouncementService {
+func NewAnnouncementService(db *gorm.DB, userRepository repository.IUserRepository, teamRepository repository.ITeamRepository, announcementRepository repository.IAnnouncementRepository, competitionRepository repository.ICompetitionRepository, mailer mail.Mailer) IAnnouncementService {
 	return &AnnouncementService{
-		db:                     mariadb.Connection,
+		db:                     db,
 		UserRepository:         userRepository,
 		TeamRepository:         teamRepository,
 		AnnouncementRepository: announcementRepository,
+		CompetitionRepository:  competitionRepository,
+		Mailer:                 mailer,
 	}
 }
 
-func (a *AnnouncementService) GetAnnouncement() ([]*model.ResponseAnnouncement, error) {
-	var response []*model.ResponseAnnouncement
-	data, err := a.AnnouncementRepository.GetAnnouncement()
+func (a *AnnouncementService) GetAnnouncement(ctx context.Context) ([]*model.ResponseAnnouncement, error) {
+	response := []*model.ResponseAnnouncement{}
+	data, err := a.AnnouncementRepository.GetAnnouncement(ctx)
 	if err != nil {
 		return nil, err
 	}
 	for _, v := range data {
-		response = append(response, &model.ResponseAnnouncement{
-			AnnouncementID: v.AnnouncementID.String(),
-			Message:        v.Description,
-			Date:           v.CreatedAt,
-		})
+		response = append(response, toResponseAnnouncement(v))
 	}
 
 	return response, nil
 }
 
-func (a *AnnouncementService) SendAnnouncement(req model.RequestAnnouncement) error {
-	users, err := a.UserRepository.GetAllUser()
+// ListAnnouncements returns the published announcements a participant can see:
+// the ones for everyone and the ones for their team's competition. With Since
+// set, only those published after it are listed.
+func (a *AnnouncementService) ListAnnouncements(ctx context.Context, userID uuid.UUID, query model.AnnouncementQuery) (*model.Paginated[*model.ResponseAnnouncement], error) {
+	query.Normalize()
+
+	user, err := a.UserRepository.GetUser(ctx, model.UserParam{
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, total, err := a.AnnouncementRepository.ListPublishedAnnouncements(ctx, user.Team.CompetitionID, time.Now(), query.Since, query.Offset(), query.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*model.ResponseAnnouncement{}
+	for _, v := range data {
+		items = append(items, toResponseAnnouncement(v))
+	}
+
+	return model.NewPaginated(items, query.PaginationQuery, total), nil
+}
+
+// UnreadAnnouncements counts the announcements the user can see that were
+// published after they last called MarkAnnouncementsRead.
+func (a *AnnouncementService) UnreadAnnouncements(ctx context.Context, userID uuid.UUID) (*model.UnreadAnnouncements, error) {
+	user, err := a.UserRepository.GetUser(ctx, model.UserParam{
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := a.AnnouncementRepository.CountPublishedAnnouncements(ctx, user.Team.CompetitionID, time.Now(), user.AnnouncementsReadAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UnreadAnnouncements{
+		Unread: unread,
+		ReadAt: user.AnnouncementsReadAt,
+	}, nil
+}
+
+// MarkAnnouncementsRead moves the user's last-read marker to now.
+func (a *AnnouncementService) MarkAnnouncementsRead(ctx context.Context, userID uuid.UUID) error {
+	return a.UserRepository.UpdateUserColumns(a.db.WithContext(ctx), userID, map[string]interface{}{
+		"announcements_read_at": time.Now(),
+	})
+}
+
+func (a *AnnouncementService) UpdateAnnouncement(ctx context.Context, announcementID uuid.UUID, req model.RequestAnnouncement) error {
+	return withTransaction(ctx, a.db, func(tx *gorm.DB) error {
+		announcement, err := a.AnnouncementRepository.GetAnnouncementByID(tx, announcementID)
+		if err != nil {
+			return err
+		}
+
+		if req.Title != "" {
+			announcement.Title = req.Title
+		}
+		announcement.Description = req.Message
+		announcement.CompetitionID = req.CompetitionID
+		if req.PublishedAt != nil {
+			announcement.PublishedAt = req.PublishedAt
+		}
+
+		return a.AnnouncementRepository.UpdateAnnouncement(tx, announcement)
+	})
+}
+
+func (a *AnnouncementService) DeleteAnnouncement(ctx context.Context, announcementID uuid.UUID) error {
+	return withTransaction(ctx, a.db, func(tx *gorm.DB) error {
+		return a.AnnouncementRepository.DeleteAnnouncement(tx, announcementID)
+	})
+}
+
+func toResponseAnnouncement(announcement *entity.Announcement) *model.ResponseAnnouncement {
+	publishedAt := announcement.CreatedAt
+	if announcement.PublishedAt != nil {
+		publishedAt = *announcement.PublishedAt
+	}
+
+	return &model.ResponseAnnouncement{
+		AnnouncementID: announcement.AnnouncementID.String(),
+		Title:          announcement.Title,
+		Message:        announcement.Description,
+		CompetitionID:  announcement.CompetitionID,
+		PublishedAt:    publishedAt,
+		Date:           announcement.CreatedAt,
+	}
+}
+
+func (a *AnnouncementService) SendAnnouncement(ctx context.Context, req model.RequestAnnouncement) error {
+	users, err := a.UserRepository.GetAllUser(ctx)
 
 	if err != nil {
 		return err
@@ -61,15 +173,29 @@ func (a *AnnouncementService) SendAnnouncement(req model.RequestAnnouncement) er
 		return model.ErrUserRecordNotFound
 	}
 
-	tx := a.db.Begin()
+	now := time.Now()
+
+	title := req.Title
+	if title == "" {
+		title = defaultAnnouncementTitle
+	}
+
+	publishedAt := now
+	if req.PublishedAt != nil {
+		publishedAt = *req.PublishedAt
+	}
+
+	tx := a.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
 	err = a.AnnouncementRepository.CreateAnnouncement(tx, entity.Announcement{
 		AnnouncementID: uuid.New(),
-		Title:          "Announcement",
+		Title:          title,
 		Description:    req.Message,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+		CompetitionID:  req.CompetitionID,
+		PublishedAt:    &publishedAt,
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	})
 	if err != nil {
 		return err
@@ -80,6 +206,12 @@ func (a *AnnouncementService) SendAnnouncement(req model.RequestAnnouncement) er
 		return err
 	}
 
+	// Scheduled announcements only show up in the list once published; they are
+	// not emailed ahead of time.
+	if publishedAt.After(now) {
+		return nil
+	}
+
 	mailBody := `
 		<!DOCTYPE html>
 		<html lang="id">
@@ -214,8 +346,11 @@ func (a *AnnouncementService) SendAnnouncement(req model.RequestAnnouncement) er
 	mailBody = strings.Replace(mailBody, "$MESSAGE$", req.Message, 1)
 
 	for _, v := range users {
-		if v.RoleID == 2 && v.StatusAccount == "active" {
-			err = mail.SendEmail(v.Email, "Pengumuman IT FEST 2025", mailBody)
+		if req.CompetitionID != nil && v.Team.CompetitionID != *req.CompetitionID {
+			continue
+		}
+		if v.RoleID == entity.RoleUser && v.StatusAccount == "active" {
+			err = a.Mailer.Send(v.Email, "Pengumuman IT FEST 2025", mailBody)
 		}
 	}
 
@@ -224,3 +359,81 @@ func (a *AnnouncementService) SendAnnouncement(req model.RequestAnnouncement) er
 	}
 	return nil
 }
+
+// BroadcastEmail personalizes the email for every participant of the
+// competition and hands it to the rate-limited mail queue.
+func (a *AnnouncementService) BroadcastEmail(ctx context.Context, competitionID int, req model.BroadcastEmailRequest) (*model.BroadcastSummary, error) {
+	subject, err := texttemplate.New("subject").Option("missingkey=error").Parse(req.Subject)
+	if err != nil {
+		return nil, model.ValidationErrors{"subject": err.Error()}
+	}
+
+	body, err := template.New("body").Option("missingkey=error").Parse(req.Body)
+	if err != nil {
+		return nil, model.ValidationErrors{"body": err.Error()}
+	}
+
+	_, err = a.CompetitionRepository.GetCompetitionByID(a.db.WithContext(ctx), competitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.BroadcastSummary{
+		Invalid: []string{},
+		Failed:  []string{},
+	}
+
+	afterUserID := ""
+	for {
+		recipients, err := a.UserRepository.GetBroadcastRecipients(ctx, competitionID, afterUserID, broadcastBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, recipient := range recipients {
+			_, err := netmail.ParseAddress(recipient.Email)
+			if err != nil {
+				summary.Invalid = append(summary.Invalid, recipient.Email)
+				continue
+			}
+
+			data := broadcastTemplateData{
+				Name:     recipient.FullName,
+				Email:    recipient.Email,
+				TeamName: recipient.TeamName,
+			}
+
+			var subjectText, bodyText strings.Builder
+			err = subject.Execute(&subjectText, data)
+			if err != nil {
+				return nil, model.ValidationErrors{"subject": err.Error()}
+			}
+
+			err = body.Execute(&bodyText, data)
+			if err != nil {
+				return nil, model.ValidationErrors{"body": err.Error()}
+			}
+
+			err = a.Mailer.Enqueue(recipient.Email, strings.ReplaceAll(subjectText.String(), "\n", " "), bodyText.String(), nil)
+			if err != nil {
+				summary.Failed = append(summary.Failed, recipient.Email)
+				continue
+			}
+
+			summary.Queued++
+		}
+
+		if len(recipients) < broadcastBatchSize {
+			break
+		}
+		afterUserID = recipients[len(recipients)-1].UserID
+	}
+
+	return summary, nil
+}
+
+type broadcastTemplateData struct {
+	Name     string
+	Email    string
+	TeamName string
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"itfest-2025/entity"
+	"itfest-2025/internal/repository"
+	"itfest-2025/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type IAuditService interface {
+	ListAuditLogs(ctx context.Context, query model.AuditLogQuery) (*model.Paginated[*model.AuditLogResponse], error)
+}
+
+type AuditService struct {
+	AuditLogRepository repository.IAuditLogRepository
+}
+
+func NewAuditService(auditLogRepository repository.IAuditLogRepository) IAuditService {
+	return &AuditService{
+		AuditLogRepository: auditLogRepository,
+	}
+}
+
+func (a *AuditService) ListAuditLogs(ctx context.Context, query model.AuditLogQuery) (*model.Paginated[*model.AuditLogResponse], error) {
+	query.Normalize()
+
+	auditLogs, total, err := a.AuditLogRepository.ListAuditLogs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*model.AuditLogResponse{}
+	for _, v := range auditLogs {
+		items = append(items, &model.AuditLogResponse{
+			AuditLogID: v.AuditLogID.String(),
+			ActorID:    v.ActorID,
+			Action:     v.Action,
+			TargetType: v.TargetType,
+			TargetID:   v.TargetID,
+			Metadata:   v.Metadata,
+			CreatedAt:  v.CreatedAt,
+		})
+	}
+
+	return model.NewPaginated(items, query.PaginationQuery, total), nil
+}
+
+// recordAudit writes an audit log entry in tx, so the entry is only kept when
+// the action it describes is committed.
+func recordAudit(auditLogRepository repository.IAuditLogRepository, tx *gorm.DB, entry model.AuditEntry) error {
+	metadata, err := json.Marshal(entry.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return auditLogRepository.CreateAuditLog(tx, &entity.AuditLog{
+		AuditLogID: uuid.New(),
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Metadata:   metadata,
+	})
+}
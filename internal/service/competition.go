@@ -1,31 +1,48 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"itfest-2025/entity"
 	"itfest-2025/internal/repository"
 	"itfest-2025/model"
-	"itfest-2025/pkg/database/mariadb"
+	"itfest-2025/pkg/config"
+	"strconv"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type ICompetitionService interface {
-	GetAllCompetitions() ([]*model.GetAllCompetitionsResponse, error)
+	GetAllCompetitions(ctx context.Context) ([]*model.GetAllCompetitionsResponse, error)
+	GetCompetition(ctx context.Context, competitionID int) (*model.GetCompetitionResponse, error)
+	UpdateCompetitionFee(ctx context.Context, actorID uuid.UUID, competitionID int, fee int) error
+	UpdateCompetitionCapacity(ctx context.Context, actorID uuid.UUID, competitionID int, capacity int) error
+	UpdateRegistrationStatus(ctx context.Context, actorID uuid.UUID, competitionID int, isOpen bool) error
+	UpdateExtraFields(ctx context.Context, actorID uuid.UUID, competitionID int, fields []model.ExtraField) error
+	GetCompetitionSchedule(ctx context.Context, competitionID int) ([]model.StageSchedule, error)
 }
 
 type CompetitionService struct {
 	db                    *gorm.DB
+	cfg                   *config.Config
 	CompetitionRepository repository.ICompetitionRepository
+	AuditLogRepository    repository.IAuditLogRepository
 }
 
-func NewCompetitionService(CompetitionRepository repository.ICompetitionRepository) *CompetitionService {
+func NewCompetitionService(db *gorm.DB, CompetitionRepository repository.ICompetitionRepository, auditLogRepository repository.IAuditLogRepository, cfg *config.Config) *CompetitionService {
 	return &CompetitionService{
-		db:                    mariadb.Connection,
+		db:                    db,
+		cfg:                   cfg,
 		CompetitionRepository: CompetitionRepository,
+		AuditLogRepository:    auditLogRepository,
 	}
 }
 
-func (c *CompetitionService) GetAllCompetitions() ([]*model.GetAllCompetitionsResponse, error) {
-	tx := c.db.Begin()
+func (c *CompetitionService) GetAllCompetitions(ctx context.Context) ([]*model.GetAllCompetitionsResponse, error) {
+	tx := c.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
 	competitions, err := c.CompetitionRepository.GetAllCompetitions(tx)
@@ -33,14 +50,252 @@ func (c *CompetitionService) GetAllCompetitions() ([]*model.GetAllCompetitionsRe
 		return nil, err
 	}
 
-	var response []*model.GetAllCompetitionsResponse
+	now := time.Now()
+	response := []*model.GetAllCompetitionsResponse{}
 	for _, v := range competitions {
 		response = append(response, &model.GetAllCompetitionsResponse{
-			CompetitionID:   v.CompetitionID,
-			CompetitionName: v.CompetitionName,
-			Description:     v.Description,
+			CompetitionID:     v.CompetitionID,
+			CompetitionName:   v.CompetitionName,
+			Category:          v.Category,
+			Description:       v.Description,
+			MinMembers:        v.MinMembers,
+			MaxMembers:        v.MaxMembers,
+			Fee:               v.Fee,
+			Capacity:          v.Capacity,
+			RegistrationOpen:  v.RegistrationOpen,
+			RegistrationClose: v.RegistrationClose,
+			IsRegistrationOn:  checkRegistrationWindow(v, now) == nil,
 		})
 	}
 
 	return response, nil
 }
+
+func (c *CompetitionService) GetCompetition(ctx context.Context, competitionID int) (*model.GetCompetitionResponse, error) {
+	tx := c.db.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	competition, err := c.CompetitionRepository.GetCompetitionByID(tx, competitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	extraFields, err := competitionExtraFields(competition)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.GetCompetitionResponse{
+		CompetitionID:     competition.CompetitionID,
+		CompetitionName:   competition.CompetitionName,
+		Category:          competition.Category,
+		Description:       competition.Description,
+		MinMembers:        competition.MinMembers,
+		MaxMembers:        competition.MaxMembers,
+		Fee:               competition.Fee,
+		Capacity:          competition.Capacity,
+		Deadline:          competition.Deadline,
+		RegistrationOpen:  competition.RegistrationOpen,
+		RegistrationClose: competition.RegistrationClose,
+		IsRegistrationOn:  checkRegistrationWindow(competition, time.Now()) == nil,
+		ExtraFields:       extraFields,
+	}, nil
+}
+
+// GetCompetitionSchedule lists the stages of a competition by StageOrder with
+// their deadlines in the configured timezone.
+func (c *CompetitionService) GetCompetitionSchedule(ctx context.Context, competitionID int) ([]model.StageSchedule, error) {
+	db := c.db.WithContext(ctx)
+
+	exists, err := c.CompetitionRepository.CompetitionExists(db, competitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	stages, err := c.CompetitionRepository.GetStagesByCompetition(db, competitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	location := c.cfg.App.Timezone
+	schedule := make([]model.StageSchedule, 0, len(stages))
+	for _, v := range stages {
+		schedule = append(schedule, model.StageSchedule{
+			StageID:    v.StageID,
+			StageName:  v.StageName,
+			StageOrder: v.StageOrder,
+			Deadline:   v.Deadline.In(location),
+			Timezone:   location.String(),
+		})
+	}
+
+	return schedule, nil
+}
+
+func (c *CompetitionService) UpdateCompetitionFee(ctx context.Context, actorID uuid.UUID, competitionID int, fee int) error {
+	tx := c.db.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	competition, err := c.CompetitionRepository.GetCompetitionByID(tx, competitionID)
+	if err != nil {
+		return err
+	}
+
+	err = c.CompetitionRepository.UpdateCompetitionFee(tx, competitionID, fee)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(c.AuditLogRepository, tx, model.AuditEntry{
+		ActorID:    &actorID,
+		Action:     model.AuditActionCompetitionFee,
+		TargetType: "competition",
+		TargetID:   strconv.Itoa(competitionID),
+		Metadata: map[string]interface{}{
+			"old_fee": competition.Fee,
+			"new_fee": fee,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// UpdateCompetitionCapacity limits how many teams may register. Lowering it
+// below the teams already registered doesn't remove any of them.
+func (c *CompetitionService) UpdateCompetitionCapacity(ctx context.Context, actorID uuid.UUID, competitionID int, capacity int) error {
+	tx := c.db.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	competition, err := c.CompetitionRepository.GetCompetitionByID(tx, competitionID)
+	if err != nil {
+		return err
+	}
+
+	err = c.CompetitionRepository.UpdateCompetitionCapacity(tx, competitionID, capacity)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(c.AuditLogRepository, tx, model.AuditEntry{
+		ActorID:    &actorID,
+		Action:     model.AuditActionCompetitionCapacity,
+		TargetType: "competition",
+		TargetID:   strconv.Itoa(competitionID),
+		Metadata: map[string]interface{}{
+			"old_capacity": competition.Capacity,
+			"new_capacity": capacity,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// UpdateRegistrationStatus opens or pauses registration. The registration
+// window still applies while it is open.
+func (c *CompetitionService) UpdateRegistrationStatus(ctx context.Context, actorID uuid.UUID, competitionID int, isOpen bool) error {
+	return withTransaction(ctx, c.db, func(tx *gorm.DB) error {
+		_, err := c.CompetitionRepository.GetCompetitionByID(tx, competitionID)
+		if err != nil {
+			return err
+		}
+
+		err = c.CompetitionRepository.UpdateRegistrationStatus(tx, competitionID, isOpen)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(c.AuditLogRepository, tx, model.AuditEntry{
+			ActorID:    &actorID,
+			Action:     model.AuditActionCompetitionRegistration,
+			TargetType: "competition",
+			TargetID:   strconv.Itoa(competitionID),
+			Metadata: map[string]interface{}{
+				"is_registration_open": isOpen,
+			},
+		})
+	})
+}
+
+// UpdateExtraFields replaces the extra fields a competition asks for at
+// registration. Values teams already entered are kept until they register again.
+func (c *CompetitionService) UpdateExtraFields(ctx context.Context, actorID uuid.UUID, competitionID int, fields []model.ExtraField) error {
+	err := model.ValidateExtraFields(fields)
+	if err != nil {
+		return err
+	}
+
+	if fields == nil {
+		fields = []model.ExtraField{}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	return withTransaction(ctx, c.db, func(tx *gorm.DB) error {
+		_, err := c.CompetitionRepository.GetCompetitionByID(tx, competitionID)
+		if err != nil {
+			return err
+		}
+
+		err = c.CompetitionRepository.UpdateExtraFields(tx, competitionID, data)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(c.AuditLogRepository, tx, model.AuditEntry{
+			ActorID:    &actorID,
+			Action:     model.AuditActionCompetitionExtraFields,
+			TargetType: "competition",
+			TargetID:   strconv.Itoa(competitionID),
+			Metadata: map[string]interface{}{
+				"extra_fields": fields,
+			},
+		})
+	})
+}
+
+// competitionExtraFields decodes the extra fields of a competition, which has
+// none when the column is empty.
+func competitionExtraFields(competition *entity.Competition) ([]model.ExtraField, error) {
+	fields := []model.ExtraField{}
+	if len(competition.ExtraFields) == 0 {
+		return fields, nil
+	}
+
+	err := json.Unmarshal(competition.ExtraFields, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("decoding extra fields of competition %d: %w", competition.CompetitionID, err)
+	}
+
+	return fields, nil
+}
+
+// checkRegistrationWindow treats a missing open or close time as unbounded on
+// that side. A paused registration is closed regardless of the window.
+func checkRegistrationWindow(competition *entity.Competition, now time.Time) error {
+	if !competition.IsRegistrationOpen {
+		return model.ErrRegistrationPaused
+	}
+
+	if competition.RegistrationOpen != nil && now.Before(*competition.RegistrationOpen) {
+		return model.ErrRegistrationNotOpen
+	}
+
+	if competition.RegistrationClose != nil && !now.Before(*competition.RegistrationClose) {
+		return model.ErrRegistrationClosed
+	}
+
+	return nil
+}
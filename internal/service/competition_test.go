@@ -0,0 +1,38 @@
+package service
+
+import (
+	"errors"
+	"itfest-2025/entity"
+	"itfest-2025/model"
+	"testing"
+	"time"
+)
+
+func TestCheckRegistrationWindow(t *testing.T) {
+	openAt := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	closeAt := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		competition entity.Competition
+		now         time.Time
+		wantErr     error
+	}{
+		{name: "no window", competition: entity.Competition{IsRegistrationOpen: true}, now: openAt},
+		{name: "paused", competition: entity.Competition{IsRegistrationOpen: false, RegistrationOpen: &openAt, RegistrationClose: &closeAt}, now: openAt.Add(time.Hour), wantErr: model.ErrRegistrationPaused},
+		{name: "before opening", competition: entity.Competition{IsRegistrationOpen: true, RegistrationOpen: &openAt, RegistrationClose: &closeAt}, now: openAt.Add(-time.Second), wantErr: model.ErrRegistrationNotOpen},
+		{name: "at opening", competition: entity.Competition{IsRegistrationOpen: true, RegistrationOpen: &openAt, RegistrationClose: &closeAt}, now: openAt},
+		{name: "just before closing", competition: entity.Competition{IsRegistrationOpen: true, RegistrationOpen: &openAt, RegistrationClose: &closeAt}, now: closeAt.Add(-time.Second)},
+		{name: "at closing", competition: entity.Competition{IsRegistrationOpen: true, RegistrationOpen: &openAt, RegistrationClose: &closeAt}, now: closeAt, wantErr: model.ErrRegistrationClosed},
+		{name: "open ended", competition: entity.Competition{IsRegistrationOpen: true, RegistrationOpen: &openAt}, now: closeAt.Add(365 * 24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRegistrationWindow(&tt.competition, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkRegistrationWindow() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
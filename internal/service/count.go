@@ -1,14 +1,14 @@
 package service
 
 import (
+	"context"
 	"itfest-2025/internal/repository"
-	"itfest-2025/pkg/database/mariadb"
 
 	"gorm.io/gorm"
 )
 
 type ICountService interface {
-	GetAllCount() (responCount, error)
+	GetAllCount(ctx context.Context) (responCount, error)
 }
 
 type CountService struct {
@@ -22,18 +22,19 @@ type responCount struct {
 	TotalPayment  int64
 	TotalBusiness int64
 	TotalUIUX     int64
+	TotalRevenue  int64
 }
 
-func NewCountService(TeamRepository repository.ITeamRepository, UserRepository repository.IUserRepository) *CountService {
+func NewCountService(db *gorm.DB, TeamRepository repository.ITeamRepository, UserRepository repository.IUserRepository) *CountService {
 	return &CountService{
-		db:             mariadb.Connection,
+		db:             db,
 		TeamRepository: TeamRepository,
 		UserRepository: UserRepository,
 	}
 }
 
-func (c *CountService) GetAllCount() (responCount, error) {
-	tx := c.db.Begin()
+func (c *CountService) GetAllCount(ctx context.Context) (responCount, error) {
+	tx := c.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
 	totalTeam, err := c.TeamRepository.GetCount(tx, "")
@@ -49,7 +50,12 @@ func (c *CountService) GetAllCount() (responCount, error) {
 		return responCount{}, err
 	}
 	
-	countPayment, err := c.UserRepository.GetCountPayment()
+	countPayment, err := c.UserRepository.GetCountPayment(ctx)
+	if err != nil {
+		return responCount{}, err
+	}
+
+	totalRevenue, err := c.TeamRepository.GetTotalRevenue(tx)
 	if err != nil {
 		return responCount{}, err
 	}
@@ -59,5 +65,6 @@ func (c *CountService) GetAllCount() (responCount, error) {
 		TotalPayment:  countPayment,
 		TotalBusiness: countBusiness,
 		TotalUIUX:     countUIUX,
+		TotalRevenue:  totalRevenue,
 	}, nil
 }
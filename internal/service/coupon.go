@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"itfest-2025/entity"
+	"itfest-2025/internal/repository"
+	"itfest-2025/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ICouponService interface {
+	ValidateCoupon(ctx context.Context, code string, competitionID int) (*model.CouponResult, error)
+	CreateCoupon(ctx context.Context, param model.CreateCouponRequest) (*entity.Coupon, error)
+}
+
+type CouponService struct {
+	db                    *gorm.DB
+	CouponRepository      repository.ICouponRepository
+	CompetitionRepository repository.ICompetitionRepository
+}
+
+func NewCouponService(db *gorm.DB, couponRepository repository.ICouponRepository, competitionRepository repository.ICompetitionRepository) ICouponService {
+	return &CouponService{
+		db:                    db,
+		CouponRepository:      couponRepository,
+		CompetitionRepository: competitionRepository,
+	}
+}
+
+// ValidateCoupon previews the discount a coupon gives on a competition fee
+// without redeeming it.
+func (c *CouponService) ValidateCoupon(ctx context.Context, code string, competitionID int) (*model.CouponResult, error) {
+	db := c.db.WithContext(ctx)
+
+	competition, err := c.CompetitionRepository.GetCompetitionByID(db, competitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	coupon, err := getCoupon(c.CouponRepository, db, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkCoupon(coupon, competition, time.Now())
+}
+
+func (c *CouponService) CreateCoupon(ctx context.Context, param model.CreateCouponRequest) (*entity.Coupon, error) {
+	err := param.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	coupon := &entity.Coupon{
+		CouponID:        uuid.New(),
+		Code:            param.Code,
+		CompetitionID:   param.CompetitionID,
+		DiscountPercent: param.DiscountPercent,
+		DiscountAmount:  param.DiscountAmount,
+		UsageLimit:      param.UsageLimit,
+		ExpiresAt:       param.ExpiresAt,
+	}
+
+	err = withTransaction(ctx, c.db, func(tx *gorm.DB) error {
+		if param.CompetitionID != nil {
+			_, err := c.CompetitionRepository.GetCompetitionByID(tx, *param.CompetitionID)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := c.CouponRepository.CreateCoupon(tx, coupon)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.ErrCouponCodeTaken
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func getCoupon(couponRepository repository.ICouponRepository, tx *gorm.DB, code string) (*entity.Coupon, error) {
+	coupon, err := couponRepository.GetCouponByCode(tx, model.NormalizeCouponCode(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+// checkCoupon reports why a coupon can't be used on the competition, or the
+// discounted fee when it can.
+func checkCoupon(coupon *entity.Coupon, competition *entity.Competition, now time.Time) (*model.CouponResult, error) {
+	if coupon.CompetitionID != nil && *coupon.CompetitionID != competition.CompetitionID {
+		return nil, model.ErrCouponCompetitionMismatch
+	}
+
+	if coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt) {
+		return nil, model.ErrCouponExpired
+	}
+
+	if coupon.UsedCount >= coupon.UsageLimit {
+		return nil, model.ErrCouponExhausted
+	}
+
+	discount := couponDiscount(coupon, competition.Fee)
+
+	return &model.CouponResult{
+		Code:          coupon.Code,
+		CompetitionID: competition.CompetitionID,
+		OriginalFee:   competition.Fee,
+		Discount:      discount,
+		FinalFee:      competition.Fee - discount,
+		RemainingUses: coupon.UsageLimit - coupon.UsedCount,
+	}, nil
+}
+
+// couponDiscount is how much a coupon takes off fee, never more than the fee.
+func couponDiscount(coupon *entity.Coupon, fee int) int {
+	discount := coupon.DiscountAmount
+	if coupon.DiscountPercent > 0 {
+		discount = fee * coupon.DiscountPercent / 100
+	}
+
+	return min(discount, fee)
+}
+
+// applyCoupon redeems a coupon for the team inside tx. A team keeps the first
+// coupon it redeems, so registering again with the same code is a no-op.
+func applyCoupon(couponRepository repository.ICouponRepository, tx *gorm.DB, code string, competition *entity.Competition, team *entity.Team, now time.Time) error {
+	coupon, err := getCoupon(couponRepository, tx, code)
+	if err != nil {
+		return err
+	}
+
+	if team.CouponID != nil {
+		if *team.CouponID != coupon.CouponID {
+			return model.ErrCouponAlreadyApplied
+		}
+
+		_, err = checkCoupon(coupon, competition, now)
+		if errors.Is(err, model.ErrCouponCompetitionMismatch) {
+			return err
+		}
+
+		return nil
+	}
+
+	_, err = checkCoupon(coupon, competition, now)
+	if err != nil {
+		return err
+	}
+
+	redeemed, err := couponRepository.RedeemCoupon(tx, coupon.CouponID, now)
+	if err != nil {
+		return err
+	}
+	if !redeemed {
+		return model.ErrCouponExhausted
+	}
+
+	team.CouponID = &coupon.CouponID
+
+	return nil
+}
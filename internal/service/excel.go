@@ -1,19 +1,23 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"itfest-2025/entity"
 	"itfest-2025/internal/repository"
 	"itfest-2025/model"
-	"itfest-2025/pkg/database/mariadb"
 	"itfest-2025/pkg/template"
+	"strings"
 
 	"github.com/xuri/excelize/v2"
 	"gorm.io/gorm"
 )
 
 type IExcelService interface {
-	ExportExcelPayment() (string, error)
-	ExportExcelTeam() (string, error)
-	ExportExcelCompetitionByID(competition int) (string, error)
+	ExportExcelPayment(ctx context.Context) (string, error)
+	ExportExcelTeam(ctx context.Context) (string, error)
+	ExportExcelCompetitionByID(ctx context.Context, competition int) (string, error)
 }
 
 type ExcelService struct {
@@ -23,17 +27,17 @@ type ExcelService struct {
 	CompetitionRepository repository.ICompetitionRepository
 }
 
-func NewExcelService(teamRepo repository.ITeamRepository, compRepo repository.ICompetitionRepository, userRepo repository.IUserRepository) IExcelService {
+func NewExcelService(db *gorm.DB, teamRepo repository.ITeamRepository, compRepo repository.ICompetitionRepository, userRepo repository.IUserRepository) IExcelService {
 	return &ExcelService{
-		db:                    mariadb.Connection,
+		db:                    db,
 		TeamRepository:        teamRepo,
 		CompetitionRepository: compRepo,
 		UserRepository:        userRepo,
 	}
 }
 
-func (s *ExcelService) ExportExcelPayment() (string, error) {
-	data, err := s.UserRepository.GetAllUser()
+func (s *ExcelService) ExportExcelPayment(ctx context.Context) (string, error) {
+	data, err := s.UserRepository.GetAllUser(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -102,14 +106,14 @@ func (s *ExcelService) ExportExcelPayment() (string, error) {
 		return "", err
 	}
 
-	headers := []string{"No", "Name", "Email", "NIM", "Team Name", "Link Registrasi", "Payment"}
+	headers := []string{"No", "Name", "Email", "Phone Number", "NIM", "Team Name", "Link Registrasi", "Payment"}
 
 	sheet := template.ExcelSheet{
 		Name:          "Payment",
 		Headers:       headers,
 		Rows:          [][]interface{}{},
 		HeaderStyleID: headerStyle,
-		ColWidths:     map[int]float64{1: 5, 2: 30, 3: 25, 4: 30, 5: 30, 6: 100, 7: 100},
+		ColWidths:     map[int]float64{1: 5, 2: 30, 3: 25, 4: 20, 5: 30, 6: 30, 7: 100, 8: 100},
 		RowStyleMap:   map[int]int{},
 		ColStyleMap:   map[int]int{},
 	}
@@ -119,7 +123,7 @@ func (s *ExcelService) ExportExcelPayment() (string, error) {
 	userColorToggle := 0
 
 	for _, dt := range data {
-		sheet.Rows = append(sheet.Rows, []interface{}{no, dt.FullName, dt.Email, dt.StudentNumber, dt.Team.TeamName, dt.RegistrationLink, dt.PaymentTransc})
+		sheet.Rows = append(sheet.Rows, []interface{}{no, dt.FullName, dt.Email, dt.PhoneNumber, dt.StudentNumber, dt.Team.TeamName, dt.RegistrationLink, dt.PaymentTransc})
 
 		excelRowNum := rowIndex + 2
 
@@ -144,8 +148,8 @@ func (s *ExcelService) ExportExcelPayment() (string, error) {
 	return fileName, nil
 }
 
-func (s *ExcelService) ExportExcelTeam() (string, error) {
-	data, err := s.UserRepository.GetAllUser()
+func (s *ExcelService) ExportExcelTeam(ctx context.Context) (string, error) {
+	data, err := s.UserRepository.GetAllUser(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -187,10 +191,10 @@ func (s *ExcelService) ExportExcelTeam() (string, error) {
 		return "", err
 	}
 
-	headers := []string{"No", "Nama User", "Nama Tim", "Nama Kompetisi", "Member"}
+	headers := []string{"No", "Nama User", "No. HP", "Nama Tim", "Nama Kompetisi", "Member", "Data Tambahan"}
 	rows := [][]interface{}{}
 
-	tx := s.db.Begin()
+	tx := s.db.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -223,18 +227,32 @@ func (s *ExcelService) ExportExcelTeam() (string, error) {
 			return "", err
 		}
 
+		extraFields, err := competitionExtraFields(competition)
+		if err != nil {
+			tx.Rollback()
+			return "", err
+		}
+
+		extraValues, err := teamExtraFieldValues(team)
+		if err != nil {
+			tx.Rollback()
+			return "", err
+		}
+
 		for i, member := range members {
 			if i == 0 {
 				rows = append(rows, []interface{}{
 					no,
 					user.FullName,
+					user.PhoneNumber,
 					team.TeamName,
 					competition.CompetitionName,
 					member.MemberName,
+					formatExtraFields(extraFields, extraValues),
 				})
 			} else {
 				rows = append(rows, []interface{}{
-					"", "", "", "", member.MemberName,
+					"", "", "", "", "", member.MemberName,
 				})
 			}
 		}
@@ -258,7 +276,7 @@ func (s *ExcelService) ExportExcelTeam() (string, error) {
 		Name:          "Team",
 		Headers:       headers,
 		Rows:          rows,
-		ColWidths:     map[int]float64{1: 5, 2: 30, 3: 30, 4: 30, 5: 25},
+		ColWidths:     map[int]float64{1: 5, 2: 30, 3: 20, 4: 30, 5: 30, 6: 25, 7: 40},
 		HeaderStyleID: headerStyle,
 		RowStyleMap:   rowStyleMap,
 		ColStyleMap:   colStyleMap,
@@ -272,8 +290,8 @@ func (s *ExcelService) ExportExcelTeam() (string, error) {
 	return fileName, nil
 }
 
-func (s *ExcelService) ExportExcelCompetitionByID(competitionID int) (string, error) {
-	tx := s.db.Begin()
+func (s *ExcelService) ExportExcelCompetitionByID(ctx context.Context, competitionID int) (string, error) {
+	tx := s.db.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -322,13 +340,24 @@ func (s *ExcelService) ExportExcelCompetitionByID(competitionID int) (string, er
 		return "", err
 	}
 
-	headers := []string{"No", "Nama User", "Nama Tim", "Nama Kompetisi", "Member"}
+	extraFields, err := competitionExtraFields(competition)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	headers := []string{"No", "Nama User", "No. HP", "Nama Tim", "Nama Kompetisi", "Member"}
+	colWidths := map[int]float64{1: 5, 2: 30, 3: 20, 4: 30, 5: 30, 6: 25}
+	for _, v := range extraFields {
+		headers = append(headers, v.Label)
+		colWidths[len(headers)] = 30
+	}
 	rows := [][]interface{}{}
 
 	no := 1
 
 	for _, team := range competition.Teams {
-		user, err := s.UserRepository.GetUser(model.UserParam{
+		user, err := s.UserRepository.GetUser(ctx, model.UserParam{
 			UserID: team.UserID,
 		})
 		if err != nil {
@@ -336,18 +365,29 @@ func (s *ExcelService) ExportExcelCompetitionByID(competitionID int) (string, er
 			return "", err
 		}
 
+		extraValues, err := teamExtraFieldValues(&team)
+		if err != nil {
+			tx.Rollback()
+			return "", err
+		}
+
 		for i, member := range team.TeamMembers {
 			if i == 0 {
-				rows = append(rows, []interface{}{
+				row := []interface{}{
 					no,
 					user.FullName,
+					user.PhoneNumber,
 					team.TeamName,
 					competition.CompetitionName,
 					member.MemberName,
-				})
+				}
+				for _, v := range extraFields {
+					row = append(row, extraValues[v.Name])
+				}
+				rows = append(rows, row)
 			} else {
 				rows = append(rows, []interface{}{
-					"", "", "", "", member.MemberName,
+					"", "", "", "", "", member.MemberName,
 				})
 			}
 		}
@@ -371,7 +411,7 @@ func (s *ExcelService) ExportExcelCompetitionByID(competitionID int) (string, er
 		Name:          "Team",
 		Headers:       headers,
 		Rows:          rows,
-		ColWidths:     map[int]float64{1: 5, 2: 30, 3: 30, 4: 30, 5: 25},
+		ColWidths:     colWidths,
 		HeaderStyleID: headerStyle,
 		RowStyleMap:   rowStyleMap,
 		ColStyleMap:   colStyleMap,
@@ -384,3 +424,31 @@ func (s *ExcelService) ExportExcelCompetitionByID(competitionID int) (string, er
 
 	return fileName, nil
 }
+
+// teamExtraFieldValues decodes the extra field values a team registered with.
+func teamExtraFieldValues(team *entity.Team) (map[string]string, error) {
+	values := map[string]string{}
+	if len(team.ExtraFields) == 0 {
+		return values, nil
+	}
+
+	err := json.Unmarshal(team.ExtraFields, &values)
+	if err != nil {
+		return nil, fmt.Errorf("decoding extra fields of team %s: %w", team.TeamID, err)
+	}
+
+	return values, nil
+}
+
+// formatExtraFields lists a team's extra field values one "Label: value" per
+// line, in the order the competition defines them.
+func formatExtraFields(fields []model.ExtraField, values map[string]string) string {
+	lines := []string{}
+	for _, v := range fields {
+		if values[v.Name] != "" {
+			lines = append(lines, v.Label+": "+values[v.Name])
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
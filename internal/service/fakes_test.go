@@ -0,0 +1,907 @@
+package service
+
+import (
+	"context"
+	"io"
+	"itfest-2025/entity"
+	"itfest-2025/internal/repository"
+	"itfest-2025/model"
+	"itfest-2025/pkg/bcrypt"
+	"itfest-2025/pkg/captcha"
+	"itfest-2025/pkg/clock"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/jwt"
+	"itfest-2025/pkg/mail"
+	"itfest-2025/pkg/storage"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The fakes below keep their rows in memory and ignore the *gorm.DB they are
+// handed. Each embeds its repository interface, so calling a method a test
+// didn't expect panics instead of silently passing.
+
+// newTestDB returns a gorm handle on sqlmock. Services only use it to open
+// transactions, which each test declares with expectTransaction.
+func newTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	t.Cleanup(func() {
+		sqlDB.Close()
+	})
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		Logger:         logger.Discard,
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open gorm: %v", err)
+	}
+
+	return db, mock
+}
+
+// expectTransaction declares n transactions that commit.
+func expectTransaction(mock sqlmock.Sqlmock, n int) {
+	for i := 0; i < n; i++ {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+	}
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Otp: config.OtpExpiry{
+			Verify: 10 * time.Minute,
+			Reset:  5 * time.Minute,
+		},
+		App: config.App{
+			DefaultCompetitionID:  1,
+			PasswordHistorySize:   3,
+			LoginHistoryRetention: 90 * 24 * time.Hour,
+			Timezone:              time.UTC,
+		},
+	}
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// userServiceFixture is a UserService wired to in-memory fakes.
+type userServiceFixture struct {
+	service       *UserService
+	mock          sqlmock.Sqlmock
+	users         *fakeUserRepository
+	teams         *fakeTeamRepository
+	otps          *fakeOtpRepository
+	competitions  *fakeCompetitionRepository
+	coupons       *fakeCouponRepository
+	idempotency   *fakeIdempotencyRepository
+	loginHistory  *fakeLoginHistoryRepository
+	notifications *fakeNotificationRepository
+	mailer        *mail.FakeMailer
+	clock         *clock.Fake
+}
+
+func newUserServiceFixture(t *testing.T) *userServiceFixture {
+	t.Helper()
+
+	db, mock := newTestDB(t)
+	clk := clock.NewFake(time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC))
+	cfg := testConfig()
+
+	f := &userServiceFixture{
+		mock:          mock,
+		users:         newFakeUserRepository(),
+		teams:         newFakeTeamRepository(),
+		otps:          newFakeOtpRepository(clk),
+		competitions:  newFakeCompetitionRepository(&entity.Competition{CompetitionID: 1, CompetitionName: "Business Plan"}),
+		coupons:       &fakeCouponRepository{},
+		idempotency:   &fakeIdempotencyRepository{clock: clk},
+		loginHistory:  &fakeLoginHistoryRepository{},
+		notifications: &fakeNotificationRepository{},
+		mailer:        &mail.FakeMailer{},
+		clock:         clk,
+	}
+
+	f.service = &UserService{
+		db:                         db,
+		cfg:                        cfg,
+		UserRepository:             f.users,
+		TeamRepository:             f.teams,
+		OtpRepository:              f.otps,
+		CompetitionRepository:      f.competitions,
+		CouponRepository:           f.coupons,
+		IdempotencyRepository:      f.idempotency,
+		LoginFingerprintRepository: &fakeLoginFingerprintRepository{},
+		LoginHistoryRepository:     f.loginHistory,
+		PasswordHistoryRepository:  &fakePasswordHistoryRepository{},
+		AuditLogRepository:         &fakeAuditLogRepository{},
+		RoleRepository:             fakeRoleRepository{},
+		NotificationRepository:     f.notifications,
+		BCrypt:                     bcrypt.Init(4),
+		JwtAuth:                    jwt.Init(config.JWT{SecretKey: "test-secret", ExpiredTime: time.Hour, RememberMeExpiredTime: 24 * time.Hour}),
+		Captcha:                    &captcha.Fake{},
+		Mailer:                     f.mailer,
+		Clock:                      clk,
+		Logger:                     testLogger(),
+		GenerateCode: func() string {
+			return "123456"
+		},
+	}
+
+	return f
+}
+
+type teamServiceFixture struct {
+	service      *TeamService
+	mock         sqlmock.Sqlmock
+	users        *fakeUserRepository
+	teams        *fakeTeamRepository
+	competitions *fakeCompetitionRepository
+	auditLogs    *fakeAuditLogRepository
+	mailer       *mail.FakeMailer
+}
+
+func newTeamServiceFixture(t *testing.T) *teamServiceFixture {
+	t.Helper()
+
+	db, mock := newTestDB(t)
+
+	f := &teamServiceFixture{
+		mock:         mock,
+		users:        newFakeUserRepository(),
+		teams:        newFakeTeamRepository(),
+		competitions: newFakeCompetitionRepository(&entity.Competition{CompetitionID: 1, CompetitionName: "Business Plan", MinMembers: 1, MaxMembers: 2}),
+		auditLogs:    &fakeAuditLogRepository{},
+		mailer:       &mail.FakeMailer{},
+	}
+
+	f.service = &TeamService{
+		db:                     db,
+		cfg:                    testConfig(),
+		UserRepository:         f.users,
+		TeamRepository:         f.teams,
+		CompetitionRepository:  f.competitions,
+		AuditLogRepository:     f.auditLogs,
+		NotificationRepository: &fakeNotificationRepository{},
+		Mailer:                 f.mailer,
+		Logger:                 testLogger(),
+	}
+
+	return f
+}
+
+// addUser stores an active password account and its empty team.
+func (f *userServiceFixture) addUser(t *testing.T, email string, password string) *entity.User {
+	t.Helper()
+
+	hash, err := f.service.BCrypt.GenerateFromPassword(password)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	user := &entity.User{
+		UserID:        uuid.New(),
+		Email:         email,
+		Password:      hash,
+		StatusAccount: "active",
+		AuthProvider:  "password",
+		RoleID:        entity.RoleUser,
+	}
+	f.users.put(user)
+	f.teams.put(&entity.Team{
+		TeamID:        uuid.New(),
+		TeamStatus:    "belum terverifikasi",
+		UserID:        user.UserID,
+		CompetitionID: 1,
+	})
+
+	return user
+}
+
+type fakeUserRepository struct {
+	repository.IUserRepository
+
+	mu    sync.Mutex
+	users map[uuid.UUID]entity.User
+	// GetErr, when set, is returned from GetUser.
+	GetErr error
+	// UpdateErr, when set, is returned from UpdateUser.
+	UpdateErr error
+}
+
+func newFakeUserRepository() *fakeUserRepository {
+	return &fakeUserRepository{
+		users: map[uuid.UUID]entity.User{},
+	}
+}
+
+func (r *fakeUserRepository) put(user *entity.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users[user.UserID] = *user
+}
+
+func (r *fakeUserRepository) get(userID uuid.UUID) *entity.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return nil
+	}
+
+	return &user
+}
+
+func (r *fakeUserRepository) CreateUser(tx *gorm.DB, user *entity.User) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return nil, gorm.ErrDuplicatedKey
+		}
+	}
+	r.users[user.UserID] = *user
+
+	return user, nil
+}
+
+func (r *fakeUserRepository) UpdateUser(tx *gorm.DB, user *entity.User) error {
+	if r.UpdateErr != nil {
+		return r.UpdateErr
+	}
+
+	r.put(user)
+	return nil
+}
+
+// UpdateUserColumns only applies the columns tests read back. Email keeps the
+// unique index of the real table.
+func (r *fakeUserRepository) UpdateUserColumns(tx *gorm.DB, userID uuid.UUID, columns map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if email, ok := columns["email"].(string); ok {
+		for _, other := range r.users {
+			if other.UserID != userID && other.Email == email {
+				return gorm.ErrDuplicatedKey
+			}
+		}
+		user.Email = email
+	}
+	if revokedAt, ok := columns["tokens_revoked_at"].(time.Time); ok {
+		user.TokensRevokedAt = &revokedAt
+	}
+	if password, ok := columns["password"].(string); ok {
+		user.Password = password
+	}
+	if pendingEmail, ok := columns["pending_email"].(string); ok {
+		user.PendingEmail = pendingEmail
+	}
+	r.users[userID] = user
+
+	return nil
+}
+
+func (r *fakeUserRepository) GetUser(ctx context.Context, param model.UserParam) (*entity.User, error) {
+	if r.GetErr != nil {
+		return nil, r.GetErr
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if param.UserID != uuid.Nil && user.UserID != param.UserID {
+			continue
+		}
+		if param.Email != "" && user.Email != param.Email {
+			continue
+		}
+
+		return &user, nil
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+// GetUsersWithTeamPage pages through the users ordered by email. Only users
+// stored with their Team are returned, like the real inner join.
+func (r *fakeUserRepository) GetUsersWithTeamPage(ctx context.Context, offset int, limit int) ([]*entity.User, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := []*entity.User{}
+	for _, user := range r.users {
+		if user.Team.TeamID != uuid.Nil {
+			users = append(users, &user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Email < users[j].Email
+	})
+
+	total := int64(len(users))
+	users = users[min(offset, len(users)):min(offset+limit, len(users))]
+
+	return users, total, nil
+}
+
+type fakeTeamRepository struct {
+	repository.ITeamRepository
+
+	mu      sync.Mutex
+	teams   map[uuid.UUID]entity.Team
+	members map[uuid.UUID][]entity.TeamMember
+}
+
+func newFakeTeamRepository() *fakeTeamRepository {
+	return &fakeTeamRepository{
+		teams:   map[uuid.UUID]entity.Team{},
+		members: map[uuid.UUID][]entity.TeamMember{},
+	}
+}
+
+func (r *fakeTeamRepository) put(team *entity.Team) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.teams[team.TeamID] = *team
+}
+
+func (r *fakeTeamRepository) CreateTeam(tx *gorm.DB, team *entity.Team) error {
+	r.put(team)
+	return nil
+}
+
+func (r *fakeTeamRepository) UpdateTeam(tx *gorm.DB, team *entity.Team) error {
+	r.put(team)
+	return nil
+}
+
+func (r *fakeTeamRepository) GetTeamByUserID(tx *gorm.DB, userID uuid.UUID) (*entity.Team, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, team := range r.teams {
+		if team.UserID == userID {
+			return &team, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeTeamRepository) GetTeamByID(tx *gorm.DB, teamID uuid.UUID) (*entity.Team, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	team, ok := r.teams[teamID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &team, nil
+}
+
+func (r *fakeTeamRepository) TeamNameExists(tx *gorm.DB, competitionID int, teamName string, excludeTeamID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, team := range r.teams {
+		if team.CompetitionID == competitionID && team.TeamID != excludeTeamID && strings.EqualFold(team.TeamName, teamName) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// GetStudentNumberConflicts only looks at members, which is all the tests
+// register.
+func (r *fakeTeamRepository) GetStudentNumberConflicts(tx *gorm.DB, competitionID int, studentNumbers []string, excludeTeamID uuid.UUID) ([]model.StudentNumberConflict, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var conflicts []model.StudentNumberConflict
+	for teamID, members := range r.members {
+		team := r.teams[teamID]
+		if teamID == excludeTeamID || team.CompetitionID != competitionID {
+			continue
+		}
+		for _, member := range members {
+			for _, number := range studentNumbers {
+				if member.StudentNumber == number {
+					conflicts = append(conflicts, model.StudentNumberConflict{StudentNumber: number, TeamID: teamID, TeamName: team.TeamName})
+				}
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+func (r *fakeTeamRepository) CreateTeamMember(tx *gorm.DB, teamMember *entity.TeamMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.members[teamMember.TeamID] = append(r.members[teamMember.TeamID], *teamMember)
+	return nil
+}
+
+func (r *fakeTeamRepository) DeleteTeamMembers(tx *gorm.DB, teamID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.members, teamID)
+	return nil
+}
+
+func (r *fakeTeamRepository) GetTeamMemberByTeamID(tx *gorm.DB, teamID uuid.UUID) ([]*entity.TeamMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	members := []*entity.TeamMember{}
+	for _, member := range r.members[teamID] {
+		members = append(members, &member)
+	}
+
+	return members, nil
+}
+
+type fakeOtpRepository struct {
+	repository.IOtpRepository
+
+	clock *clock.Fake
+	mu    sync.Mutex
+	otps  []entity.OtpCode
+}
+
+func newFakeOtpRepository(clk *clock.Fake) *fakeOtpRepository {
+	return &fakeOtpRepository{
+		clock: clk,
+	}
+}
+
+// GetOtp matches the non-zero fields of param and returns the newest match, like
+// the real repository.
+func (r *fakeOtpRepository) GetOtp(tx *gorm.DB, param model.GetOtp) (*entity.OtpCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matches []entity.OtpCode
+	for _, otp := range r.otps {
+		if param.OtpID != uuid.Nil && otp.OtpID != param.OtpID {
+			continue
+		}
+		if param.UserID != uuid.Nil && otp.UserID != param.UserID {
+			continue
+		}
+		if param.Code != "" && otp.Code != param.Code {
+			continue
+		}
+		if param.Purpose != "" && otp.Purpose != param.Purpose {
+			continue
+		}
+		matches = append(matches, otp)
+	}
+	if len(matches) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].CreatedAt.After(matches[j].CreatedAt)
+	})
+
+	return &matches[0], nil
+}
+
+// CreateOtp replaces the user's code for the same purpose and stamps it with
+// the fake clock, so tests can age it.
+func (r *fakeOtpRepository) CreateOtp(tx *gorm.DB, otp *entity.OtpCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.otps[:0]
+	for _, existing := range r.otps {
+		if existing.UserID != otp.UserID || existing.Purpose != otp.Purpose {
+			kept = append(kept, existing)
+		}
+	}
+
+	now := r.clock.Now()
+	otp.CreatedAt = now
+	otp.UpdatedAt = now
+	r.otps = append(kept, *otp)
+
+	return nil
+}
+
+func (r *fakeOtpRepository) UpdateOtp(tx *gorm.DB, otp *entity.OtpCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.otps {
+		if r.otps[i].OtpID == otp.OtpID {
+			otp.UpdatedAt = r.clock.Now()
+			r.otps[i] = *otp
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeOtpRepository) DeleteOtp(tx *gorm.DB, otp *entity.OtpCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.otps[:0]
+	for _, existing := range r.otps {
+		if existing.OtpID != otp.OtpID {
+			kept = append(kept, existing)
+		}
+	}
+	r.otps = kept
+
+	return nil
+}
+
+func (r *fakeOtpRepository) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.otps)
+}
+
+type fakeCompetitionRepository struct {
+	repository.ICompetitionRepository
+
+	mu           sync.Mutex
+	competitions map[int]entity.Competition
+}
+
+func newFakeCompetitionRepository(competitions ...*entity.Competition) *fakeCompetitionRepository {
+	r := &fakeCompetitionRepository{
+		competitions: map[int]entity.Competition{},
+	}
+	for _, competition := range competitions {
+		r.competitions[competition.CompetitionID] = *competition
+	}
+
+	return r
+}
+
+func (r *fakeCompetitionRepository) CompetitionExists(tx *gorm.DB, competitionID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.competitions[competitionID]
+	return ok, nil
+}
+
+func (r *fakeCompetitionRepository) GetCompetitionByID(tx *gorm.DB, competitionID int) (*entity.Competition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	competition, ok := r.competitions[competitionID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &competition, nil
+}
+
+func (r *fakeCompetitionRepository) GetAllCompetitions(tx *gorm.DB) ([]*entity.Competition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	competitions := []*entity.Competition{}
+	for _, competition := range r.competitions {
+		competitions = append(competitions, &competition)
+	}
+
+	return competitions, nil
+}
+
+func (r *fakeCompetitionRepository) GetCompetitionForUpdate(tx *gorm.DB, competitionID int) (*entity.Competition, error) {
+	return r.GetCompetitionByID(tx, competitionID)
+}
+
+type fakeCouponRepository struct {
+	repository.ICouponRepository
+
+	mu      sync.Mutex
+	coupons []entity.Coupon
+}
+
+func (r *fakeCouponRepository) put(coupon *entity.Coupon) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.coupons = append(r.coupons, *coupon)
+}
+
+func (r *fakeCouponRepository) GetCouponsByIDs(tx *gorm.DB, couponIDs []uuid.UUID) ([]*entity.Coupon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	coupons := []*entity.Coupon{}
+	for _, coupon := range r.coupons {
+		for _, id := range couponIDs {
+			if coupon.CouponID == id {
+				coupons = append(coupons, &coupon)
+				break
+			}
+		}
+	}
+
+	return coupons, nil
+}
+
+type fakeIdempotencyRepository struct {
+	repository.IIdempotencyRepository
+
+	clock *clock.Fake
+	mu    sync.Mutex
+	keys  []entity.IdempotencyKey
+}
+
+func (r *fakeIdempotencyRepository) GetIdempotencyKey(tx *gorm.DB, scope string, userID uuid.UUID, key string, after time.Time) (*entity.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.keys {
+		if stored.Scope == scope && stored.UserID == userID && stored.Key == key && stored.CreatedAt.After(after) {
+			return &stored, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeIdempotencyRepository) CreateIdempotencyKey(tx *gorm.DB, idempotencyKey *entity.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.keys {
+		if stored.Scope == idempotencyKey.Scope && stored.UserID == idempotencyKey.UserID && stored.Key == idempotencyKey.Key {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	idempotencyKey.CreatedAt = r.clock.Now()
+	r.keys = append(r.keys, *idempotencyKey)
+
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) DeleteExpiredIdempotencyKeys(tx *gorm.DB, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.keys[:0]
+	for _, stored := range r.keys {
+		if !stored.CreatedAt.Before(before) {
+			kept = append(kept, stored)
+		}
+	}
+	r.keys = kept
+
+	return nil
+}
+
+type fakeLoginFingerprintRepository struct {
+	repository.ILoginFingerprintRepository
+}
+
+func (fakeLoginFingerprintRepository) GetLoginFingerprint(tx *gorm.DB, userID uuid.UUID, ipAddress string) (*entity.LoginFingerprint, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (fakeLoginFingerprintRepository) CountLoginFingerprints(tx *gorm.DB, userID uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+func (fakeLoginFingerprintRepository) SaveLoginFingerprint(tx *gorm.DB, fingerprint *entity.LoginFingerprint) error {
+	return nil
+}
+
+func (fakeLoginFingerprintRepository) DeleteStaleLoginFingerprints(tx *gorm.DB, userID uuid.UUID, keep int) error {
+	return nil
+}
+
+type fakeLoginHistoryRepository struct {
+	repository.ILoginHistoryRepository
+
+	mu      sync.Mutex
+	entries []entity.LoginHistory
+	// CreateErr, when set, is returned from CreateLoginHistory.
+	CreateErr error
+}
+
+func (r *fakeLoginHistoryRepository) CreateLoginHistory(tx *gorm.DB, entry *entity.LoginHistory) error {
+	if r.CreateErr != nil {
+		return r.CreateErr
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+type fakePasswordHistoryRepository struct {
+	repository.IPasswordHistoryRepository
+
+	mu     sync.Mutex
+	hashes map[uuid.UUID][]string
+}
+
+func (r *fakePasswordHistoryRepository) GetRecentPasswordHashes(tx *gorm.DB, userID uuid.UUID, limit int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hashes := r.hashes[userID]
+	if len(hashes) > limit {
+		hashes = hashes[:limit]
+	}
+
+	return append([]string(nil), hashes...), nil
+}
+
+// CreatePasswordHistory keeps the newest hash first, the order the real
+// repository returns them in.
+func (r *fakePasswordHistoryRepository) CreatePasswordHistory(tx *gorm.DB, history *entity.PasswordHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.hashes == nil {
+		r.hashes = map[uuid.UUID][]string{}
+	}
+	r.hashes[history.UserID] = append([]string{history.PasswordHash}, r.hashes[history.UserID]...)
+
+	return nil
+}
+
+func (r *fakePasswordHistoryRepository) DeleteStalePasswordHistory(tx *gorm.DB, userID uuid.UUID, keep int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.hashes[userID]) > keep {
+		r.hashes[userID] = r.hashes[userID][:keep]
+	}
+
+	return nil
+}
+
+type fakeAuditLogRepository struct {
+	repository.IAuditLogRepository
+
+	mu   sync.Mutex
+	logs []entity.AuditLog
+}
+
+func (r *fakeAuditLogRepository) CreateAuditLog(tx *gorm.DB, auditLog *entity.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.logs = append(r.logs, *auditLog)
+	return nil
+}
+
+type fakeNotificationRepository struct {
+	repository.INotificationRepository
+
+	mu            sync.Mutex
+	notifications []entity.Notification
+}
+
+func (r *fakeNotificationRepository) CreateNotification(tx *gorm.DB, notification *entity.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notifications = append(r.notifications, *notification)
+	return nil
+}
+
+func (r *fakeNotificationRepository) CountUnreadNotifications(tx *gorm.DB, userID uuid.UUID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, notification := range r.notifications {
+		if notification.UserID == userID && notification.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type fakeRoleRepository struct {
+	repository.IRoleRepository
+}
+
+func (fakeRoleRepository) GetRoleByID(tx *gorm.DB, roleID int) (*entity.Role, error) {
+	names := map[int]string{
+		entity.RoleAdmin:   entity.RoleNameAdmin,
+		entity.RoleUser:    entity.RoleNameUser,
+		entity.RoleJudge:   entity.RoleNameJudge,
+		entity.RoleFinance: entity.RoleNameFinance,
+	}
+
+	name, ok := names[roleID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &entity.Role{RoleID: roleID, RoleName: name}, nil
+}
+
+// fakeStorage keeps uploaded files in memory, keyed by the URL it hands out.
+type fakeStorage struct {
+	storage.Interface
+
+	mu    sync.Mutex
+	files map[string][]byte
+	// UploadErr, when set, is returned from UploadFileContext.
+	UploadErr error
+}
+
+func (s *fakeStorage) UploadFileContext(ctx context.Context, data []byte, filename string) (string, error) {
+	if s.UploadErr != nil {
+		return "", s.UploadErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.files == nil {
+		s.files = map[string][]byte{}
+	}
+	url := "https://files.example.com/" + filename
+	s.files[url] = data
+
+	return url, nil
+}
+
+func (s *fakeStorage) DeleteFile(fileURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.files, fileURL)
+	return nil
+}
+
+func (s *fakeStorage) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.files)
+}
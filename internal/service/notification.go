@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"itfest-2025/entity"
+	"itfest-2025/internal/repository"
+	"itfest-2025/model"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type INotificationService interface {
+	CreateNotification(ctx context.Context, userID uuid.UUID, notificationType string, message string) error
+	ListNotifications(ctx context.Context, userID uuid.UUID, query model.PaginationQuery) (*model.Paginated[*model.NotificationResponse], error)
+	MarkAsRead(ctx context.Context, userID uuid.UUID, notificationID uuid.UUID) error
+}
+
+type NotificationService struct {
+	db                     *gorm.DB
+	NotificationRepository repository.INotificationRepository
+}
+
+func NewNotificationService(db *gorm.DB, notificationRepository repository.INotificationRepository) INotificationService {
+	return &NotificationService{
+		db:                     db,
+		NotificationRepository: notificationRepository,
+	}
+}
+
+func (n *NotificationService) CreateNotification(ctx context.Context, userID uuid.UUID, notificationType string, message string) error {
+	return createNotification(n.NotificationRepository, n.db.WithContext(ctx), userID, notificationType, message)
+}
+
+func (n *NotificationService) ListNotifications(ctx context.Context, userID uuid.UUID, query model.PaginationQuery) (*model.Paginated[*model.NotificationResponse], error) {
+	query.Normalize()
+
+	notifications, total, err := n.NotificationRepository.ListNotifications(ctx, userID, query.Offset(), query.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*model.NotificationResponse{}
+	for _, v := range notifications {
+		items = append(items, &model.NotificationResponse{
+			NotificationID: v.NotificationID,
+			Type:           v.Type,
+			Message:        v.Message,
+			ReadAt:         v.ReadAt,
+			CreatedAt:      v.CreatedAt,
+		})
+	}
+
+	return model.NewPaginated(items, query, total), nil
+}
+
+// MarkAsRead marks one of the user's notifications read. Marking it again is a
+// no-op.
+func (n *NotificationService) MarkAsRead(ctx context.Context, userID uuid.UUID, notificationID uuid.UUID) error {
+	found, err := n.NotificationRepository.MarkNotificationRead(n.db.WithContext(ctx), notificationID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return model.ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+func createNotification(notificationRepository repository.INotificationRepository, tx *gorm.DB, userID uuid.UUID, notificationType string, message string) error {
+	return notificationRepository.CreateNotification(tx, &entity.Notification{
+		NotificationID: uuid.New(),
+		UserID:         userID,
+		Type:           notificationType,
+		Message:        message,
+	})
+}
+
+// notify creates a notification outside any transaction, for events that have
+// already been committed. A failure is only logged.
+func notify(logger *slog.Logger, notificationRepository repository.INotificationRepository, db *gorm.DB, userID uuid.UUID, notificationType string, message string) {
+	err := createNotification(notificationRepository, db, userID, notificationType, message)
+	if err != nil {
+		logger.Error("failed to create notification", "type", notificationType, "user_id", userID, "error", err)
+	}
+}
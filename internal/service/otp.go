@@ -1,41 +1,60 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"html"
+	"itfest-2025/entity"
 	"itfest-2025/internal/repository"
 	"itfest-2025/model"
-	"itfest-2025/pkg/database/mariadb"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/jwt"
 	"itfest-2025/pkg/mail"
+	"log/slog"
+	"net/url"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const otpResendCooldown = 5 * time.Minute
+
 type IOtpService interface {
-	ResendOtp(param model.GetOtp) error
-	ResendOtpChangePassword(param model.GetOtp) error
+	ResendOtp(ctx context.Context, param model.GetOtp) error
+	ResendOtpChangePassword(ctx context.Context, param model.ForgotPasswordRequest) error
 }
 
 type OtpService struct {
 	db             *gorm.DB
+	cfg            *config.Config
 	OtpRepository  repository.IOtpRepository
 	UserRepository repository.IUserRepository
+	JwtAuth        jwt.Interface
+	Mailer         mail.Mailer
+	Logger         *slog.Logger
+	GenerateCode   func() string
 }
 
-func NewOtpService(OtpRepository repository.IOtpRepository, UserRepository repository.IUserRepository) IOtpService {
+func NewOtpService(db *gorm.DB, OtpRepository repository.IOtpRepository, UserRepository repository.IUserRepository, jwtAuth jwt.Interface, mailer mail.Mailer, logger *slog.Logger, cfg *config.Config) IOtpService {
 	return &OtpService{
-		db:             mariadb.Connection,
+		db:             db,
+		cfg:            cfg,
 		OtpRepository:  OtpRepository,
 		UserRepository: UserRepository,
+		JwtAuth:        jwtAuth,
+		Mailer:         mailer,
+		Logger:         logger,
+		GenerateCode:   mail.GenerateCode,
 	}
 }
 
-func (o *OtpService) ResendOtp(param model.GetOtp) error {
-	tx := o.db.Begin()
+func (o *OtpService) ResendOtp(ctx context.Context, param model.GetOtp) error {
+	tx := o.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
-	user, err := o.UserRepository.GetUser(model.UserParam{
+	user, err := o.UserRepository.GetUser(ctx, model.UserParam{
 		UserID: param.UserID,
 	})
 	if err != nil {
@@ -47,19 +66,21 @@ func (o *OtpService) ResendOtp(param model.GetOtp) error {
 	}
 
 	otp, err := o.OtpRepository.GetOtp(tx, model.GetOtp{
-		UserID: user.UserID,
+		UserID:  user.UserID,
+		Purpose: model.OtpPurposeVerify,
 	})
 	if err != nil {
 		return err
 	}
 
-	if otp.UpdatedAt.After(time.Now().UTC().Add(-5 * time.Minute)) {
-		return errors.New("you can only resend otp every 5 minutes")
+	err = checkCooldown("resend otp", otp.UpdatedAt, otpResendCooldown)
+	if err != nil {
+		return err
 	}
 
-	otp.Code = mail.GenerateCode()
+	otp.Code = o.GenerateCode()
 
-	err = mail.SendEmail(user.Email, "OTP Verification", fmt.Sprintf(`
+	err = o.Mailer.Send(user.Email, "OTP Verification", fmt.Sprintf(`
 		<!DOCTYPE html>
 		<html lang="id">
 		<head>
@@ -118,7 +139,7 @@ func (o *OtpService) ResendOtp(param model.GetOtp) error {
 
 							<tr>
 								<td align="center" style="padding: 10px 20px; font-family: Arial, sans-serif; font-size: 16px; line-height: 1.5; color: #d1d1d1;">
-									Gunakan kode di bawah ini untuk menyelesaikan proses verifikasi email Anda. Kode ini hanya berlaku selama 5 menit.
+									Gunakan kode di bawah ini untuk menyelesaikan proses verifikasi email Anda. Kode ini hanya berlaku selama %d menit.
 								</td>
 							</tr>
 
@@ -136,6 +157,8 @@ func (o *OtpService) ResendOtp(param model.GetOtp) error {
 								</td>
 							</tr>
 
+							%s
+
 							<tr>
 								<td align="center" style="padding: 30px 20px 20px 20px; font-family: Arial, sans-serif; font-size: 14px; line-height: 1.5; color: #a0a0a0;">
 									Jika Anda tidak merasa mendaftar untuk IT FEST, abaikan saja email ini.
@@ -155,7 +178,7 @@ func (o *OtpService) ResendOtp(param model.GetOtp) error {
 			</table>
 		</body>
 		</html>
-	`, otp.Code))
+	`, int(o.cfg.Otp.Verify.Minutes()), otp.Code, verificationLinkRow(o.Logger, o.JwtAuth, o.cfg, user.UserID, otp.Code)))
 	if err != nil {
 		return err
 	}
@@ -173,31 +196,39 @@ func (o *OtpService) ResendOtp(param model.GetOtp) error {
 	return nil
 }
 
-func (o *OtpService) ResendOtpChangePassword(param model.GetOtp) error {
-	tx := o.db.Begin()
+// ResendOtpChangePassword resends a pending reset code. Like ChangePassword, it
+// succeeds quietly when the email has no account or no pending reset.
+func (o *OtpService) ResendOtpChangePassword(ctx context.Context, param model.ForgotPasswordRequest) error {
+	tx := o.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
-	user, err := o.UserRepository.GetUser(model.UserParam{
-		UserID: param.UserID,
+	user, err := o.UserRepository.GetUser(ctx, model.UserParam{
+		Email: param.Email,
 	})
-	if err != nil {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
 		return err
 	}
 
 	otp, err := o.OtpRepository.GetOtp(tx, model.GetOtp{
-		UserID: user.UserID,
+		UserID:  user.UserID,
+		Purpose: model.OtpPurposeReset,
 	})
-	if err != nil {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
 		return err
 	}
 
-	if otp.UpdatedAt.After(time.Now().UTC().Add(-5 * time.Minute)) {
-		return errors.New("you can only resend otp every 5 minutes")
+	err = checkCooldown("resend otp", otp.UpdatedAt, otpResendCooldown)
+	if err != nil {
+		return err
 	}
 
-	otp.Code = mail.GenerateCode()
+	otp.Code = o.GenerateCode()
 
-	err = mail.SendEmail(user.Email, "Reset Password Token", "Your Reset Password Code is "+otp.Code+".")
+	err = o.Mailer.Send(user.Email, "Reset Password Token", "Your Reset Password Code is "+otp.Code+".")
 	if err != nil {
 		return err
 	}
@@ -215,3 +246,53 @@ func (o *OtpService) ResendOtpChangePassword(param model.GetOtp) error {
 	return nil
 
 }
+
+// checkCooldown returns a CooldownError while cooldown hasn't passed since
+// lastSent.
+func checkCooldown(action string, lastSent time.Time, cooldown time.Duration) error {
+	remaining := lastSent.Add(cooldown).Sub(time.Now().UTC())
+	if remaining > 0 {
+		return &model.CooldownError{
+			Action:    action,
+			Cooldown:  cooldown,
+			Remaining: remaining,
+		}
+	}
+
+	return nil
+}
+
+// otpExpired reports whether otp was last issued more than expiry before now.
+func otpExpired(otp *entity.OtpCode, expiry time.Duration, now time.Time) bool {
+	return otp.UpdatedAt.Before(now.UTC().Add(-expiry))
+}
+
+// verificationLinkRow renders the email verification button that sits under the
+// OTP. It renders nothing when FRONTEND_URL isn't set, leaving the code only.
+func verificationLinkRow(logger *slog.Logger, jwtAuth jwt.Interface, cfg *config.Config, userID uuid.UUID, code string) string {
+	if cfg.App.FrontendURL == "" {
+		return ""
+	}
+
+	token, err := jwtAuth.CreateVerificationToken(userID, code, cfg.Otp.Verify)
+	if err != nil {
+		logger.Error("failed to create verification link", "user_id", userID, "error", err)
+		return ""
+	}
+
+	link := cfg.App.FrontendURL + "/verify?token=" + url.QueryEscape(token)
+
+	return fmt.Sprintf(`
+							<tr>
+								<td align="center" style="padding: 0 20px 10px 20px; font-family: Arial, sans-serif; font-size: 14px; line-height: 1.5; color: #d1d1d1;">
+									Atau klik tombol di bawah ini untuk langsung memverifikasi email Anda.
+								</td>
+							</tr>
+
+							<tr>
+								<td align="center" style="padding: 10px 0 20px 0;">
+									<a href="%s" style="display: inline-block; border-radius: 8px; background-color: #85FFF5; padding: 12px 28px; font-family: Arial, sans-serif; font-size: 16px; font-weight: bold; color: #030D35; text-decoration: none;">Verifikasi Email</a>
+								</td>
+							</tr>
+`, html.EscapeString(link))
+}
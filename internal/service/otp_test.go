@@ -0,0 +1,39 @@
+package service
+
+import (
+	"itfest-2025/entity"
+	"testing"
+	"time"
+)
+
+func TestOtpExpired(t *testing.T) {
+	cfg := testConfig()
+	issued := time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC)
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	tests := []struct {
+		name   string
+		expiry time.Duration
+		now    time.Time
+		want   bool
+	}{
+		{name: "verify code just issued", expiry: cfg.Otp.Verify, now: issued, want: false},
+		{name: "verify code before OTP_VERIFY_EXPIRY", expiry: cfg.Otp.Verify, now: issued.Add(cfg.Otp.Verify - time.Second), want: false},
+		{name: "verify code at OTP_VERIFY_EXPIRY", expiry: cfg.Otp.Verify, now: issued.Add(cfg.Otp.Verify), want: false},
+		{name: "verify code after OTP_VERIFY_EXPIRY", expiry: cfg.Otp.Verify, now: issued.Add(cfg.Otp.Verify + time.Second), want: true},
+		{name: "reset code before OTP_RESET_EXPIRY", expiry: cfg.Otp.Reset, now: issued.Add(cfg.Otp.Reset - time.Second), want: false},
+		{name: "reset code after OTP_RESET_EXPIRY", expiry: cfg.Otp.Reset, now: issued.Add(cfg.Otp.Reset + time.Second), want: true},
+		{name: "reset code checked with the verify expiry", expiry: cfg.Otp.Verify, now: issued.Add(cfg.Otp.Reset + time.Second), want: false},
+		{name: "now in another time zone", expiry: cfg.Otp.Reset, now: issued.Add(cfg.Otp.Reset + time.Second).In(jakarta), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otp := &entity.OtpCode{UpdatedAt: issued}
+
+			if got := otpExpired(otp, tt.expiry, tt.now); got != tt.want {
+				t.Errorf("otpExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
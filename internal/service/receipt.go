@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"itfest-2025/entity"
+	"itfest-2025/internal/repository"
+	"itfest-2025/model"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/template"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type IReceiptService interface {
+	GenerateReceipt(ctx context.Context, teamID uuid.UUID) ([]byte, error)
+	GenerateMyReceipt(ctx context.Context, userID uuid.UUID) ([]byte, error)
+}
+
+type ReceiptService struct {
+	db                    *gorm.DB
+	cfg                   *config.Config
+	UserRepository        repository.IUserRepository
+	TeamRepository        repository.ITeamRepository
+	CompetitionRepository repository.ICompetitionRepository
+	CouponRepository      repository.ICouponRepository
+}
+
+func NewReceiptService(db *gorm.DB, userRepository repository.IUserRepository, teamRepository repository.ITeamRepository, competitionRepository repository.ICompetitionRepository, couponRepository repository.ICouponRepository, cfg *config.Config) IReceiptService {
+	return &ReceiptService{
+		db:                    db,
+		cfg:                   cfg,
+		UserRepository:        userRepository,
+		TeamRepository:        teamRepository,
+		CompetitionRepository: competitionRepository,
+		CouponRepository:      couponRepository,
+	}
+}
+
+// GenerateReceipt renders the PDF payment receipt of a team. Only teams whose
+// payment was approved have one.
+func (r *ReceiptService) GenerateReceipt(ctx context.Context, teamID uuid.UUID) ([]byte, error) {
+	db := r.db.WithContext(ctx)
+
+	team, err := r.TeamRepository.GetTeamByID(db, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.render(ctx, db, team)
+}
+
+// GenerateMyReceipt renders the payment receipt of the user's own team.
+func (r *ReceiptService) GenerateMyReceipt(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	db := r.db.WithContext(ctx)
+
+	team, err := teamByUserID(r.TeamRepository, db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.render(ctx, db, team)
+}
+
+// render charges the competition fee less the discount of the coupon the team
+// redeemed, if any.
+func (r *ReceiptService) render(ctx context.Context, db *gorm.DB, team *entity.Team) ([]byte, error) {
+	if team.TeamStatus != "terverifikasi" {
+		return nil, model.ErrPaymentNotApproved
+	}
+
+	leader, err := r.UserRepository.GetUser(ctx, model.UserParam{
+		UserID: team.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	competition, err := r.CompetitionRepository.GetCompetitionByID(db, team.CompetitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var discount int
+	var couponCode string
+	if team.CouponID != nil {
+		coupon, err := r.CouponRepository.GetCouponByID(db, *team.CouponID)
+		if err != nil {
+			return nil, err
+		}
+		discount = couponDiscount(coupon, competition.Fee)
+		couponCode = coupon.Code
+	}
+
+	location := r.cfg.App.Timezone
+	var approvedAt *time.Time
+	if team.PaymentApprovedAt != nil {
+		t := team.PaymentApprovedAt.In(location)
+		approvedAt = &t
+	}
+
+	return template.RenderReceipt(template.Receipt{
+		Number:          receiptNumber(team.TeamID),
+		TeamName:        team.TeamName,
+		LeaderName:      leader.FullName,
+		LeaderEmail:     leader.Email,
+		CompetitionName: competition.CompetitionName,
+		Fee:             competition.Fee,
+		Discount:        discount,
+		CouponCode:      couponCode,
+		Amount:          competition.Fee - discount,
+		ApprovedAt:      approvedAt,
+		IssuedAt:        time.Now().In(location),
+	})
+}
+
+// receiptNumber is stable for a team, so downloading the receipt again gives
+// the same number.
+func receiptNumber(teamID uuid.UUID) string {
+	return "ITF-" + strings.ToUpper(strings.ReplaceAll(teamID.String(), "-", "")[:12])
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"itfest-2025/entity"
+	"itfest-2025/internal/repository"
+	"itfest-2025/model"
+	"itfest-2025/pkg/clock"
+	"itfest-2025/pkg/database/mariadb"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openIntegrationDB connects to the MariaDB named by TEST_DATABASE_DSN and
+// migrates it. The capacity check relies on SELECT ... FOR UPDATE, which the
+// in-memory fakes can't reproduce, so tests that need it skip without a real
+// database. Point the DSN at a throwaway schema, for example
+// "root:secret@tcp(127.0.0.1:3306)/itfest_test?parseTime=True".
+func openIntegrationDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		Logger:         logger.Discard,
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+
+	err = mariadb.Migrate(db)
+	if err != nil {
+		t.Fatalf("failed to migrate the test database: %v", err)
+	}
+
+	return db
+}
+
+func TestCompetitionRegistrationRespectsCapacityUnderLoad(t *testing.T) {
+	db := openIntegrationDB(t)
+
+	const (
+		capacity = 3
+		teams    = 20
+	)
+
+	suffix := uuid.NewString()[:8]
+	deadline := time.Now().Add(24 * time.Hour)
+	home := &entity.Competition{CompetitionID: 900000 + int(uuid.New().ID()%90000), CompetitionName: "race home " + suffix, Deadline: deadline, IsRegistrationOpen: true}
+	target := &entity.Competition{CompetitionID: home.CompetitionID + 1, CompetitionName: "race target " + suffix, Capacity: capacity, Deadline: deadline, IsRegistrationOpen: true}
+	for _, competition := range []*entity.Competition{home, target} {
+		err := db.Create(competition).Error
+		if err != nil {
+			t.Fatalf("failed to create competition: %v", err)
+		}
+	}
+
+	userIDs := make([]uuid.UUID, teams)
+	for i := range userIDs {
+		user := &entity.User{
+			UserID:        uuid.New(),
+			Email:         fmt.Sprintf("race-%s-%d@example.com", suffix, i),
+			StatusAccount: "active",
+			AuthProvider:  "password",
+			RoleID:        entity.RoleUser,
+		}
+		err := db.Create(user).Error
+		if err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+
+		err = db.Create(&entity.Team{
+			TeamID:        uuid.New(),
+			TeamName:      fmt.Sprintf("race-%s-%d", suffix, i),
+			TeamStatus:    "belum terverifikasi",
+			UserID:        user.UserID,
+			CompetitionID: home.CompetitionID,
+		}).Error
+		if err != nil {
+			t.Fatalf("failed to create team: %v", err)
+		}
+		userIDs[i] = user.UserID
+	}
+
+	t.Cleanup(func() {
+		db.Unscoped().Where("user_id IN ?", userIDs).Delete(&entity.Notification{})
+		db.Unscoped().Where("user_id IN ?", userIDs).Delete(&entity.Team{})
+		db.Unscoped().Where("user_id IN ?", userIDs).Delete(&entity.User{})
+		db.Where("competition_id IN ?", []int{home.CompetitionID, target.CompetitionID}).Delete(&entity.Competition{})
+	})
+
+	repo := repository.NewRepository(db)
+	service := &UserService{
+		db:                     db,
+		cfg:                    testConfig(),
+		UserRepository:         repo.UserRepository,
+		TeamRepository:         repo.TeamRepository,
+		CompetitionRepository:  repo.CompetitionRepository,
+		CouponRepository:       repo.CouponRepository,
+		NotificationRepository: repo.NotificationRepository,
+		Clock:                  clock.Real(),
+		Logger:                 testLogger(),
+	}
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		registered int
+		waitlisted int
+	)
+	start := make(chan struct{})
+	for i, userID := range userIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			result, err := service.CompetitionRegistration(context.Background(), userID, target.CompetitionID, model.CompetitionRegistrationRequest{
+				FullName:      fmt.Sprintf("Leader %d", i),
+				StudentNumber: fmt.Sprintf("%015d", i),
+				University:    "Universitas Brawijaya",
+				Major:         "Sistem Informasi",
+				PhoneNumber:   "081234567890",
+			})
+			if err != nil {
+				t.Errorf("CompetitionRegistration() error = %v", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if result.Waitlisted {
+				waitlisted++
+			} else {
+				registered++
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	if registered != capacity {
+		t.Errorf("%d teams registered, want the capacity of %d", registered, capacity)
+	}
+	if waitlisted != teams-capacity {
+		t.Errorf("%d teams waitlisted, want %d", waitlisted, teams-capacity)
+	}
+
+	var count int64
+	err := db.Model(&entity.Team{}).
+		Where("competition_id = ? AND registered_at IS NOT NULL AND waitlisted_at IS NULL", target.CompetitionID).
+		Count(&count).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != capacity {
+		t.Errorf("%d teams hold a slot in the database, want %d", count, capacity)
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"itfest-2025/entity"
+	"itfest-2025/internal/repository"
+	"itfest-2025/model"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/mail"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IReminderService interface {
+	SendDeadlineReminders(ctx context.Context) error
+}
+
+type ReminderService struct {
+	db                     *gorm.DB
+	cfg                    *config.Config
+	ReminderRepository     repository.IReminderRepository
+	NotificationRepository repository.INotificationRepository
+	Mailer                 mail.Mailer
+	Logger                 *slog.Logger
+}
+
+func NewReminderService(db *gorm.DB, reminderRepository repository.IReminderRepository, notificationRepository repository.INotificationRepository, mailer mail.Mailer, logger *slog.Logger, cfg *config.Config) IReminderService {
+	return &ReminderService{
+		db:                     db,
+		cfg:                    cfg,
+		ReminderRepository:     reminderRepository,
+		NotificationRepository: notificationRepository,
+		Mailer:                 mailer,
+		Logger:                 logger,
+	}
+}
+
+// SendDeadlineReminders emails every verified team that hasn't submitted for a
+// stage due within REMINDER_WINDOW_HOURS (default 24). The reminder row is only
+// written once the queue has sent the email, so a failed send or a restart
+// before the queue drains leaves the reminder for the next run.
+func (r *ReminderService) SendDeadlineReminders(ctx context.Context) error {
+	now := time.Now()
+
+	reminders, err := r.ReminderRepository.GetDueDeadlineReminders(ctx, now, now.Add(r.cfg.App.ReminderWindow))
+	if err != nil {
+		return err
+	}
+
+	// The emails are sent after this run returns, so the callbacks must not
+	// inherit its cancellation.
+	db := r.db.WithContext(context.WithoutCancel(ctx))
+	queued := 0
+	for _, v := range reminders {
+		err := r.Mailer.Enqueue(v.Email, "Pengingat Deadline "+v.StageName, deadlineReminderMailBody(v.FullName, v.TeamName, v.StageName, v.Deadline), func(err error) {
+			r.recordReminder(db, v, err)
+		})
+		if err != nil {
+			r.Logger.ErrorContext(ctx, "failed to queue deadline reminder", "team_id", v.TeamID, "stage_id", v.StageID, "error", err)
+			continue
+		}
+
+		queued++
+	}
+
+	r.Logger.InfoContext(ctx, "queued deadline reminders", "count", queued)
+
+	return nil
+}
+
+// recordReminder runs once the queue has tried a reminder. Only a sent email is
+// recorded and turned into a notification.
+func (r *ReminderService) recordReminder(db *gorm.DB, v model.DeadlineReminder, sendErr error) {
+	if sendErr != nil {
+		r.Logger.Error("failed to send deadline reminder", "team_id", v.TeamID, "stage_id", v.StageID, "error", sendErr)
+		return
+	}
+
+	err := r.ReminderRepository.CreateStageReminder(db, &entity.StageReminder{
+		TeamID:  v.TeamID,
+		StageID: v.StageID,
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return
+	} else if err != nil {
+		r.Logger.Error("failed to record deadline reminder", "team_id", v.TeamID, "stage_id", v.StageID, "error", err)
+		return
+	}
+
+	notify(r.Logger, r.NotificationRepository, db, v.UserID, model.NotificationDeadlineReminder,
+		fmt.Sprintf("Tim %s belum mengumpulkan submission tahap %s. Batas pengumpulan %s.", v.TeamName, v.StageName, v.Deadline.In(r.cfg.App.Timezone).Format("02 January 2006 15:04")))
+}
+
+func deadlineReminderMailBody(name, teamName, stageName string, deadline time.Time) string {
+	return fmt.Sprintf(`
+		<p>Halo %s,</p>
+		<p>Tim <strong>%s</strong> belum mengumpulkan submission untuk tahap <strong>%s</strong>.</p>
+		<p>Batas pengumpulan adalah <strong>%s</strong>. Segera kumpulkan melalui Dashboard Anda.</p>
+		<p>Keluarga Besar Mahasiswa Departemen Sistem Informasi<br>Universitas Brawijaya</p>
+	`, html.EscapeString(name), html.EscapeString(teamName), html.EscapeString(stageName), deadline.Format("02 January 2006"))
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"itfest-2025/entity"
+	"itfest-2025/internal/repository"
+	"itfest-2025/model"
+	"itfest-2025/pkg/mail"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeReminderRepository struct {
+	repository.IReminderRepository
+
+	due []model.DeadlineReminder
+
+	mu        sync.Mutex
+	reminders []entity.StageReminder
+}
+
+func (r *fakeReminderRepository) GetDueDeadlineReminders(ctx context.Context, from time.Time, to time.Time) ([]model.DeadlineReminder, error) {
+	return r.due, nil
+}
+
+func (r *fakeReminderRepository) CreateStageReminder(tx *gorm.DB, reminder *entity.StageReminder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reminders = append(r.reminders, *reminder)
+	return nil
+}
+
+func newReminderServiceFixture(t *testing.T, mailer *mail.FakeMailer) (*ReminderService, *fakeReminderRepository, *fakeNotificationRepository) {
+	t.Helper()
+
+	db, _ := newTestDB(t)
+	reminders := &fakeReminderRepository{
+		due: []model.DeadlineReminder{{
+			TeamID:    uuid.New(),
+			TeamName:  "Tim A",
+			UserID:    uuid.New(),
+			FullName:  "Leader",
+			Email:     "leader@example.com",
+			StageID:   1,
+			StageName: "Proposal",
+			Deadline:  time.Now().Add(time.Hour),
+		}},
+	}
+	notifications := &fakeNotificationRepository{}
+
+	cfg := testConfig()
+	cfg.App.ReminderWindow = 24 * time.Hour
+
+	return &ReminderService{
+		db:                     db,
+		cfg:                    cfg,
+		ReminderRepository:     reminders,
+		NotificationRepository: notifications,
+		Mailer:                 mailer,
+		Logger:                 testLogger(),
+	}, reminders, notifications
+}
+
+func TestSendDeadlineRemindersRecordsSentReminder(t *testing.T) {
+	mailer := &mail.FakeMailer{}
+	service, reminders, notifications := newReminderServiceFixture(t, mailer)
+
+	err := service.SendDeadlineReminders(context.Background())
+	if err != nil {
+		t.Fatalf("SendDeadlineReminders() error = %v, want nil", err)
+	}
+
+	if len(mailer.Sent()) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(mailer.Sent()))
+	}
+	if len(reminders.reminders) != 1 {
+		t.Errorf("recorded %d reminders, want 1", len(reminders.reminders))
+	}
+	if len(notifications.notifications) != 1 {
+		t.Errorf("created %d notifications, want 1", len(notifications.notifications))
+	}
+}
+
+func TestSendDeadlineRemindersSkipsFailedSend(t *testing.T) {
+	mailer := &mail.FakeMailer{Err: errors.New("smtp unavailable")}
+	service, reminders, notifications := newReminderServiceFixture(t, mailer)
+
+	err := service.SendDeadlineReminders(context.Background())
+	if err != nil {
+		t.Fatalf("SendDeadlineReminders() error = %v, want nil", err)
+	}
+
+	if len(reminders.reminders) != 0 {
+		t.Error("a reminder whose email failed was recorded as sent")
+	}
+	if len(notifications.notifications) != 0 {
+		t.Error("a reminder whose email failed created a notification")
+	}
+}
@@ -3,8 +3,18 @@ package service
 import (
 	"itfest-2025/internal/repository"
 	"itfest-2025/pkg/bcrypt"
+	"itfest-2025/pkg/captcha"
+	"itfest-2025/pkg/clock"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/google"
 	"itfest-2025/pkg/jwt"
-	"itfest-2025/pkg/supabase"
+	"itfest-2025/pkg/mail"
+	"itfest-2025/pkg/storage"
+	"itfest-2025/pkg/webhook"
+	"itfest-2025/pkg/whatsapp"
+	"log/slog"
+
+	"gorm.io/gorm"
 )
 
 type Service struct {
@@ -16,17 +26,29 @@ type Service struct {
 	ExcelService        IExcelService
 	CountService        ICountService
 	AnnouncementService IAnnouncementService
+	SupportService      ISupportService
+	CouponService       ICouponService
+	AuditService        IAuditService
+	ReminderService     IReminderService
+	ReceiptService      IReceiptService
+	NotificationService INotificationService
 }
 
-func NewService(repository *repository.Repository, bcrypt bcrypt.Interface, jwtAuth jwt.Interface, supabase supabase.Interface) *Service {
+func NewService(cfg *config.Config, db *gorm.DB, repository *repository.Repository, bcrypt bcrypt.Interface, jwtAuth jwt.Interface, storage storage.Interface, whatsapp whatsapp.Interface, google google.Interface, captcha captcha.Interface, webhook webhook.Interface, mailer mail.Mailer, logger *slog.Logger) *Service {
 	return &Service{
-		UserService:         NewUserService(repository.UserRepository, repository.TeamRepository, repository.OtpRepository, repository.CompetitionRepository, bcrypt, jwtAuth, supabase),
-		TeamService:         NewTeamService(repository.UserRepository, repository.TeamRepository, repository.CompetitionRepository, repository.SubmissionRepository),
-		OtpService:          NewOtpService(repository.OtpRepository, repository.UserRepository),
-		SubmissionService:   NewSubmissionService(repository.SubmissionRepository, repository.TeamRepository),
-		CompetitionService:  NewCompetitionService(repository.CompetitionRepository),
-		ExcelService:        NewExcelService(repository.TeamRepository, repository.CompetitionRepository, repository.UserRepository),
-		CountService:        NewCountService(repository.TeamRepository, repository.UserRepository),
-		AnnouncementService: NewAnnouncementService(repository.UserRepository, repository.TeamRepository, repository.AnnouncementRepository),
+		UserService:         NewUserService(db, repository.UserRepository, repository.TeamRepository, repository.OtpRepository, repository.CompetitionRepository, repository.IdempotencyRepository, repository.LoginFingerprintRepository, repository.LoginHistoryRepository, repository.CouponRepository, repository.PasswordHistoryRepository, repository.AuditLogRepository, repository.RoleRepository, repository.SubmissionRepository, repository.NotificationRepository, bcrypt, jwtAuth, storage, google, captcha, mailer, clock.Real(), logger, cfg),
+		TeamService:         NewTeamService(db, repository.UserRepository, repository.TeamRepository, repository.CompetitionRepository, repository.SubmissionRepository, repository.AuditLogRepository, repository.NotificationRepository, whatsapp, webhook, mailer, logger, cfg),
+		OtpService:          NewOtpService(db, repository.OtpRepository, repository.UserRepository, jwtAuth, mailer, logger, cfg),
+		SubmissionService:   NewSubmissionService(db, repository.SubmissionRepository, repository.TeamRepository, repository.UserRepository, repository.CompetitionRepository, repository.AuditLogRepository, repository.NotificationRepository, mailer, logger, cfg),
+		CompetitionService:  NewCompetitionService(db, repository.CompetitionRepository, repository.AuditLogRepository, cfg),
+		ExcelService:        NewExcelService(db, repository.TeamRepository, repository.CompetitionRepository, repository.UserRepository),
+		CountService:        NewCountService(db, repository.TeamRepository, repository.UserRepository),
+		AnnouncementService: NewAnnouncementService(db, repository.UserRepository, repository.TeamRepository, repository.AnnouncementRepository, repository.CompetitionRepository, mailer),
+		SupportService:      NewSupportService(db, repository.SupportMessageRepository, mailer, logger, cfg),
+		CouponService:       NewCouponService(db, repository.CouponRepository, repository.CompetitionRepository),
+		AuditService:        NewAuditService(repository.AuditLogRepository),
+		ReminderService:     NewReminderService(db, repository.ReminderRepository, repository.NotificationRepository, mailer, logger, cfg),
+		NotificationService: NewNotificationService(db, repository.NotificationRepository),
+		ReceiptService:      NewReceiptService(db, repository.UserRepository, repository.TeamRepository, repository.CompetitionRepository, repository.CouponRepository, cfg),
 	}
 }
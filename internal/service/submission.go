@@ -1,11 +1,18 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"html"
 	"itfest-2025/entity"
 	"itfest-2025/internal/repository"
 	"itfest-2025/model"
-	"itfest-2025/pkg/database/mariadb"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/mail"
+	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,43 +20,62 @@ import (
 )
 
 type ISubmissionService interface {
-	GetSubmission(param *model.ReqFilterSubmission) ([]entity.TeamProgress, error)
-	GetCurrentStage(userID uuid.UUID) (model.ResStage, error)
-	CreateSubmission(userID uuid.UUID, param *model.ReqSubmission) error
-	UpdateStatusSubmission(teamID string, stageID string, param *model.RequestUpdateStatusSubmission) error
+	GetSubmission(ctx context.Context, param *model.ReqFilterSubmission) ([]entity.TeamProgress, error)
+	GetCurrentStage(ctx context.Context, userID uuid.UUID) (model.ResStage, error)
+	CreateSubmission(ctx context.Context, userID uuid.UUID, param *model.ReqSubmission) error
+	UpdateStatusSubmission(ctx context.Context, actorID uuid.UUID, teamID string, stageID string, param *model.RequestUpdateStatusSubmission) error
+	GetMyProgress(ctx context.Context, userID uuid.UUID) ([]model.StageProgress, error)
+	GradeSubmission(ctx context.Context, actorID uuid.UUID, teamProgressID int, param model.GradeSubmissionRequest) error
+	ScoreSubmission(ctx context.Context, actorID uuid.UUID, stageID int, teamID uuid.UUID, param model.GradeSubmissionRequest) error
+	GetStageLeaderboard(ctx context.Context, stageID int) ([]model.StageLeaderboardEntry, error)
+	GetLeaderboard(ctx context.Context, competitionID int, query model.LeaderboardQuery) (*model.Paginated[model.LeaderboardEntry], error)
 }
 
 type SubmissionService struct {
-	db                   *gorm.DB
-	SubmissionRepository repository.ISubmissionRepository
-	TeamRepository       repository.ITeamRepository
+	db                     *gorm.DB
+	cfg                    *config.Config
+	SubmissionRepository   repository.ISubmissionRepository
+	TeamRepository         repository.ITeamRepository
+	UserRepository         repository.IUserRepository
+	CompetitionRepository  repository.ICompetitionRepository
+	AuditLogRepository     repository.IAuditLogRepository
+	NotificationRepository repository.INotificationRepository
+	Mailer                 mail.Mailer
+	Logger                 *slog.Logger
 }
 
-func NewSubmissionService(submissionRepository repository.ISubmissionRepository, teamRepository repository.ITeamRepository) ISubmissionService {
+func NewSubmissionService(db *gorm.DB, submissionRepository repository.ISubmissionRepository, teamRepository repository.ITeamRepository, userRepository repository.IUserRepository, competitionRepository repository.ICompetitionRepository, auditLogRepository repository.IAuditLogRepository, notificationRepository repository.INotificationRepository, mailer mail.Mailer, logger *slog.Logger, cfg *config.Config) ISubmissionService {
 	return &SubmissionService{
-		db:                   mariadb.Connection,
-		SubmissionRepository: submissionRepository,
-		TeamRepository:       teamRepository,
+		db:                     db,
+		cfg:                    cfg,
+		SubmissionRepository:   submissionRepository,
+		TeamRepository:         teamRepository,
+		UserRepository:         userRepository,
+		CompetitionRepository:  competitionRepository,
+		AuditLogRepository:     auditLogRepository,
+		NotificationRepository: notificationRepository,
+		Mailer:                 mailer,
+		Logger:                 logger,
 	}
 }
 
-func (s *SubmissionService) GetSubmission(param *model.ReqFilterSubmission) ([]entity.TeamProgress, error) {
-	return s.SubmissionRepository.GetSubmission(param)
+func (s *SubmissionService) GetSubmission(ctx context.Context, param *model.ReqFilterSubmission) ([]entity.TeamProgress, error) {
+	return s.SubmissionRepository.GetSubmission(ctx, param)
 }
 
-func (s *SubmissionService) GetCurrentStage(userID uuid.UUID) (model.ResStage, error) {
+func (s *SubmissionService) GetCurrentStage(ctx context.Context, userID uuid.UUID) (model.ResStage, error) {
 	var data model.ResStage
-	tx := s.db.Begin()
+	tx := s.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
-	team, err := s.TeamRepository.GetTeamByUserID(tx, userID)
+	team, err := teamByUserID(s.TeamRepository, tx, userID)
 	if err != nil {
 		return data, err
 	}
 
-	currentStage, err := s.SubmissionRepository.GetCurrentStage(team)
+	currentStage, err := s.SubmissionRepository.GetCurrentStage(ctx, team)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		firstStage, err := s.SubmissionRepository.GetFirstStage(team.CompetitionID)
+		firstStage, err := s.SubmissionRepository.GetFirstStage(ctx, team.CompetitionID)
 		if err != nil {
 			return data, err
 		}
@@ -64,9 +90,9 @@ func (s *SubmissionService) GetCurrentStage(userID uuid.UUID) (model.ResStage, e
 	} else if err != nil {
 		return data, err
 	}
-	submission, err := s.SubmissionRepository.GetSubmission(&model.ReqFilterSubmission{
+	submission, err := s.SubmissionRepository.GetSubmission(ctx, &model.ReqFilterSubmission{
 		StageID: data.IDCurrentStage,
-		TeamID: team.TeamID.String(),
+		TeamID:  team.TeamID.String(),
 	})
 
 	if submission[0].Status == "diproses" || submission[0].Status == "tidak lolos" {
@@ -78,7 +104,7 @@ func (s *SubmissionService) GetCurrentStage(userID uuid.UUID) (model.ResStage, e
 		}, nil
 	}
 
-	nextStage, err := s.SubmissionRepository.GetNextStage(currentStage.StageID, team.CompetitionID)
+	nextStage, err := s.SubmissionRepository.GetNextStage(ctx, currentStage.StageID, team.CompetitionID)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return data, err
 	}
@@ -93,24 +119,24 @@ func (s *SubmissionService) GetCurrentStage(userID uuid.UUID) (model.ResStage, e
 	return data, nil
 }
 
-func (s *SubmissionService) CreateSubmission(userID uuid.UUID, param *model.ReqSubmission) error {
-	tx := s.db.Begin()
+func (s *SubmissionService) CreateSubmission(ctx context.Context, userID uuid.UUID, param *model.ReqSubmission) error {
+	tx := s.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
-	stage, err := s.GetCurrentStage(userID)
+	stage, err := s.GetCurrentStage(ctx, userID)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	team, err := s.TeamRepository.GetTeamByUserID(tx, userID)
+	team, err := teamByUserID(s.TeamRepository, tx, userID)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
-	submission, err := s.SubmissionRepository.GetSubmission(&model.ReqFilterSubmission{
+	submission, err := s.SubmissionRepository.GetSubmission(ctx, &model.ReqFilterSubmission{
 		StageID: stage.IDCurrentStage,
-		TeamID: team.TeamID.String(),
+		TeamID:  team.TeamID.String(),
 	})
 
 	if len(submission) > 0 {
@@ -128,10 +154,14 @@ func (s *SubmissionService) CreateSubmission(userID uuid.UUID, param *model.ReqS
 	}
 
 	// Verifikasi status
-	if team.TeamStatus == "belum terverifikasi" {
+	if team.TeamStatus != "terverifikasi" {
 		return model.ErrUnverifiedAccount
 	}
 
+	err = checkTeamMinimum(s.TeamRepository, s.CompetitionRepository, tx, team)
+	if err != nil {
+		return err
+	}
 
 	newSubmission := &entity.TeamProgress{
 		StageID:    stage.IDNextStage,
@@ -148,6 +178,253 @@ func (s *SubmissionService) CreateSubmission(userID uuid.UUID, param *model.ReqS
 	return tx.Commit().Error
 }
 
-func (s *SubmissionService) UpdateStatusSubmission(teamID string, stageID string, param *model.RequestUpdateStatusSubmission) error {
-	return s.SubmissionRepository.UpdateStatusSubmission(s.db, teamID, stageID, *param)
+func (s *SubmissionService) UpdateStatusSubmission(ctx context.Context, actorID uuid.UUID, teamID string, stageID string, param *model.RequestUpdateStatusSubmission) error {
+	err := withTransaction(ctx, s.db, func(tx *gorm.DB) error {
+		err := s.SubmissionRepository.UpdateStatusSubmission(tx, teamID, stageID, *param)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(s.AuditLogRepository, tx, model.AuditEntry{
+			ActorID:    &actorID,
+			Action:     model.AuditActionSubmissionStatus,
+			TargetType: "team",
+			TargetID:   teamID,
+			Metadata: map[string]interface{}{
+				"stage_id": stageID,
+				"status":   param.SubmissionStatus,
+			},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notifySubmissionStatus(context.WithoutCancel(ctx), teamID, stageID, param.SubmissionStatus)
+
+	return nil
+}
+
+// notifySubmissionStatus tells the team leader the outcome of a stage. The
+// status is already saved, so a failed lookup is only logged.
+func (s *SubmissionService) notifySubmissionStatus(ctx context.Context, teamID string, stageID string, status string) {
+	db := s.db.WithContext(ctx)
+
+	id, err := uuid.Parse(teamID)
+	if err != nil {
+		return
+	}
+
+	team, err := s.TeamRepository.GetTeamByID(db, id)
+	if err != nil {
+		s.Logger.ErrorContext(ctx, "failed to load team for submission status notification", "team_id", teamID, "error", err)
+		return
+	}
+
+	stageName := "stage " + stageID
+	if id, err := strconv.Atoi(stageID); err == nil {
+		stage, err := s.SubmissionRepository.GetStage(db, id)
+		if err == nil {
+			stageName = stage.StageName
+		}
+	}
+
+	notify(s.Logger, s.NotificationRepository, db, team.UserID, model.NotificationSubmissionStatus, fmt.Sprintf("Tim Anda dinyatakan %s pada tahap %s.", status, stageName))
+}
+
+// GetMyProgress lists every stage of the team's competition in order, with the
+// team's submission for it. A submission is on time when it was made no later
+// than the deadline, the same rule CreateSubmission enforces.
+func (s *SubmissionService) GetMyProgress(ctx context.Context, userID uuid.UUID) ([]model.StageProgress, error) {
+	db := s.db.WithContext(ctx)
+
+	team, err := teamByUserID(s.TeamRepository, db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stages, err := s.SubmissionRepository.GetStageProgress(db, team.TeamID, team.CompetitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range stages {
+		if v.SubmittedAt != nil {
+			onTime := !v.SubmittedAt.After(v.Deadline)
+			stages[i].OnTime = &onTime
+		}
+	}
+
+	return stages, nil
+}
+
+// GradeSubmission records a judge's score and feedback on a stage submission.
+// The score must be within SCORE_MIN and SCORE_MAX.
+func (s *SubmissionService) GradeSubmission(ctx context.Context, actorID uuid.UUID, teamProgressID int, param model.GradeSubmissionRequest) error {
+	return s.grade(ctx, actorID, param, func(tx *gorm.DB) (*entity.TeamProgress, error) {
+		return s.SubmissionRepository.GetSubmissionByID(tx, teamProgressID)
+	})
+}
+
+// ScoreSubmission grades a team's latest submission for a stage, for judges
+// who work from a stage's list of teams rather than submission IDs.
+func (s *SubmissionService) ScoreSubmission(ctx context.Context, actorID uuid.UUID, stageID int, teamID uuid.UUID, param model.GradeSubmissionRequest) error {
+	return s.grade(ctx, actorID, param, func(tx *gorm.DB) (*entity.TeamProgress, error) {
+		return s.SubmissionRepository.GetLatestSubmission(tx, stageID, teamID)
+	})
+}
+
+// grade scores the submission returned by find on behalf of actorID.
+func (s *SubmissionService) grade(ctx context.Context, actorID uuid.UUID, param model.GradeSubmissionRequest, find func(tx *gorm.DB) (*entity.TeamProgress, error)) error {
+	scoreRange := s.cfg.Score
+	if *param.Score < scoreRange.Min || *param.Score > scoreRange.Max {
+		return model.ValidationErrors{
+			"score": fmt.Sprintf("must be between %g and %g", scoreRange.Min, scoreRange.Max),
+		}
+	}
+
+	var (
+		submission *entity.TeamProgress
+		stage      entity.Stages
+	)
+	err := withTransaction(ctx, s.db, func(tx *gorm.DB) error {
+		var err error
+		submission, err = find(tx)
+		if err != nil {
+			return err
+		}
+
+		stage, err = s.SubmissionRepository.GetStage(tx, submission.StageID)
+		if err != nil {
+			return err
+		}
+
+		err = s.SubmissionRepository.GradeSubmission(tx, submission.TeamProgressID, *param.Score, strings.TrimSpace(param.Feedback), time.Now())
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(s.AuditLogRepository, tx, model.AuditEntry{
+			ActorID:    &actorID,
+			Action:     model.AuditActionSubmissionGrade,
+			TargetType: "team_progress",
+			TargetID:   strconv.Itoa(submission.TeamProgressID),
+			Metadata: map[string]interface{}{
+				"team_id":  submission.TeamID,
+				"stage_id": submission.StageID,
+				"score":    *param.Score,
+			},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if param.Notify {
+		s.notifyGrade(ctx, submission.TeamID, stage.StageName, *param.Score, strings.TrimSpace(param.Feedback))
+	}
+
+	return nil
+}
+
+// notifyGrade emails the team leader in the background. The grade is already
+// saved, so a failed lookup is only logged.
+func (s *SubmissionService) notifyGrade(ctx context.Context, teamID uuid.UUID, stageName string, score float64, feedback string) {
+	team, err := s.TeamRepository.GetTeamByID(s.db.WithContext(ctx), teamID)
+	if err != nil {
+		s.Logger.ErrorContext(ctx, "failed to load team for grade notification", "team_id", teamID, "error", err)
+		return
+	}
+
+	notify(s.Logger, s.NotificationRepository, s.db.WithContext(ctx), team.UserID, model.NotificationGrade, fmt.Sprintf("Submission tahap %s telah dinilai dengan skor %g.", stageName, score))
+
+	leader, err := s.UserRepository.GetUser(ctx, model.UserParam{
+		UserID: team.UserID,
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx, "failed to load team leader for grade notification", "team_id", teamID, "error", err)
+		return
+	}
+
+	mail.SendAsync(s.Mailer, leader.Email, "Hasil Penilaian "+stageName, gradeMailBody(team.TeamName, stageName, score, feedback))
+}
+
+func (s *SubmissionService) GetStageLeaderboard(ctx context.Context, stageID int) ([]model.StageLeaderboardEntry, error) {
+	db := s.db.WithContext(ctx)
+
+	_, err := s.SubmissionRepository.GetStage(db, stageID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.SubmissionRepository.GetStageLeaderboard(db, stageID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+
+	return entries, nil
+}
+
+func (s *SubmissionService) GetLeaderboard(ctx context.Context, competitionID int, query model.LeaderboardQuery) (*model.Paginated[model.LeaderboardEntry], error) {
+	query.Normalize()
+
+	var deadlineBefore *time.Time
+	if query.CompletedOnly {
+		now := time.Now()
+		deadlineBefore = &now
+	}
+
+	entries, total, err := s.SubmissionRepository.GetLeaderboard(s.db.WithContext(ctx), competitionID, deadlineBefore, query.Offset(), query.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Rank = query.Offset() + i + 1
+	}
+
+	return model.NewPaginated(entries, query.PaginationQuery, total), nil
+}
+
+func gradeMailBody(teamName, stageName string, score float64, feedback string) string {
+	if feedback == "" {
+		feedback = "-"
+	}
+
+	return fmt.Sprintf(`
+		<p>Halo tim <strong>%s</strong>,</p>
+		<p>Submission Anda untuk tahap <strong>%s</strong> telah dinilai.</p>
+		<p><strong>Nilai:</strong> %g</p>
+		<p><strong>Feedback:</strong><br>%s</p>
+		<p>Keluarga Besar Mahasiswa Departemen Sistem Informasi<br>Universitas Brawijaya</p>
+	`, html.EscapeString(teamName), html.EscapeString(stageName), score,
+		strings.ReplaceAll(html.EscapeString(feedback), "\n", "<br>"))
+}
+
+// checkTeamMinimum returns a TeamSizeError when team has fewer members than
+// its competition requires.
+func checkTeamMinimum(teamRepository repository.ITeamRepository, competitionRepository repository.ICompetitionRepository, tx *gorm.DB, team *entity.Team) error {
+	competition, err := competitionRepository.GetCompetitionByID(tx, team.CompetitionID)
+	if err != nil {
+		return err
+	}
+
+	members, err := teamRepository.GetTeamMemberByTeamID(tx, team.TeamID)
+	if err != nil {
+		return err
+	}
+
+	if len(members) < competition.MinMembers {
+		return &model.TeamSizeError{
+			Members: len(members),
+			Min:     competition.MinMembers,
+			Max:     competition.MaxMembers,
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"itfest-2025/entity"
+	"itfest-2025/internal/repository"
+	"itfest-2025/model"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/mail"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ISupportService interface {
+	SubmitSupportMessage(ctx context.Context, param model.SupportMessage) error
+}
+
+type SupportService struct {
+	db                       *gorm.DB
+	cfg                      *config.Config
+	SupportMessageRepository repository.ISupportMessageRepository
+	Mailer                   mail.Mailer
+	Logger                   *slog.Logger
+}
+
+func NewSupportService(db *gorm.DB, supportMessageRepository repository.ISupportMessageRepository, mailer mail.Mailer, logger *slog.Logger, cfg *config.Config) ISupportService {
+	return &SupportService{
+		db:                       db,
+		cfg:                      cfg,
+		SupportMessageRepository: supportMessageRepository,
+		Mailer:                   mailer,
+		Logger:                   logger,
+	}
+}
+
+// SubmitSupportMessage stores the message and forwards it to SUPPORT_INBOX_EMAIL.
+// The stored row is the source of truth, so a failed forward is only logged.
+func (s *SupportService) SubmitSupportMessage(ctx context.Context, param model.SupportMessage) error {
+	err := param.Validate()
+	if err != nil {
+		return err
+	}
+
+	message := &entity.SupportMessage{
+		SupportMessageID: uuid.New(),
+		UserID:           param.UserID,
+		Name:             param.Name,
+		Email:            param.Email,
+		Subject:          param.Subject,
+		Body:             param.Body,
+		IPAddress:        param.IPAddress,
+	}
+
+	err = withTransaction(ctx, s.db, func(tx *gorm.DB) error {
+		return s.SupportMessageRepository.CreateSupportMessage(tx, message)
+	})
+	if err != nil {
+		return err
+	}
+
+	inbox := s.cfg.App.SupportInboxEmail
+	if inbox == "" {
+		s.Logger.WarnContext(ctx, "SUPPORT_INBOX_EMAIL is not set, support message was not forwarded", "support_message_id", message.SupportMessageID)
+		return nil
+	}
+
+	err = s.Mailer.Send(inbox, "[Support] "+message.Subject, supportMailBody(message))
+	if err != nil {
+		s.Logger.ErrorContext(ctx, "failed to forward support message", "support_message_id", message.SupportMessageID, "error", err)
+	}
+
+	return nil
+}
+
+func supportMailBody(message *entity.SupportMessage) string {
+	sender := "-"
+	if message.UserID != nil {
+		sender = message.UserID.String()
+	}
+
+	name := message.Name
+	if name == "" {
+		name = "-"
+	}
+
+	return fmt.Sprintf(`
+		<p><strong>Name:</strong> %s</p>
+		<p><strong>Email:</strong> %s</p>
+		<p><strong>User ID:</strong> %s</p>
+		<p><strong>Subject:</strong> %s</p>
+		<p>%s</p>
+	`, html.EscapeString(name), html.EscapeString(message.Email), sender, html.EscapeString(message.Subject),
+		strings.ReplaceAll(html.EscapeString(message.Body), "\n", "<br>"))
+}
@@ -1,82 +1,180 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"html"
 	"itfest-2025/entity"
 	"itfest-2025/internal/repository"
 	"itfest-2025/model"
-	"itfest-2025/pkg/database/mariadb"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/mail"
+	"itfest-2025/pkg/webhook"
+	"itfest-2025/pkg/whatsapp"
+	"log/slog"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// paymentConfirmationCooldown limits how often a leader can have the payment
+// approval email sent again.
+const paymentConfirmationCooldown = 5 * time.Minute
+
 type ITeamService interface {
-	UpsertTeam(userID uuid.UUID, param *model.UpsertTeamRequest) (*model.UpsertTeamResponse, error)
-	GetMembersByUserID(userID uuid.UUID) (*model.TeamInfoResponse, error)
-	GetAllTeam() ([]*model.GetAllTeamsResponse, error)
-	UpdateTeamStatus(id string, req model.ReqUpdateStatusTeam) error
-	GetTeamByID(teamID uuid.UUID) (*model.TeamInfoResponseAdmin, error)
-	GetDetailTeam(teamID uuid.UUID) (*model.TeamDetailProgress, error)
-	GetProgressByUserID(userID uuid.UUID) (*model.TeamDetailProgress, error)
+	UpsertTeam(ctx context.Context, userID uuid.UUID, param *model.UpsertTeamRequest) (*model.UpsertTeamResponse, error)
+	GetMembersByUserID(ctx context.Context, userID uuid.UUID) (*model.TeamInfoResponse, error)
+	GetAllTeam(ctx context.Context, page model.PaginationQuery) (*model.Paginated[*model.GetAllTeamsResponse], error)
+	UpdateTeamStatus(ctx context.Context, actorID uuid.UUID, id string, req model.ReqUpdateStatusTeam) error
+	BulkApprovePayments(ctx context.Context, actorID uuid.UUID, teamIDs []uuid.UUID) (model.BulkResult, error)
+	ResendPaymentConfirmation(ctx context.Context, userID uuid.UUID) error
+	UpdateTeamCompetition(ctx context.Context, actorID uuid.UUID, teamID uuid.UUID, competitionID int) error
+	PromoteWaitlistedTeams(ctx context.Context, actorID uuid.UUID, competitionID int) (model.WaitlistPromotionResult, error)
+	GetTeamByID(ctx context.Context, teamID uuid.UUID) (*model.TeamInfoResponseAdmin, error)
+	GetDetailTeam(ctx context.Context, teamID uuid.UUID) (*model.TeamDetailProgress, error)
+	GetProgressByUserID(ctx context.Context, userID uuid.UUID) (*model.TeamDetailProgress, error)
+	SetTeamName(ctx context.Context, userID uuid.UUID, param model.SetTeamNameRequest) (*model.SetTeamNameResponse, error)
 }
 
 type TeamService struct {
-	db                    *gorm.DB
-	UserRepository        repository.IUserRepository
-	TeamRepository        repository.ITeamRepository
-	CompetitionRepository repository.ICompetitionRepository
-	SubmissionRepository  repository.ISubmissionRepository
+	db                     *gorm.DB
+	cfg                    *config.Config
+	UserRepository         repository.IUserRepository
+	TeamRepository         repository.ITeamRepository
+	CompetitionRepository  repository.ICompetitionRepository
+	SubmissionRepository   repository.ISubmissionRepository
+	AuditLogRepository     repository.IAuditLogRepository
+	NotificationRepository repository.INotificationRepository
+	WhatsApp               whatsapp.Interface
+	Webhook                webhook.Interface
+	Mailer                 mail.Mailer
+	Logger                 *slog.Logger
 }
 
-func NewTeamService(userRepository repository.IUserRepository, teamRepository repository.ITeamRepository, competitionRepository repository.ICompetitionRepository, submissionRepository repository.ISubmissionRepository) ITeamService {
+func NewTeamService(db *gorm.DB, userRepository repository.IUserRepository, teamRepository repository.ITeamRepository, competitionRepository repository.ICompetitionRepository, submissionRepository repository.ISubmissionRepository, auditLogRepository repository.IAuditLogRepository, notificationRepository repository.INotificationRepository, whatsapp whatsapp.Interface, webhook webhook.Interface, mailer mail.Mailer, logger *slog.Logger, cfg *config.Config) ITeamService {
 	return &TeamService{
-		db:                    mariadb.Connection,
-		UserRepository:        userRepository,
-		TeamRepository:        teamRepository,
-		CompetitionRepository: competitionRepository,
-		SubmissionRepository:  submissionRepository,
+		db:                     db,
+		cfg:                    cfg,
+		UserRepository:         userRepository,
+		TeamRepository:         teamRepository,
+		CompetitionRepository:  competitionRepository,
+		SubmissionRepository:   submissionRepository,
+		AuditLogRepository:     auditLogRepository,
+		NotificationRepository: notificationRepository,
+		WhatsApp:               whatsapp,
+		Webhook:                webhook,
+		Mailer:                 mailer,
+		Logger:                 logger,
 	}
 }
 
-func (t *TeamService) UpsertTeam(userID uuid.UUID, param *model.UpsertTeamRequest) (*model.UpsertTeamResponse, error) {
-	if len(param.Members) > 2 {
-		return nil, errors.New("maximum of 2 team members allowed")
+func (t *TeamService) UpsertTeam(ctx context.Context, userID uuid.UUID, param *model.UpsertTeamRequest) (*model.UpsertTeamResponse, error) {
+	err := param.Validate()
+	if err != nil {
+		return nil, err
 	}
 
-	tx := t.db.Begin()
+	tx := t.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
+	leader, err := t.UserRepository.GetUser(ctx, model.UserParam{
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	studentNumbers := map[string]bool{}
+	if leader.StudentNumber != "" {
+		studentNumbers[model.NormalizeStudentNumber(leader.StudentNumber)] = true
+	}
+	for _, v := range param.Members {
+		if studentNumbers[v.StudentNumber] {
+			return nil, model.ErrDuplicateStudentNumber
+		}
+		studentNumbers[v.StudentNumber] = true
+	}
+
 	team, err := t.TeamRepository.GetTeamByUserID(tx, userID)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
 
+	var competitionID int
+	if team != nil {
+		competitionID = team.CompetitionID
+	} else {
+		competitionID, err = defaultCompetitionID(t.CompetitionRepository, tx, t.cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	competition, err := t.CompetitionRepository.GetCompetitionByID(tx, competitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the maximum is enforced here so a team can be filled in over
+	// several saves; the minimum is checked when the team submits.
+	if len(param.Members) > competition.MaxMembers {
+		return nil, &model.TeamSizeError{
+			Members: len(param.Members),
+			Min:     competition.MinMembers,
+			Max:     competition.MaxMembers,
+		}
+	}
+
+	err = t.checkMembersUnregistered(tx, competitionID, team, studentNumbers)
+	if err != nil {
+		return nil, err
+	}
+
+	// The name is optional here; a team that leaves it out keeps its current
+	// one. When given it gets the same checks as SetTeamName.
+	if param.TeamName != "" {
+		teamID := uuid.Nil
+		if team != nil {
+			teamID = team.TeamID
+		}
+
+		err = t.checkTeamName(tx, competitionID, param.TeamName, teamID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if team == nil {
 		teamID := uuid.New()
 		newTeam := &entity.Team{
 			TeamID:        teamID,
 			TeamName:      param.TeamName,
 			TeamStatus:    "belum terverifikasi",
-			CompetitionID: 1,
+			CompetitionID: competitionID,
 			UserID:        userID,
 		}
 
-		err := t.TeamRepository.GetTeamByName(tx, param.TeamName)
-		if err == nil {
-			return nil, errors.New("team name already exists")
-		}
-
 		err = t.TeamRepository.CreateTeam(tx, newTeam)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, model.ErrTeamNameTaken
+		}
 		if err != nil {
 			return nil, err
 		}
 		team = newTeam
 	} else {
-		team.TeamName = param.TeamName
+		if param.TeamName != "" {
+			team.TeamName = param.TeamName
+		}
 
 		err := t.TeamRepository.UpdateTeam(tx, team)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, model.ErrTeamNameTaken
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -112,11 +210,40 @@ func (t *TeamService) UpsertTeam(userID uuid.UUID, param *model.UpsertTeamReques
 	return &response, nil
 }
 
-func (t *TeamService) GetMembersByUserID(userID uuid.UUID) (*model.TeamInfoResponse, error) {
-	tx := t.db.Begin()
+// checkMembersUnregistered rejects student numbers that are already a leader or
+// member of another team in the competition, so nobody is registered twice.
+func (t *TeamService) checkMembersUnregistered(tx *gorm.DB, competitionID int, team *entity.Team, studentNumbers map[string]bool) error {
+	numbers := make([]string, 0, len(studentNumbers))
+	for v := range studentNumbers {
+		numbers = append(numbers, v)
+	}
+
+	teamID := uuid.Nil
+	if team != nil {
+		teamID = team.TeamID
+	}
+
+	conflicts, err := t.TeamRepository.GetStudentNumberConflicts(tx, competitionID, numbers, teamID)
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		return &model.DuplicateMemberError{
+			StudentNumber: conflicts[0].StudentNumber,
+			TeamID:        conflicts[0].TeamID,
+			TeamName:      conflicts[0].TeamName,
+		}
+	}
+
+	return nil
+}
+
+func (t *TeamService) GetMembersByUserID(ctx context.Context, userID uuid.UUID) (*model.TeamInfoResponse, error) {
+	tx := t.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
-	team, err := t.TeamRepository.GetTeamByUserID(tx, userID)
+	team, err := teamByUserID(t.TeamRepository, tx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -126,7 +253,7 @@ func (t *TeamService) GetMembersByUserID(userID uuid.UUID) (*model.TeamInfoRespo
 		return nil, err
 	}
 
-	var memberResponse []model.TeamMembersResponse
+	memberResponse := []model.TeamMembersResponse{}
 	for _, v := range members {
 		memberResponse = append(memberResponse, model.TeamMembersResponse{
 			FullName:      v.MemberName,
@@ -159,56 +286,561 @@ func (t *TeamService) GetMembersByUserID(userID uuid.UUID) (*model.TeamInfoRespo
 	return &TeamInforResponse, nil
 }
 
-func (t *TeamService) GetAllTeam() ([]*model.GetAllTeamsResponse, error) {
-	var (
-		res []*model.GetAllTeamsResponse
-	)
+// GetAllTeam lists one page of teams. Teams that haven't picked a competition
+// yet have an empty competition name.
+func (t *TeamService) GetAllTeam(ctx context.Context, page model.PaginationQuery) (*model.Paginated[*model.GetAllTeamsResponse], error) {
+	page.Normalize()
 
-	tx := t.db.Begin()
-	defer tx.Rollback()
+	users, total, err := t.UserRepository.GetUsersWithTeamPage(ctx, page.Offset(), page.Limit)
+	if err != nil {
+		return nil, err
+	}
 
-	user, err := t.UserRepository.GetAllUser()
+	competitions, err := t.CompetitionRepository.GetAllCompetitions(t.db.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
 
-	for _, v := range user {
-		competition, err := t.CompetitionRepository.GetCompetitionByID(tx, v.Team.CompetitionID)
-		if err != nil {
-			continue
-		}
-		if v.RoleID == 1 {
-			continue
-		}
+	competitionNames := make(map[int]string, len(competitions))
+	for _, v := range competitions {
+		competitionNames[v.CompetitionID] = v.CompetitionName
+	}
 
-		var teamMembers []model.GetTeamMembers
+	items := []*model.GetAllTeamsResponse{}
+	for _, v := range users {
+		teamMembers := []model.GetTeamMembers{}
 		for _, x := range v.Team.TeamMembers {
 			teamMembers = append(teamMembers, model.GetTeamMembers{
 				Name: x.MemberName,
 			})
 		}
 
-		res = append(res, &model.GetAllTeamsResponse{
+		items = append(items, &model.GetAllTeamsResponse{
 			TeamID:          v.Team.TeamID.String(),
 			TeamName:        v.Team.TeamName,
 			LeaderName:      v.FullName,
 			University:      v.University,
 			PaymentStatus:   v.Team.TeamStatus,
-			CompetitionName: competition.CompetitionName,
+			CompetitionName: competitionNames[v.Team.CompetitionID],
 			TeamMembers:     teamMembers,
 		})
 	}
 
-	return res, nil
+	return model.NewPaginated(items, page, total), nil
 }
 
-func (t *TeamService) UpdateTeamStatus(id string, req model.ReqUpdateStatusTeam) error {
+func (t *TeamService) UpdateTeamStatus(ctx context.Context, actorID uuid.UUID, id string, req model.ReqUpdateStatusTeam) error {
 	req.TeamID = id
-	return t.TeamRepository.UpdateTeamStatus(t.db, req)
+	var event model.TeamStatusWebhook
+	err := withTransaction(ctx, t.db, func(tx *gorm.DB) error {
+		err := t.TeamRepository.UpdateTeamStatus(tx, req, time.Now())
+		if err != nil {
+			return err
+		}
+
+		event, err = t.teamStatusWebhook(tx, id, req.PaymentStatus)
+		if err != nil {
+			return err
+		}
+
+		var action string
+		switch req.PaymentStatus {
+		case "terverifikasi":
+			action = model.AuditActionPaymentApprove
+		case "ditolak":
+			action = model.AuditActionPaymentReject
+		default:
+			return nil
+		}
+
+		return recordAudit(t.AuditLogRepository, tx, model.AuditEntry{
+			ActorID:    &actorID,
+			Action:     action,
+			TargetType: "team",
+			TargetID:   id,
+			Metadata: map[string]interface{}{
+				"payment_status": req.PaymentStatus,
+			},
+		})
+	})
+	if err != nil {
+		t.Logger.ErrorContext(ctx, "failed to update team status", "operation", "update_team_status", "team_id", id, "actor_id", actorID, "error", err)
+		return err
+	}
+
+	t.Logger.InfoContext(ctx, "team status updated", "team_id", id, "status", req.PaymentStatus, "actor_id", actorID)
+	t.Webhook.Send(model.WebhookEventTeamStatusChanged, event)
+
+	if req.PaymentStatus == "terverifikasi" || req.PaymentStatus == "ditolak" {
+		t.notifyPaymentStatus(context.WithoutCancel(ctx), id, req.PaymentStatus)
+	}
+
+	return nil
+}
+
+func (t *TeamService) teamStatusWebhook(tx *gorm.DB, teamID string, status string) (model.TeamStatusWebhook, error) {
+	id, err := uuid.Parse(teamID)
+	if err != nil {
+		return model.TeamStatusWebhook{}, err
+	}
+
+	team, err := t.TeamRepository.GetTeamByID(tx, id)
+	if err != nil {
+		return model.TeamStatusWebhook{}, err
+	}
+
+	competition, err := t.CompetitionRepository.GetCompetitionByID(tx, team.CompetitionID)
+	if err != nil {
+		return model.TeamStatusWebhook{}, err
+	}
+
+	return model.TeamStatusWebhook{
+		Event:         model.WebhookEventTeamStatusChanged,
+		TeamID:        team.TeamID,
+		Status:        status,
+		CompetitionID: competition.CompetitionID,
+		Competition:   competition.CompetitionName,
+		Timestamp:     time.Now().UTC(),
+	}, nil
+}
+
+// BulkApprovePayments approves the payments of many teams in one transaction.
+// Teams that can't be approved, because they don't exist, have no payment
+// proof or are already approved, are reported in the result instead of
+// aborting the rest. Leaders of approved teams are emailed through the mail
+// queue once the transaction has committed.
+func (t *TeamService) BulkApprovePayments(ctx context.Context, actorID uuid.UUID, teamIDs []uuid.UUID) (model.BulkResult, error) {
+	result := model.BulkResult{
+		Succeeded: []uuid.UUID{},
+		Failed:    []model.BulkFailure{},
+	}
+	var approved []model.PaymentReviewTeam
+	now := time.Now()
+
+	err := withTransaction(ctx, t.db, func(tx *gorm.DB) error {
+		teams, err := t.TeamRepository.GetPaymentReviewTeams(tx, teamIDs)
+		if err != nil {
+			return err
+		}
+
+		teamsByID := map[uuid.UUID]model.PaymentReviewTeam{}
+		for _, team := range teams {
+			teamsByID[team.TeamID] = team
+		}
+
+		seen := map[uuid.UUID]bool{}
+		for _, id := range teamIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			team, ok := teamsByID[id]
+
+			var reason error
+			switch {
+			case !ok:
+				reason = model.ErrTeamNotFound
+			case team.PaymentTransc == "":
+				reason = model.ErrNoPaymentProof
+			case team.TeamStatus == "terverifikasi":
+				reason = model.ErrPaymentAlreadyApproved
+			}
+			if reason != nil {
+				result.Failed = append(result.Failed, model.BulkFailure{ID: id, Reason: reason.Error()})
+				continue
+			}
+
+			err = t.TeamRepository.UpdateTeamStatus(tx, model.ReqUpdateStatusTeam{
+				TeamID:        id.String(),
+				PaymentStatus: "terverifikasi",
+			}, now)
+			if err != nil {
+				return err
+			}
+
+			err = recordAudit(t.AuditLogRepository, tx, model.AuditEntry{
+				ActorID:    &actorID,
+				Action:     model.AuditActionPaymentApprove,
+				TargetType: "team",
+				TargetID:   id.String(),
+				Metadata: map[string]interface{}{
+					"payment_status": "terverifikasi",
+					"bulk":           true,
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			result.Succeeded = append(result.Succeeded, id)
+			approved = append(approved, team)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Logger.ErrorContext(ctx, "failed to approve payments", "operation", "bulk_approve_payments", "actor_id", actorID, "error", err)
+		return model.BulkResult{}, err
+	}
+
+	t.Logger.InfoContext(ctx, "payments approved", "approved", len(result.Succeeded), "failed", len(result.Failed), "actor_id", actorID)
+
+	subject, message := paymentStatusMessage("terverifikasi")
+	db := t.db.WithContext(context.WithoutCancel(ctx))
+	for _, team := range approved {
+		notify(t.Logger, t.NotificationRepository, db, team.UserID, model.NotificationPaymentStatus, sentence(message))
+
+		t.Webhook.Send(model.WebhookEventTeamStatusChanged, model.TeamStatusWebhook{
+			Event:         model.WebhookEventTeamStatusChanged,
+			TeamID:        team.TeamID,
+			Status:        "terverifikasi",
+			CompetitionID: team.CompetitionID,
+			Competition:   team.CompetitionName,
+			Timestamp:     time.Now().UTC(),
+		})
+
+		err = t.Mailer.Enqueue(team.Email, subject, paymentStatusMailBody(team.FullName, message), nil)
+		if err != nil {
+			t.Logger.ErrorContext(ctx, "failed to queue payment status email", "team_id", team.TeamID, "error", err)
+		}
+	}
+
+	go func() {
+		for _, team := range approved {
+			err := t.WhatsApp.Notify(team.PhoneNumber, fmt.Sprintf("[IT FEST 2025] Halo %s, %s", team.FullName, message))
+			if err != nil {
+				t.Logger.Error("failed to send payment status whatsapp", "team_id", team.TeamID, "error", err)
+			}
+		}
+	}()
+
+	return result, nil
+}
+
+// ResendPaymentConfirmation emails the payment approval to the team leader
+// again. It can be requested once every paymentConfirmationCooldown.
+func (t *TeamService) ResendPaymentConfirmation(ctx context.Context, userID uuid.UUID) error {
+	return withTransaction(ctx, t.db, func(tx *gorm.DB) error {
+		team, err := teamByUserID(t.TeamRepository, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if team.TeamStatus != "terverifikasi" {
+			return model.ErrPaymentNotApproved
+		}
+
+		if team.PaymentConfirmationSentAt != nil {
+			err = checkCooldown("resend the payment confirmation", *team.PaymentConfirmationSentAt, paymentConfirmationCooldown)
+			if err != nil {
+				return err
+			}
+		}
+
+		user, err := t.UserRepository.GetUser(ctx, model.UserParam{
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = t.TeamRepository.UpdatePaymentConfirmationSentAt(tx, team.TeamID, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		subject, message := paymentStatusMessage("terverifikasi")
+
+		return t.Mailer.Send(user.Email, subject, paymentStatusMailBody(user.FullName, message))
+	})
+}
+
+// notifyPaymentStatus tells the team leader about a payment decision by email and
+// WhatsApp. Delivery failures are logged only, the status change is already saved.
+// Both are sent in the background so a slow gateway doesn't hold up the admin.
+func (t *TeamService) notifyPaymentStatus(ctx context.Context, teamID string, status string) {
+	id, err := uuid.Parse(teamID)
+	if err != nil {
+		return
+	}
+
+	team, err := t.TeamRepository.GetTeamByID(t.db.WithContext(ctx), id)
+	if err != nil {
+		t.Logger.ErrorContext(ctx, "failed to load team for payment notification", "team_id", teamID, "error", err)
+		return
+	}
+
+	subject, message := paymentStatusMessage(status)
+	notify(t.Logger, t.NotificationRepository, t.db.WithContext(ctx), team.UserID, model.NotificationPaymentStatus, sentence(message))
+
+	user, err := t.UserRepository.GetUser(ctx, model.UserParam{
+		UserID: team.UserID,
+	})
+	if err != nil {
+		t.Logger.ErrorContext(ctx, "failed to load team leader for payment notification", "team_id", teamID, "error", err)
+		return
+	}
+
+	err = t.Mailer.Enqueue(user.Email, subject, paymentStatusMailBody(user.FullName, message), nil)
+	if err != nil {
+		t.Logger.ErrorContext(ctx, "failed to queue payment status email", "team_id", teamID, "error", err)
+	}
+
+	go func() {
+		err := t.WhatsApp.Notify(user.PhoneNumber, fmt.Sprintf("[IT FEST 2025] Halo %s, %s", user.FullName, message))
+		if err != nil {
+			t.Logger.ErrorContext(ctx, "failed to send payment status whatsapp", "team_id", teamID, "error", err)
+		}
+	}()
+}
+
+// sentence capitalizes the first letter of message, which is written to follow
+// a greeting in emails.
+func sentence(message string) string {
+	if message == "" {
+		return message
+	}
+
+	return strings.ToUpper(message[:1]) + message[1:]
 }
 
-func (t *TeamService) GetTeamByID(teamID uuid.UUID) (*model.TeamInfoResponseAdmin, error) {
-	tx := t.db.Begin()
+func paymentStatusMessage(status string) (string, string) {
+	if status == "terverifikasi" {
+		return "Pembayaran IT FEST 2025 Terverifikasi", "pembayaran tim Anda telah kami verifikasi. Selamat bertanding!"
+	}
+
+	return "Pembayaran IT FEST 2025 Ditolak", "bukti pembayaran tim Anda belum dapat kami verifikasi. Silakan unggah ulang bukti pembayaran yang valid melalui Dashboard Anda."
+}
+
+func paymentStatusMailBody(name string, message string) string {
+	return noticeMailBody("Status Pembayaran", name, message)
+}
+
+// noticeMailBody lays out a short message to a team leader under a title. All
+// three are escaped, since the name is whatever the participant typed.
+func noticeMailBody(title string, name string, message string) string {
+	return fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html lang="id">
+		<head>
+			<style>
+				body, table, td, a {
+					-webkit-text-size-adjust: 100%%;
+					-ms-text-size-adjust: 100%%;
+				}
+
+				table, td {
+					mso-table-lspace: 0pt;
+					mso-table-rspace: 0pt;
+				}
+
+				img {
+					-ms-interpolation-mode: bicubic;
+					border: 0;
+					height: auto;
+					line-height: 100%%;
+					outline: none;
+					text-decoration: none;
+				}
+
+				body {
+					height: 100%% !important;
+					margin: 0 !important;
+					padding: 0 !important;
+					width: 100%% !important;
+				}
+			</style>
+		</head>
+
+		<body style="margin: 0; padding: 0; background-color: #030D35; background: linear-gradient(to bottom, #030D35 0%%, #19217C 100%%);">
+			<table border="0" cellpadding="0" cellspacing="0" width="100%%" style="max-width: 600px; margin: 0 auto;">
+				<tr>
+					<td align="center" valign="top" style="padding: 40px 20px 20px 20px;">
+						<table border="0" cellpadding="0" cellspacing="0" width="100%%">
+
+							<tr>
+								<td align="center" style="padding-bottom: 20px;">
+									<img src="https://i.postimg.cc/9QHJbbGw/it-fest-2025.png" width="300" alt="IT FEST 2025 Logo" style="display: block; width: 300px; max-width: 100%%; min-width: 100px; font-family: Arial, sans-serif; color: #ffffff;">
+								</td>
+							</tr>
+
+							<tr>
+								<td align="center" style="padding: 10px 0; font-family: Arial, sans-serif; font-size: 24px; font-weight: bold; color: #ffffff;">
+									%s
+								</td>
+							</tr>
+
+							<tr>
+								<td align="center" style="padding: 10px 20px; font-family: Arial, sans-serif; font-size: 16px; line-height: 1.5; color: #d1d1d1;">
+									Halo %s, %s
+								</td>
+							</tr>
+
+							<tr>
+								<td align="center" style="padding: 0 20px 40px 20px; font-family: Arial, sans-serif; font-size: 12px; line-height: 1.5; color: #a0a0a0 !important;">
+									Keluarga Besar Mahasiswa Departemen Sistem Informasi<br>
+									Universitas Brawijaya
+								</td>
+							</tr>
+
+						</table>
+					</td>
+				</tr>
+			</table>
+		</body>
+		</html>
+	`, html.EscapeString(title), html.EscapeString(name), html.EscapeString(message))
+}
+
+// UpdateTeamCompetition is the admin override for teams that are locked out of
+// switching competitions themselves once payment has been submitted.
+func (t *TeamService) UpdateTeamCompetition(ctx context.Context, actorID uuid.UUID, teamID uuid.UUID, competitionID int) error {
+	tx := t.db.WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	team, err := t.TeamRepository.GetTeamByID(tx, teamID)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.CompetitionRepository.GetCompetitionByID(tx, competitionID)
+	if err != nil {
+		return err
+	}
+
+	oldCompetitionID := team.CompetitionID
+	team.CompetitionID = competitionID
+	// The admin placed the team by hand, so it no longer waits for a slot.
+	team.WaitlistedAt = nil
+	err = t.TeamRepository.UpdateTeam(tx, team)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return model.ErrTeamNameTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(t.AuditLogRepository, tx, model.AuditEntry{
+		ActorID:    &actorID,
+		Action:     model.AuditActionTeamCompetition,
+		TargetType: "team",
+		TargetID:   teamID.String(),
+		Metadata: map[string]interface{}{
+			"old_competition_id": oldCompetitionID,
+			"new_competition_id": competitionID,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// PromoteWaitlistedTeams gives the free slots of a competition to the teams that
+// have waited longest, and emails each promoted leader. All waitlisted teams
+// are promoted when the competition has no capacity.
+func (t *TeamService) PromoteWaitlistedTeams(ctx context.Context, actorID uuid.UUID, competitionID int) (model.WaitlistPromotionResult, error) {
+	result := model.WaitlistPromotionResult{
+		Promoted: []uuid.UUID{},
+	}
+	var promoted []*entity.Team
+	var competitionName string
+
+	err := withTransaction(ctx, t.db, func(tx *gorm.DB) error {
+		// Shares the lock registrations take, so no registration can claim a
+		// slot while it is being handed out.
+		competition, err := t.CompetitionRepository.GetCompetitionForUpdate(tx, competitionID)
+		if err != nil {
+			return err
+		}
+		competitionName = competition.CompetitionName
+
+		free := -1
+		if competition.Capacity > 0 {
+			count, err := t.TeamRepository.CountRegisteredTeams(tx, competitionID, uuid.Nil)
+			if err != nil {
+				return err
+			}
+			free = competition.Capacity - int(count)
+			if free <= 0 {
+				return nil
+			}
+		}
+
+		promoted, err = t.TeamRepository.GetWaitlistedTeams(tx, competitionID, free)
+		if err != nil {
+			return err
+		}
+		if len(promoted) == 0 {
+			return nil
+		}
+
+		for _, team := range promoted {
+			result.Promoted = append(result.Promoted, team.TeamID)
+		}
+
+		err = t.TeamRepository.ClearWaitlist(tx, result.Promoted)
+		if err != nil {
+			return err
+		}
+
+		for _, team := range promoted {
+			err = recordAudit(t.AuditLogRepository, tx, model.AuditEntry{
+				ActorID:    &actorID,
+				Action:     model.AuditActionWaitlistPromote,
+				TargetType: "team",
+				TargetID:   team.TeamID.String(),
+				Metadata: map[string]interface{}{
+					"competition_id": competitionID,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Logger.ErrorContext(ctx, "failed to promote waitlisted teams", "operation", "promote_waitlist", "competition_id", competitionID, "actor_id", actorID, "error", err)
+		return model.WaitlistPromotionResult{}, err
+	}
+
+	if len(promoted) > 0 {
+		t.Logger.InfoContext(ctx, "waitlisted teams promoted", "competition_id", competitionID, "count", len(promoted), "actor_id", actorID)
+	}
+
+	for _, team := range promoted {
+		t.notifyWaitlistPromotion(context.WithoutCancel(ctx), team, competitionName)
+	}
+
+	return result, nil
+}
+
+// notifyWaitlistPromotion tells the leader their team got a slot. Like payment
+// notifications, delivery failures are only logged.
+func (t *TeamService) notifyWaitlistPromotion(ctx context.Context, team *entity.Team, competitionName string) {
+	message := fmt.Sprintf("tim Anda telah mendapatkan slot di %s. Silakan lanjutkan pembayaran melalui Dashboard Anda.", competitionName)
+	notify(t.Logger, t.NotificationRepository, t.db.WithContext(ctx), team.UserID, model.NotificationWaitlist, sentence(message))
+
+	user, err := t.UserRepository.GetUser(ctx, model.UserParam{
+		UserID: team.UserID,
+	})
+	if err != nil {
+		t.Logger.ErrorContext(ctx, "failed to load team leader for waitlist promotion", "team_id", team.TeamID, "error", err)
+		return
+	}
+
+	err = t.Mailer.Send(user.Email, "Slot IT FEST 2025 Tersedia", noticeMailBody("Slot Tersedia", user.FullName, message))
+	if err != nil {
+		t.Logger.ErrorContext(ctx, "failed to send waitlist promotion email", "team_id", team.TeamID, "error", err)
+	}
+}
+
+func (t *TeamService) GetTeamByID(ctx context.Context, teamID uuid.UUID) (*model.TeamInfoResponseAdmin, error) {
+	tx := t.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
 	team, err := t.TeamRepository.GetTeamByID(tx, teamID)
@@ -219,7 +851,7 @@ func (t *TeamService) GetTeamByID(teamID uuid.UUID) (*model.TeamInfoResponseAdmi
 		return nil, err
 	}
 
-	user, err := t.UserRepository.GetUser(model.UserParam{
+	user, err := t.UserRepository.GetUser(ctx, model.UserParam{
 		UserID: team.UserID,
 	})
 	if err != nil {
@@ -235,7 +867,7 @@ func (t *TeamService) GetTeamByID(teamID uuid.UUID) (*model.TeamInfoResponseAdmi
 		members = []*entity.TeamMember{}
 	}
 
-	var memberResponse []model.TeamMembersResponse
+	memberResponse := []model.TeamMembersResponse{}
 	for _, v := range members {
 		memberResponse = append(memberResponse, model.TeamMembersResponse{
 			FullName:      v.MemberName,
@@ -252,9 +884,9 @@ func (t *TeamService) GetTeamByID(teamID uuid.UUID) (*model.TeamInfoResponseAdmi
 	}
 
 	var data model.ResStage
-	currentStage, err := t.SubmissionRepository.GetCurrentStage(team)
+	currentStage, err := t.SubmissionRepository.GetCurrentStage(ctx, team)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		firstStage, err := t.SubmissionRepository.GetFirstStage(team.CompetitionID)
+		firstStage, err := t.SubmissionRepository.GetFirstStage(ctx, team.CompetitionID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return &model.TeamInfoResponseAdmin{
@@ -278,7 +910,7 @@ func (t *TeamService) GetTeamByID(teamID uuid.UUID) (*model.TeamInfoResponseAdmi
 			DeadlineNextStage: firstStage.Deadline,
 		}
 	} else {
-		nextStage, err := t.SubmissionRepository.GetNextStage(currentStage.StageID, team.CompetitionID)
+		nextStage, err := t.SubmissionRepository.GetNextStage(ctx, currentStage.StageID, team.CompetitionID)
 		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err
 		}
@@ -297,7 +929,7 @@ func (t *TeamService) GetTeamByID(teamID uuid.UUID) (*model.TeamInfoResponseAdmi
 	}
 
 	submission := ""
-	dataSubmission, err := t.SubmissionRepository.GetSubmission(&model.ReqFilterSubmission{
+	dataSubmission, err := t.SubmissionRepository.GetSubmission(ctx, &model.ReqFilterSubmission{
 		TeamID:  team.TeamID.String(),
 		StageID: stage.StageID,
 	})
@@ -340,8 +972,8 @@ func (t *TeamService) GetTeamByID(teamID uuid.UUID) (*model.TeamInfoResponseAdmi
 	return &response, nil
 }
 
-func (t *TeamService) GetDetailTeam(teamID uuid.UUID) (*model.TeamDetailProgress, error) {
-	tx := t.db.Begin()
+func (t *TeamService) GetDetailTeam(ctx context.Context, teamID uuid.UUID) (*model.TeamDetailProgress, error) {
+	tx := t.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
 	team, err := t.TeamRepository.GetTeamByID(tx, teamID)
@@ -357,7 +989,7 @@ func (t *TeamService) GetDetailTeam(teamID uuid.UUID) (*model.TeamDetailProgress
 		return nil, err
 	}
 
-	var stages []model.Stages
+	stages := []model.Stages{}
 	dataSubmission, err := t.SubmissionRepository.GetSubmissionAllStage(tx, team.TeamID, team.CompetitionID)
 	if err != nil {
 		return &model.TeamDetailProgress{}, err
@@ -371,9 +1003,9 @@ func (t *TeamService) GetDetailTeam(teamID uuid.UUID) (*model.TeamDetailProgress
 	var currentStageName string
 	var nextStageName string
 
-	currentStage, err := t.SubmissionRepository.GetCurrentStage(team)
+	currentStage, err := t.SubmissionRepository.GetCurrentStage(ctx, team)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		firstStage, err := t.SubmissionRepository.GetFirstStage(team.CompetitionID)
+		firstStage, err := t.SubmissionRepository.GetFirstStage(ctx, team.CompetitionID)
 		if err != nil {
 			return nil, err
 		}
@@ -388,7 +1020,7 @@ func (t *TeamService) GetDetailTeam(teamID uuid.UUID) (*model.TeamDetailProgress
 			}
 
 			// Ambil next stage setelah proposal jika ada
-			nextStage, err := t.SubmissionRepository.GetNextStage(firstStage.StageID, team.CompetitionID)
+			nextStage, err := t.SubmissionRepository.GetNextStage(ctx, firstStage.StageID, team.CompetitionID)
 			if err == nil {
 				data.NextStage = nextStage.StageOrder
 				data.IDNextStage = nextStage.StageID
@@ -421,7 +1053,7 @@ func (t *TeamService) GetDetailTeam(teamID uuid.UUID) (*model.TeamDetailProgress
 		}
 
 		// Cek apakah sudah submit untuk currentStage
-		submission, err := t.SubmissionRepository.GetSubmission(&model.ReqFilterSubmission{
+		submission, err := t.SubmissionRepository.GetSubmission(ctx, &model.ReqFilterSubmission{
 			StageID: currentStage.StageID,
 			TeamID:  team.TeamID.String(),
 		})
@@ -432,7 +1064,7 @@ func (t *TeamService) GetDetailTeam(teamID uuid.UUID) (*model.TeamDetailProgress
 		// Jika sudah submit dan statusnya "lolos", geser ke stage berikutnya
 		if len(submission) > 0 && submission[0].Status == "lolos" {
 			// Ambil next stage dari current
-			nextStage, err := t.SubmissionRepository.GetNextStage(currentStage.StageID, team.CompetitionID)
+			nextStage, err := t.SubmissionRepository.GetNextStage(ctx, currentStage.StageID, team.CompetitionID)
 			if err != nil {
 				if !errors.Is(err, gorm.ErrRecordNotFound) {
 					return nil, err
@@ -450,7 +1082,7 @@ func (t *TeamService) GetDetailTeam(teamID uuid.UUID) (*model.TeamDetailProgress
 				data.IDCurrentStage = nextStage.StageID
 
 				// Coba ambil stage setelah nextStage
-				stageAfterNext, err := t.SubmissionRepository.GetNextStage(nextStage.StageID, team.CompetitionID)
+				stageAfterNext, err := t.SubmissionRepository.GetNextStage(ctx, nextStage.StageID, team.CompetitionID)
 				if err == nil {
 					data.NextStage = stageAfterNext.StageOrder
 					data.IDNextStage = stageAfterNext.StageID
@@ -468,7 +1100,7 @@ func (t *TeamService) GetDetailTeam(teamID uuid.UUID) (*model.TeamDetailProgress
 			}
 			currentStageName = stage.StageName
 
-			nextStage, err := t.SubmissionRepository.GetNextStage(currentStage.StageID, team.CompetitionID)
+			nextStage, err := t.SubmissionRepository.GetNextStage(ctx, currentStage.StageID, team.CompetitionID)
 			if err == nil {
 				data.NextStage = nextStage.StageOrder
 				data.IDNextStage = nextStage.StageID
@@ -490,15 +1122,12 @@ func (t *TeamService) GetDetailTeam(teamID uuid.UUID) (*model.TeamDetailProgress
 	}, nil
 }
 
-func (t *TeamService) GetProgressByUserID(userID uuid.UUID) (*model.TeamDetailProgress, error) {
-	tx := t.db.Begin()
+func (t *TeamService) GetProgressByUserID(ctx context.Context, userID uuid.UUID) (*model.TeamDetailProgress, error) {
+	tx := t.db.WithContext(ctx).Begin()
 	defer tx.Rollback()
 
-	team, err := t.TeamRepository.GetTeamByUserID(tx, userID)
+	team, err := teamByUserID(t.TeamRepository, tx, userID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) || team == nil {
-			return &model.TeamDetailProgress{}, nil
-		}
 		return nil, err
 	}
 
@@ -507,10 +1136,10 @@ func (t *TeamService) GetProgressByUserID(userID uuid.UUID) (*model.TeamDetailPr
 		return nil, err
 	}
 
-	var stages []model.Stages
+	stages := []model.Stages{}
 	dataSubmission, err := t.SubmissionRepository.GetSubmissionAllStage(tx, team.TeamID, team.CompetitionID)
 	if err != nil {
-		return &model.TeamDetailProgress{}, err
+		return nil, err
 	}
 	stages = nil
 	if len(dataSubmission) > 0 {
@@ -521,9 +1150,9 @@ func (t *TeamService) GetProgressByUserID(userID uuid.UUID) (*model.TeamDetailPr
 	var currentStageName string
 	var nextStageName string
 
-	currentStage, err := t.SubmissionRepository.GetCurrentStage(team)
+	currentStage, err := t.SubmissionRepository.GetCurrentStage(ctx, team)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		firstStage, err := t.SubmissionRepository.GetFirstStage(team.CompetitionID)
+		firstStage, err := t.SubmissionRepository.GetFirstStage(ctx, team.CompetitionID)
 		if err != nil {
 			return nil, err
 		}
@@ -538,7 +1167,7 @@ func (t *TeamService) GetProgressByUserID(userID uuid.UUID) (*model.TeamDetailPr
 			}
 
 			// Ambil next stage setelah proposal jika ada
-			nextStage, err := t.SubmissionRepository.GetNextStage(firstStage.StageID, team.CompetitionID)
+			nextStage, err := t.SubmissionRepository.GetNextStage(ctx, firstStage.StageID, team.CompetitionID)
 			if err == nil {
 				data.NextStage = nextStage.StageOrder
 				data.IDNextStage = nextStage.StageID
@@ -565,13 +1194,15 @@ func (t *TeamService) GetProgressByUserID(userID uuid.UUID) (*model.TeamDetailPr
 			NextStage:       "",
 			Stages:          stages,
 		}, nil
+	} else if err != nil {
+		return nil, err
 	} else {
 		data = model.ResStage{
 			IDCurrentStage: currentStage.StageID,
 		}
 
 		// Cek apakah sudah submit untuk currentStage
-		submission, err := t.SubmissionRepository.GetSubmission(&model.ReqFilterSubmission{
+		submission, err := t.SubmissionRepository.GetSubmission(ctx, &model.ReqFilterSubmission{
 			StageID: currentStage.StageID,
 			TeamID:  team.TeamID.String(),
 		})
@@ -582,7 +1213,7 @@ func (t *TeamService) GetProgressByUserID(userID uuid.UUID) (*model.TeamDetailPr
 		// Jika sudah submit dan statusnya "lolos", geser ke stage berikutnya
 		if len(submission) > 0 && submission[0].Status == "lolos" {
 			// Ambil next stage dari current
-			nextStage, err := t.SubmissionRepository.GetNextStage(currentStage.StageID, team.CompetitionID)
+			nextStage, err := t.SubmissionRepository.GetNextStage(ctx, currentStage.StageID, team.CompetitionID)
 			if err != nil {
 				if !errors.Is(err, gorm.ErrRecordNotFound) {
 					return nil, err
@@ -600,7 +1231,7 @@ func (t *TeamService) GetProgressByUserID(userID uuid.UUID) (*model.TeamDetailPr
 				data.IDCurrentStage = nextStage.StageID
 
 				// Coba ambil stage setelah nextStage
-				stageAfterNext, err := t.SubmissionRepository.GetNextStage(nextStage.StageID, team.CompetitionID)
+				stageAfterNext, err := t.SubmissionRepository.GetNextStage(ctx, nextStage.StageID, team.CompetitionID)
 				if err == nil {
 					data.NextStage = stageAfterNext.StageOrder
 					data.IDNextStage = stageAfterNext.StageID
@@ -618,7 +1249,7 @@ func (t *TeamService) GetProgressByUserID(userID uuid.UUID) (*model.TeamDetailPr
 			}
 			currentStageName = stage.StageName
 
-			nextStage, err := t.SubmissionRepository.GetNextStage(currentStage.StageID, team.CompetitionID)
+			nextStage, err := t.SubmissionRepository.GetNextStage(ctx, currentStage.StageID, team.CompetitionID)
 			if err == nil {
 				data.NextStage = nextStage.StageOrder
 				data.IDNextStage = nextStage.StageID
@@ -639,3 +1270,99 @@ func (t *TeamService) GetProgressByUserID(userID uuid.UUID) (*model.TeamDetailPr
 		Stages:          stages,
 	}, nil
 }
+
+// SetTeamName renames the team led by userID. The name must be unique within
+// the team's competition and free of words listed in TEAM_NAME_BLOCKLIST.
+func (t *TeamService) SetTeamName(ctx context.Context, userID uuid.UUID, param model.SetTeamNameRequest) (*model.SetTeamNameResponse, error) {
+	err := param.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = withTransaction(ctx, t.db, func(tx *gorm.DB) error {
+		team, err := teamByUserID(t.TeamRepository, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		err = t.checkTeamName(tx, team.CompetitionID, param.TeamName, team.TeamID)
+		if err != nil {
+			return err
+		}
+
+		team.TeamName = param.TeamName
+
+		err = t.TeamRepository.UpdateTeam(tx, team)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.ErrTeamNameTaken
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.SetTeamNameResponse{
+		TeamName: param.TeamName,
+	}, nil
+}
+
+// checkTeamName rejects a name with a word from the blocklist, or one that
+// another team in the competition already uses, ignoring case. teamID is the
+// team being named, or uuid.Nil for a new one.
+func (t *TeamService) checkTeamName(tx *gorm.DB, competitionID int, teamName string, teamID uuid.UUID) error {
+	if containsBlockedWord(t.cfg.App.TeamNameBlocklist, teamName) {
+		return model.ErrTeamNameNotAllowed
+	}
+
+	exists, err := t.TeamRepository.TeamNameExists(tx, competitionID, teamName, teamID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return model.ErrTeamNameTaken
+	}
+
+	return nil
+}
+
+// containsBlockedWord reports whether any word of name is in blocklist, which
+// comes from TEAM_NAME_BLOCKLIST. Whole words are compared so innocent names
+// that merely contain a blocked word are not rejected.
+func containsBlockedWord(blocklist []string, name string) bool {
+	blocked := map[string]bool{}
+	for _, word := range blocklist {
+		blocked[strings.ToLower(word)] = true
+	}
+
+	if len(blocked) == 0 {
+		return false
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if blocked[word] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// teamByUserID is GetTeamByUserID for callers that need the team to exist. Only
+// the registration flow creates a team, so accounts made any other way, such as
+// admins, have none.
+func teamByUserID(teamRepository repository.ITeamRepository, tx *gorm.DB, userID uuid.UUID) (*entity.Team, error) {
+	team, err := teamRepository.GetTeamByUserID(tx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrNoTeam
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"itfest-2025/entity"
+	"itfest-2025/internal/repository"
+	"itfest-2025/model"
+	"itfest-2025/pkg/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type failingTeamRepository struct {
+	repository.ITeamRepository
+
+	err error
+}
+
+func (r failingTeamRepository) GetTeamByUserID(tx *gorm.DB, userID uuid.UUID) (*entity.Team, error) {
+	return nil, r.err
+}
+
+func TestTeamByUserID(t *testing.T) {
+	teams := newFakeTeamRepository()
+	leader := uuid.New()
+	teams.put(&entity.Team{TeamID: uuid.New(), UserID: leader, CompetitionID: 1})
+	dbErr := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		repository repository.ITeamRepository
+		userID     uuid.UUID
+		wantErr    error
+	}{
+		{name: "user with a team", repository: teams, userID: leader},
+		{name: "user without a team", repository: teams, userID: uuid.New(), wantErr: model.ErrNoTeam},
+		{name: "database error", repository: failingTeamRepository{err: dbErr}, userID: leader, wantErr: dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team, err := teamByUserID(tt.repository, nil, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("teamByUserID() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && team.UserID != tt.userID {
+				t.Errorf("teamByUserID() returned the team of %s, want %s", team.UserID, tt.userID)
+			}
+		})
+	}
+}
+
+type failingSubmissionRepository struct {
+	repository.ISubmissionRepository
+
+	err error
+}
+
+func (r failingSubmissionRepository) GetSubmissionAllStage(tx *gorm.DB, teamID uuid.UUID, competitionID int) ([]model.Stages, error) {
+	return nil, r.err
+}
+
+func TestGetProgressByUserIDErrors(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	leader := uuid.New()
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		wantErr error
+	}{
+		{name: "user without a team", userID: uuid.New(), wantErr: model.ErrNoTeam},
+		{name: "submission lookup fails", userID: leader, wantErr: dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTeamServiceFixture(t)
+			f.teams.put(&entity.Team{TeamID: uuid.New(), UserID: leader, CompetitionID: 1})
+			f.service.SubmissionRepository = failingSubmissionRepository{err: dbErr}
+			f.mock.ExpectBegin()
+			f.mock.ExpectRollback()
+
+			progress, err := f.service.GetProgressByUserID(context.Background(), tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetProgressByUserID() error = %v, want %v", err, tt.wantErr)
+			}
+			if progress != nil {
+				t.Errorf("GetProgressByUserID() = %+v, want nil", progress)
+			}
+		})
+	}
+}
+
+func TestCompetitionRegistrationWithoutTeam(t *testing.T) {
+	f := newUserServiceFixture(t)
+	f.competitions.competitions[1] = entity.Competition{CompetitionID: 1, CompetitionName: "Business Plan", IsRegistrationOpen: true}
+
+	// Accounts that didn't sign up through the registration flow, such as
+	// admins, have no team.
+	admin := &entity.User{UserID: uuid.New(), Email: "admin@example.com", StatusAccount: "active", RoleID: entity.RoleAdmin}
+	f.users.put(admin)
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+
+	_, err := f.service.CompetitionRegistration(context.Background(), admin.UserID, 1, registrationRequest())
+	if !errors.Is(err, model.ErrNoTeam) {
+		t.Fatalf("CompetitionRegistration() error = %v, want %v", err, model.ErrNoTeam)
+	}
+}
+
+func TestUpdateTeamCompetitionOverridesLock(t *testing.T) {
+	db, mock := newTestDB(t)
+	teams := newFakeTeamRepository()
+	auditLogs := &fakeAuditLogRepository{}
+	service := &TeamService{
+		db:                    db,
+		TeamRepository:        teams,
+		CompetitionRepository: newFakeCompetitionRepository(&entity.Competition{CompetitionID: 1}, &entity.Competition{CompetitionID: 2}),
+		AuditLogRepository:    auditLogs,
+		Logger:                testLogger(),
+	}
+
+	waitlistedAt := time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC)
+	team := &entity.Team{TeamID: uuid.New(), UserID: uuid.New(), TeamStatus: "terverifikasi", CompetitionID: 1, WaitlistedAt: &waitlistedAt}
+	teams.put(team)
+	expectTransaction(mock, 1)
+
+	adminID := uuid.New()
+	err := service.UpdateTeamCompetition(context.Background(), adminID, team.TeamID, 2)
+	if err != nil {
+		t.Fatalf("UpdateTeamCompetition() error = %v, want nil", err)
+	}
+
+	updated, _ := teams.GetTeamByID(nil, team.TeamID)
+	if updated.CompetitionID != 2 {
+		t.Errorf("team competition = %d, want 2", updated.CompetitionID)
+	}
+	if updated.WaitlistedAt != nil {
+		t.Error("the moved team is still waitlisted")
+	}
+	if len(auditLogs.logs) != 1 || auditLogs.logs[0].Action != model.AuditActionTeamCompetition {
+		t.Errorf("audit logs = %v, want one %s entry", auditLogs.logs, model.AuditActionTeamCompetition)
+	}
+}
+
+func TestUpsertTeamUsesDefaultCompetition(t *testing.T) {
+	members := []model.TeamMemberRequest{
+		{Name: "Member One", StudentNumber: "225150400111002"},
+		{Name: "Member Two", StudentNumber: "225150400111003"},
+		{Name: "Member Three", StudentNumber: "225150400111004"},
+	}
+
+	tests := []struct {
+		name          string
+		competitionID int
+		wantErr       bool
+	}{
+		{name: "configured competition exists", competitionID: 2},
+		{name: "configured competition missing", competitionID: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTeamServiceFixture(t)
+			f.service.cfg.App.DefaultCompetitionID = tt.competitionID
+			// Competition 1 allows two members, so three only fit if the
+			// configured competition's limits are used.
+			f.competitions.competitions[2] = entity.Competition{CompetitionID: 2, CompetitionName: "UI/UX", MinMembers: 1, MaxMembers: 3}
+			leader := &entity.User{UserID: uuid.New(), Email: "admin@example.com", StatusAccount: "active"}
+			f.users.put(leader)
+			f.mock.ExpectBegin()
+			if tt.wantErr {
+				f.mock.ExpectRollback()
+			} else {
+				f.mock.ExpectCommit()
+			}
+
+			_, err := f.service.UpsertTeam(context.Background(), leader.UserID, &model.UpsertTeamRequest{Members: members})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("UpsertTeam() error = nil, want an error for the missing competition")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpsertTeam() error = %v, want nil", err)
+			}
+
+			team, err := f.teams.GetTeamByUserID(nil, leader.UserID)
+			if err != nil {
+				t.Fatalf("GetTeamByUserID() error = %v, want nil", err)
+			}
+			if team.CompetitionID != tt.competitionID {
+				t.Errorf("team competition = %d, want %d", team.CompetitionID, tt.competitionID)
+			}
+		})
+	}
+}
+
+func TestUpsertTeamChecksTeamName(t *testing.T) {
+	tests := []struct {
+		name     string
+		teamName string
+		wantErr  error
+	}{
+		{name: "free name", teamName: "  Byte   Busters "},
+		{name: "taken in the competition, other case", teamName: "CODE CRAFTERS", wantErr: model.ErrTeamNameTaken},
+		{name: "taken in another competition only", teamName: "Pixel Pushers"},
+		{name: "blocked word", teamName: "Team Badword", wantErr: model.ErrTeamNameNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTeamServiceFixture(t)
+			f.service.cfg.App.TeamNameBlocklist = []string{"badword"}
+			f.teams.put(&entity.Team{TeamID: uuid.New(), UserID: uuid.New(), TeamName: "Code Crafters", CompetitionID: 1})
+			f.teams.put(&entity.Team{TeamID: uuid.New(), UserID: uuid.New(), TeamName: "Pixel Pushers", CompetitionID: 2})
+			leader := &entity.User{UserID: uuid.New(), Email: "leader@example.com", StatusAccount: "active"}
+			f.users.put(leader)
+			f.mock.ExpectBegin()
+			if tt.wantErr == nil {
+				f.mock.ExpectCommit()
+			} else {
+				f.mock.ExpectRollback()
+			}
+
+			res, err := f.service.UpsertTeam(context.Background(), leader.UserID, &model.UpsertTeamRequest{TeamName: tt.teamName})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpsertTeam() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && res.TeamName != model.NormalizeTeamName(tt.teamName) {
+				t.Errorf("team name = %q, want %q", res.TeamName, model.NormalizeTeamName(tt.teamName))
+			}
+		})
+	}
+}
+
+func TestUpsertTeamRejectsShortName(t *testing.T) {
+	f := newTeamServiceFixture(t)
+
+	_, err := f.service.UpsertTeam(context.Background(), uuid.New(), &model.UpsertTeamRequest{TeamName: " AB "})
+
+	var validationErr model.ValidationErrors
+	if !errors.As(err, &validationErr) || validationErr["team_name"] == "" {
+		t.Fatalf("UpsertTeam() error = %v, want a team_name validation error", err)
+	}
+}
+
+func TestUpsertTeamKeepsNameWhenOmitted(t *testing.T) {
+	f := newTeamServiceFixture(t)
+	leader := &entity.User{UserID: uuid.New(), Email: "leader@example.com", StatusAccount: "active"}
+	f.users.put(leader)
+	f.teams.put(&entity.Team{TeamID: uuid.New(), UserID: leader.UserID, TeamName: "Code Crafters", CompetitionID: 1})
+	expectTransaction(f.mock, 1)
+
+	res, err := f.service.UpsertTeam(context.Background(), leader.UserID, &model.UpsertTeamRequest{
+		Members: []model.TeamMemberRequest{{Name: "Member One", StudentNumber: "225150400111002"}},
+	})
+	if err != nil {
+		t.Fatalf("UpsertTeam() error = %v, want nil", err)
+	}
+	if res.TeamName != "Code Crafters" {
+		t.Errorf("team name = %q, want the current name kept", res.TeamName)
+	}
+}
+
+func TestNoticeMailBodyEscapesHTML(t *testing.T) {
+	body := noticeMailBody("Status <Pembayaran>", `<a href="https://evil.example">Leader</a>`, "tim <b>Anda</b> & kami")
+
+	for _, raw := range []string{"<Pembayaran>", `<a href="https://evil.example">`, "<b>Anda</b>", "& kami"} {
+		if strings.Contains(body, raw) {
+			t.Errorf("mail body contains unescaped %q", raw)
+		}
+	}
+	for _, escaped := range []string{"&lt;Pembayaran&gt;", "&lt;a href=&#34;https://evil.example&#34;&gt;Leader&lt;/a&gt;", "&lt;b&gt;Anda&lt;/b&gt; &amp; kami"} {
+		if !strings.Contains(body, escaped) {
+			t.Errorf("mail body is missing %q", escaped)
+		}
+	}
+}
+
+// blockingMailer queues messages like FakeMailer, but Send waits until
+// release is closed, like a slow SMTP server.
+type blockingMailer struct {
+	mail.FakeMailer
+
+	release chan struct{}
+}
+
+func (m *blockingMailer) Send(to, subject, body string) error {
+	<-m.release
+	return m.FakeMailer.Send(to, subject, body)
+}
+
+// blockingWhatsApp stands in for a gateway that doesn't answer until released.
+type blockingWhatsApp struct {
+	release  chan struct{}
+	notified chan string
+}
+
+func (w *blockingWhatsApp) Notify(phone, message string) error {
+	<-w.release
+	w.notified <- phone
+	return nil
+}
+
+func TestNotifyPaymentStatusDoesNotWaitForDelivery(t *testing.T) {
+	f := newTeamServiceFixture(t)
+	release := make(chan struct{})
+	mailer := &blockingMailer{release: release}
+	whatsApp := &blockingWhatsApp{release: release, notified: make(chan string, 1)}
+	f.service.Mailer = mailer
+	f.service.WhatsApp = whatsApp
+
+	leader := &entity.User{UserID: uuid.New(), Email: "leader@example.com", FullName: "Leader", PhoneNumber: "+6281234567890"}
+	f.users.put(leader)
+	team := &entity.Team{TeamID: uuid.New(), UserID: leader.UserID, CompetitionID: 1}
+	f.teams.put(team)
+
+	done := make(chan struct{})
+	go func() {
+		f.service.notifyPaymentStatus(context.Background(), team.TeamID.String(), "terverifikasi")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		close(release)
+		t.Fatal("notifyPaymentStatus() waited for the email or WhatsApp gateway")
+	}
+
+	if sent := mailer.Sent(); len(sent) != 1 || sent[0].To != leader.Email {
+		t.Errorf("queued emails = %v, want one to %s", sent, leader.Email)
+	}
+
+	close(release)
+	select {
+	case phone := <-whatsApp.notified:
+		if phone != leader.PhoneNumber {
+			t.Errorf("WhatsApp sent to %s, want %s", phone, leader.PhoneNumber)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the WhatsApp notification was never sent")
+	}
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// withTransaction runs fn in a transaction bound to ctx. The transaction is
+// committed when fn returns nil and rolled back when it returns an error or panics.
+func withTransaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return db.WithContext(ctx).Transaction(fn)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"itfest-2025/model"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/imaging"
+	"itfest-2025/pkg/storage"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// uploadContentTypes are the kinds of file participants may upload.
+var uploadContentTypes = []string{"image/jpeg", "image/png", "application/pdf"}
+
+// readUpload reads an uploaded file into memory. Its type is detected from the
+// content rather than taken from the client, must be one of uploadContentTypes,
+// and decides the extension of the returned file name.
+func readUpload(file *multipart.FileHeader) ([]byte, string, error) {
+	src, contentType, err := storage.OpenUpload(file)
+	if err != nil {
+		return nil, "", err
+	}
+	defer src.Close()
+
+	if !slices.Contains(uploadContentTypes, contentType) {
+		return nil, "", fmt.Errorf("%w, got %s", model.ErrUnsupportedFileType, contentType)
+	}
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, "", err
+	}
+
+	filename := strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename)) + storage.Extension(contentType)
+
+	return data, filename, nil
+}
+
+// prepareImage strips metadata such as EXIF location from a JPEG or PNG and,
+// when compression is enabled, downscales one larger than the configured
+// threshold into a .jpg. Other files are returned unchanged. A file that looks
+// like an image but can't be decoded is rejected with model.ErrInvalidImage.
+func prepareImage(cfg config.ImageCompression, data []byte, filename string) ([]byte, string, error) {
+	switch http.DetectContentType(data) {
+	case "image/jpeg", "image/png":
+	default:
+		return data, filename, nil
+	}
+
+	stripped, err := imaging.StripMetadata(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", model.ErrInvalidImage, err)
+	}
+
+	if !cfg.Enabled || int64(len(data)) <= cfg.Threshold {
+		return stripped, filename, nil
+	}
+
+	compressed, err := imaging.Downscale(data, cfg.MaxDimension, cfg.Quality)
+	if err != nil || len(compressed) >= len(stripped) {
+		return stripped, filename, nil
+	}
+
+	return compressed, strings.TrimSuffix(filename, filepath.Ext(filename)) + ".jpg", nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"itfest-2025/model"
+	"mime/multipart"
+	"net/textproto"
+	"testing"
+)
+
+// uploadedFile returns the header of a file uploaded as filename, the way gin
+// hands it to a handler.
+func uploadedFile(t *testing.T, filename, contentType string, content []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = part.Write(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		form.RemoveAll()
+	})
+
+	return form.File["file"][0]
+}
+
+func paymentProof(t *testing.T) *multipart.FileHeader {
+	t.Helper()
+
+	return uploadedFile(t, "payment.pdf", "application/pdf", []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n"))
+}
+
+func TestUploadPaymentStorageFailure(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	files := &fakeStorage{UploadErr: errors.New("storage unavailable")}
+	f.service.Storage = files
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+
+	_, err := f.service.UploadPayment(context.Background(), user.UserID, paymentProof(t), "")
+	if err == nil {
+		t.Fatal("UploadPayment() error = nil, want the storage error")
+	}
+
+	stored, _ := f.users.GetUser(context.Background(), model.UserParam{UserID: user.UserID})
+	if stored.PaymentTransc != "" {
+		t.Errorf("PaymentTransc = %q, want it unchanged", stored.PaymentTransc)
+	}
+}
+
+func TestUploadPaymentDatabaseFailureDeletesFile(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	files := &fakeStorage{}
+	f.service.Storage = files
+	f.users.UpdateErr = errors.New("connection reset")
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+
+	_, err := f.service.UploadPayment(context.Background(), user.UserID, paymentProof(t), "")
+	if err == nil {
+		t.Fatal("UploadPayment() error = nil, want the database error")
+	}
+	if got := files.count(); got != 0 {
+		t.Errorf("stored files = %d, want the orphaned upload deleted", got)
+	}
+}
+
+func TestUploadPaymentKeepsFileOnSuccess(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	files := &fakeStorage{}
+	f.service.Storage = files
+	expectTransaction(f.mock, 1)
+
+	url, err := f.service.UploadPayment(context.Background(), user.UserID, paymentProof(t), "")
+	if err != nil {
+		t.Fatalf("UploadPayment() error = %v, want nil", err)
+	}
+	if got := files.count(); got != 1 {
+		t.Errorf("stored files = %d, want 1", got)
+	}
+
+	stored, _ := f.users.GetUser(context.Background(), model.UserParam{UserID: user.UserID})
+	if stored.PaymentTransc != url {
+		t.Errorf("PaymentTransc = %q, want %q", stored.PaymentTransc, url)
+	}
+}
@@ -1,139 +1,241 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"itfest-2025/entity"
 	"itfest-2025/internal/repository"
 	"itfest-2025/model"
 	"itfest-2025/pkg/bcrypt"
-	"itfest-2025/pkg/database/mariadb"
+	"itfest-2025/pkg/captcha"
+	"itfest-2025/pkg/clock"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/google"
 	"itfest-2025/pkg/jwt"
 	"itfest-2025/pkg/mail"
-	"itfest-2025/pkg/supabase"
+	"itfest-2025/pkg/metrics"
+	"itfest-2025/pkg/storage"
+	"log/slog"
 	"mime/multipart"
-	"os"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const (
+	idempotencyScopePayment  = "upload-payment"
+	idempotencyScopeRegister = "register"
+	idempotencyKeyTTL        = 24 * time.Hour
+
+	// loginFingerprintLimit is how many recently seen IP addresses are kept per user.
+	loginFingerprintLimit = 10
+
+	// loginHistoryLimit is how many recent logins GetLoginHistory returns.
+	loginHistoryLimit = 20
+)
+
 type IUserService interface {
-	Register(param *model.UserRegister) (model.RegisterResponse, error)
-	Login(param model.UserLogin) (model.LoginResponse, error)
-	UploadPayment(userID uuid.UUID, file *multipart.FileHeader) (string, error)
-	UploadKTM(userID uuid.UUID, file *multipart.FileHeader) error
-	VerifyUser(param model.VerifyUser) error
-	UpdateProfile(userID uuid.UUID, param model.UpdateProfile) (*model.UpdateProfile, error)
-	GetUserProfile(userID uuid.UUID) (model.UserProfile, error)
-	GetMyTeamProfile(userID uuid.UUID) (*model.UserTeamProfile, error)
-	ChangePassword(email string) (string, error)
-	ChangePasswordAfterVerify(param model.ResetPasswordRequest) error
-	VerifyOtpChangePassword(param model.VerifyToken) error
-	CompetitionRegistration(userID uuid.UUID, competitionID int, param model.CompetitionRegistrationRequest) error
-	GetUserPaymentStatus() ([]*model.GetUserPaymentStatus, error)
-	GetTotalParticipant() (*model.GetTotalParticipant, error)
-	GetUser(param model.UserParam) (*entity.User, error)
+	Register(ctx context.Context, param *model.UserRegister, idempotencyKey string) (model.RegisterResponse, error)
+	Login(ctx context.Context, param model.UserLogin) (model.LoginResponse, error)
+	LoginWithGoogle(ctx context.Context, param model.GoogleLoginRequest) (model.LoginResponse, error)
+	UploadPayment(ctx context.Context, userID uuid.UUID, file *multipart.FileHeader, idempotencyKey string) (string, error)
+	UploadKTM(ctx context.Context, userID uuid.UUID, file *multipart.FileHeader) error
+	VerifyUser(ctx context.Context, param model.VerifyUser) error
+	VerifyUserByToken(ctx context.Context, token string) error
+	UpdateProfile(ctx context.Context, userID uuid.UUID, param model.UpdateProfile) (*model.UpdateProfile, error)
+	RequestEmailChange(ctx context.Context, userID uuid.UUID, newEmail string) error
+	ConfirmEmailChange(ctx context.Context, userID uuid.UUID, code string) error
+	DeleteAccount(ctx context.Context, userID uuid.UUID) error
+	RestoreAccount(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) error
+	GetUserProfile(ctx context.Context, userID uuid.UUID) (model.UserProfile, error)
+	GetMyTeamProfile(ctx context.Context, userID uuid.UUID) (*model.UserTeamProfile, error)
+	ExportUserData(ctx context.Context, userID uuid.UUID) (*model.UserDataExport, error)
+	GetLoginHistory(ctx context.Context, userID uuid.UUID) ([]model.LoginEvent, error)
+	PruneLoginHistory(ctx context.Context) error
+	ChangePassword(ctx context.Context, email string) error
+	ChangePasswordAfterVerify(ctx context.Context, param model.ResetPasswordRequest) error
+	VerifyOtpChangePassword(ctx context.Context, param model.VerifyToken) error
+	CompetitionRegistration(ctx context.Context, userID uuid.UUID, competitionID int, param model.CompetitionRegistrationRequest) (model.CompetitionRegistrationResponse, error)
+	GetUserPaymentStatus(ctx context.Context, page model.PaginationQuery) (*model.Paginated[*model.GetUserPaymentStatus], error)
+	GetTotalParticipant(ctx context.Context) (*model.GetTotalParticipant, error)
+	GetUser(ctx context.Context, param model.UserParam) (*entity.User, error)
+	IsEmailAvailable(ctx context.Context, email string) (bool, error)
+	GetUserRole(ctx context.Context, userID uuid.UUID) (*model.UserRole, error)
+	ListRoles(ctx context.Context) ([]model.RoleResponse, error)
+	HasPermission(ctx context.Context, roleID int, permission string) (bool, error)
+	AssignRole(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, param model.AssignRoleRequest) error
 }
 
 type UserService struct {
-	db                    *gorm.DB
-	UserRepository        repository.IUserRepository
-	TeamRepository        repository.ITeamRepository
-	OtpRepository         repository.IOtpRepository
-	CompetitionRepository repository.ICompetitionRepository
-	BCrypt                bcrypt.Interface
-	JwtAuth               jwt.Interface
-	Supabase              supabase.Interface
+	db                         *gorm.DB
+	cfg                        *config.Config
+	UserRepository             repository.IUserRepository
+	TeamRepository             repository.ITeamRepository
+	OtpRepository              repository.IOtpRepository
+	CompetitionRepository      repository.ICompetitionRepository
+	IdempotencyRepository      repository.IIdempotencyRepository
+	LoginFingerprintRepository repository.ILoginFingerprintRepository
+	LoginHistoryRepository     repository.ILoginHistoryRepository
+	CouponRepository           repository.ICouponRepository
+	PasswordHistoryRepository  repository.IPasswordHistoryRepository
+	AuditLogRepository         repository.IAuditLogRepository
+	RoleRepository             repository.IRoleRepository
+	SubmissionRepository       repository.ISubmissionRepository
+	NotificationRepository     repository.INotificationRepository
+	BCrypt                     bcrypt.Interface
+	JwtAuth                    jwt.Interface
+	Storage                    storage.Interface
+	Google                     google.Interface
+	Captcha                    captcha.Interface
+	Mailer                     mail.Mailer
+	Clock                      clock.Clock
+	Logger                     *slog.Logger
+	GenerateCode               func() string
 }
 
-func NewUserService(userRepository repository.IUserRepository, teamRepository repository.ITeamRepository, otpRepository repository.IOtpRepository, competitionRepository repository.ICompetitionRepository, bcrypt bcrypt.Interface, jwtAuth jwt.Interface, supabase supabase.Interface) IUserService {
+func NewUserService(db *gorm.DB, userRepository repository.IUserRepository, teamRepository repository.ITeamRepository, otpRepository repository.IOtpRepository, competitionRepository repository.ICompetitionRepository, idempotencyRepository repository.IIdempotencyRepository, loginFingerprintRepository repository.ILoginFingerprintRepository, loginHistoryRepository repository.ILoginHistoryRepository, couponRepository repository.ICouponRepository, passwordHistoryRepository repository.IPasswordHistoryRepository, auditLogRepository repository.IAuditLogRepository, roleRepository repository.IRoleRepository, submissionRepository repository.ISubmissionRepository, notificationRepository repository.INotificationRepository, bcrypt bcrypt.Interface, jwtAuth jwt.Interface, storage storage.Interface, google google.Interface, captcha captcha.Interface, mailer mail.Mailer, clk clock.Clock, logger *slog.Logger, cfg *config.Config) IUserService {
 	return &UserService{
-		db:                    mariadb.Connection,
-		UserRepository:        userRepository,
-		TeamRepository:        teamRepository,
-		OtpRepository:         otpRepository,
-		CompetitionRepository: competitionRepository,
-		BCrypt:                bcrypt,
-		JwtAuth:               jwtAuth,
-		Supabase:              supabase,
+		db:                         db,
+		cfg:                        cfg,
+		UserRepository:             userRepository,
+		TeamRepository:             teamRepository,
+		OtpRepository:              otpRepository,
+		CompetitionRepository:      competitionRepository,
+		IdempotencyRepository:      idempotencyRepository,
+		LoginFingerprintRepository: loginFingerprintRepository,
+		LoginHistoryRepository:     loginHistoryRepository,
+		CouponRepository:           couponRepository,
+		PasswordHistoryRepository:  passwordHistoryRepository,
+		AuditLogRepository:         auditLogRepository,
+		RoleRepository:             roleRepository,
+		SubmissionRepository:       submissionRepository,
+		NotificationRepository:     notificationRepository,
+		BCrypt:                     bcrypt,
+		JwtAuth:                    jwtAuth,
+		Storage:                    storage,
+		Google:                     google,
+		Captcha:                    captcha,
+		Mailer:                     mailer,
+		Clock:                      clk,
+		Logger:                     logger,
+		GenerateCode:               mail.GenerateCode,
 	}
 }
 
-func (u *UserService) Register(param *model.UserRegister) (model.RegisterResponse, error) {
-	tx := u.db.Begin()
-	defer tx.Rollback()
-
+func (u *UserService) Register(ctx context.Context, param *model.UserRegister, idempotencyKey string) (model.RegisterResponse, error) {
 	var result model.RegisterResponse
+	var created *entity.User
+
+	// There is no user yet, so the key is scoped to the email being registered.
+	keyOwner := uuid.NewSHA1(uuid.Nil, []byte(param.Email))
+
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		if idempotencyKey != "" {
+			stored, err := u.IdempotencyRepository.GetIdempotencyKey(tx, idempotencyScopeRegister, keyOwner, idempotencyKey, u.Clock.Now().Add(-idempotencyKeyTTL))
+			if err == nil {
+				result, err = registerReplay(stored)
+				return err
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
 
-	_, err := u.UserRepository.GetUser(model.UserParam{
-		Email: param.Email,
-	})
-
-	if err == nil {
-		return result, errors.New("email already registered")
-	}
+		// Checked after the replay, since a retry resends a token reCAPTCHA
+		// has already consumed.
+		err := u.Captcha.Verify(ctx, param.CaptchaToken, param.IPAddress)
+		if err != nil {
+			return err
+		}
 
-	if param.Password != param.ConfirmPassword {
-		return result, errors.New("password doesn't match")
-	}
+		hash, err := u.BCrypt.GenerateFromPassword(param.Password)
+		if err != nil {
+			return err
+		}
 
-	hash, err := u.BCrypt.GenerateFromPassword(param.Password)
-	if err != nil {
-		return result, err
-	}
+		id, err := uuid.NewUUID()
+		if err != nil {
+			return err
+		}
 
-	id, err := uuid.NewUUID()
-	if err != nil {
-		return result, err
-	}
+		result.UserID = id
+
+		// The key is claimed before anything else is written, so a concurrent
+		// request with the same key waits on the unique index and then fails
+		// instead of creating a second account.
+		if idempotencyKey != "" {
+			err = u.IdempotencyRepository.DeleteExpiredIdempotencyKeys(tx, u.Clock.Now().Add(-idempotencyKeyTTL))
+			if err != nil {
+				return err
+			}
+
+			err = u.IdempotencyRepository.CreateIdempotencyKey(tx, &entity.IdempotencyKey{
+				IdempotencyKeyID: uuid.New(),
+				Key:              idempotencyKey,
+				Scope:            idempotencyScopeRegister,
+				UserID:           keyOwner,
+				Response:         id.String(),
+			})
+			if err != nil {
+				return err
+			}
+		}
 
-	user := &entity.User{
-		UserID:        id,
-		Email:         param.Email,
-		Password:      hash,
-		StatusAccount: "inactive",
-		RoleID:        2,
-	}
+		user := &entity.User{
+			UserID:        id,
+			Email:         param.Email,
+			Password:      hash,
+			StatusAccount: "inactive",
+			RoleID:        entity.RoleUser,
+		}
 
-	_, err = u.UserRepository.CreateUser(tx, user)
-	if err != nil {
-		return result, err
-	}
+		// The unique email index decides between simultaneous signups, so there
+		// is no lookup beforehand that both of them could pass.
+		_, err = u.UserRepository.CreateUser(tx, user)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.ErrEmailAlreadyRegistered
+		}
+		if err != nil {
+			return err
+		}
+		created = user
 
-	token, err := u.JwtAuth.CreateJWTToken(user.UserID, false)
-	if err != nil {
-		return result, errors.New("failed to create token")
-	}
+		competitionID, err := defaultCompetitionID(u.CompetitionRepository, tx, u.cfg)
+		if err != nil {
+			return err
+		}
 
-	team := &entity.Team{
-		TeamID:        uuid.New(),
-		TeamName:      "",
-		TeamStatus:    "belum terverifikasi",
-		UserID:        user.UserID,
-		CompetitionID: 1,
-	}
+		team := &entity.Team{
+			TeamID:        uuid.New(),
+			TeamName:      "",
+			TeamStatus:    "belum terverifikasi",
+			UserID:        user.UserID,
+			CompetitionID: competitionID,
+		}
 
-	err = u.TeamRepository.CreateTeam(tx, team)
-	if err != nil {
-		return result, err
-	}
+		err = u.TeamRepository.CreateTeam(tx, team)
+		if err != nil {
+			return err
+		}
 
-	code := mail.GenerateCode()
-	otp := &entity.OtpCode{
-		OtpID:  uuid.New(),
-		UserID: user.UserID,
-		Code:   code,
-	}
+		code := u.GenerateCode()
+		otp := &entity.OtpCode{
+			OtpID:   uuid.New(),
+			UserID:  user.UserID,
+			Code:    code,
+			Purpose: model.OtpPurposeVerify,
+		}
 
-	err = u.OtpRepository.CreateOtp(tx, otp)
-	if err != nil {
-		return result, err
-	}
+		err = u.OtpRepository.CreateOtp(tx, otp)
+		if err != nil {
+			return err
+		}
 
-	err = mail.SendEmail(user.Email, "OTP Verification", fmt.Sprintf(`
+		err = u.Mailer.Send(user.Email, "OTP Verification", fmt.Sprintf(`
 		<!DOCTYPE html>
 		<html lang="id">
 		<head>
@@ -192,7 +294,7 @@ func (u *UserService) Register(param *model.UserRegister) (model.RegisterRespons
 
 							<tr>
 								<td align="center" style="padding: 10px 20px; font-family: Arial, sans-serif; font-size: 16px; line-height: 1.5; color: #d1d1d1;">
-									Gunakan kode di bawah ini untuk menyelesaikan proses verifikasi email Anda. Kode ini hanya berlaku selama 5 menit.
+									Gunakan kode di bawah ini untuk menyelesaikan proses verifikasi email Anda. Kode ini hanya berlaku selama %d menit.
 								</td>
 							</tr>
 
@@ -210,6 +312,8 @@ func (u *UserService) Register(param *model.UserRegister) (model.RegisterRespons
 								</td>
 							</tr>
 
+							%s
+
 							<tr>
 								<td align="center" style="padding: 30px 20px 20px 20px; font-family: Arial, sans-serif; font-size: 14px; line-height: 1.5; color: #a0a0a0;">
 									Jika Anda tidak merasa mendaftar untuk IT FEST, abaikan saja email ini.
@@ -229,325 +333,1600 @@ func (u *UserService) Register(param *model.UserRegister) (model.RegisterRespons
 			</table>
 		</body>
 		</html>
-		`, code))
+		`, int(u.cfg.Otp.Verify.Minutes()), code, verificationLinkRow(u.Logger, u.JwtAuth, u.cfg, user.UserID, code)))
 
-	if err != nil {
-		return result, err
-	}
+		if err != nil {
+			return err
+		}
 
-	err = tx.Commit().Error
+		return nil
+	})
 	if err != nil {
-		return result, err
+		if idempotencyKey != "" && errors.Is(err, gorm.ErrDuplicatedKey) {
+			stored, lookupErr := u.IdempotencyRepository.GetIdempotencyKey(u.db.WithContext(ctx), idempotencyScopeRegister, keyOwner, idempotencyKey, u.Clock.Now().Add(-idempotencyKeyTTL))
+			if lookupErr == nil {
+				return registerReplay(stored)
+			}
+		}
+
+		if !errors.Is(err, model.ErrEmailAlreadyRegistered) && !errors.Is(err, captcha.ErrVerificationFailed) {
+			u.Logger.ErrorContext(ctx, "failed to register user", "operation", "register", "error", err)
+		}
+
+		return model.RegisterResponse{}, err
 	}
 
-	result.Token = token
+	// A replayed idempotency key returns the stored result without creating
+	// another account.
+	if created != nil {
+		metrics.Registrations.WithLabelValues("password").Inc()
+		u.Logger.InfoContext(ctx, "user registered", "user_id", created.UserID, "method", "password")
+	}
 
 	return result, nil
 }
 
-func (u *UserService) Login(param model.UserLogin) (model.LoginResponse, error) {
-	var isAdmin bool
+// registerReplay answers a repeated Idempotency-Key. Only the user ID is kept
+// for the key, since the key is scoped by email alone and anyone could send
+// the same pair.
+func registerReplay(stored *entity.IdempotencyKey) (model.RegisterResponse, error) {
+	userID, err := uuid.Parse(stored.Response)
+	if err != nil {
+		return model.RegisterResponse{}, fmt.Errorf("stored register response is not a user ID: %w", err)
+	}
 
-	tx := u.db.Begin()
-	defer tx.Rollback()
+	return model.RegisterResponse{
+		UserID: userID,
+	}, nil
+}
 
+func (u *UserService) Login(ctx context.Context, param model.UserLogin) (model.LoginResponse, error) {
 	var result model.LoginResponse
 
-	user, err := u.UserRepository.GetUser(model.UserParam{
+	if u.cfg.Captcha.OnLogin {
+		err := u.Captcha.Verify(ctx, param.CaptchaToken, param.IPAddress)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	user, err := u.UserRepository.GetUser(ctx, model.UserParam{
 		Email: param.Email,
 	})
-	if err != nil {
-		return result, errors.New("email or password is wrong")
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, err
 	}
-
-	if user.RoleID == 1 {
-		isAdmin = true
-	} else {
-		isAdmin = false
+	if err != nil || user.AuthProvider == "google" {
+		// Still pay for a bcrypt comparison so response times don't reveal
+		// whether the email is registered. A google account has no password
+		// to compare and is answered the same way.
+		u.BCrypt.CompareDummy(param.Password)
+		metrics.FailedLogins.Inc()
+		return result, errors.New("email or password is wrong")
 	}
 
 	err = u.BCrypt.CompareAndHashPassword(user.Password, param.Password)
 	if err != nil {
+		metrics.FailedLogins.Inc()
 		return result, errors.New("email or password is wrong")
 	}
 
-	token, err := u.JwtAuth.CreateJWTToken(user.UserID, isAdmin)
-	if err != nil {
-		return result, errors.New("failed to create token")
+	if u.BCrypt.NeedsRehash(user.Password) {
+		u.upgradePasswordHash(ctx, user, param.Password)
 	}
 
-	result.Token = token
-
-	err = tx.Commit().Error
-	if err != nil {
-		return result, nil
+	// Only checked once the password matched, so the status of an account
+	// isn't revealed to someone who only knows its email.
+	if user.StatusAccount != "active" {
+		return result, &model.UnverifiedAccountError{UserID: user.UserID}
 	}
 
-	return result, nil
-}
-
-func (u *UserService) UploadPayment(userID uuid.UUID, file *multipart.FileHeader) (string, error) {
-	maxSize := int64(1024 * 1024)
-	if file.Size > maxSize {
-		return "", errors.New("file size exceeds maximum limit of 1MB")
+	role, err := u.RoleRepository.GetRoleByID(u.db.WithContext(ctx), user.RoleID)
+	if err != nil {
+		return result, err
 	}
 
-	tx := u.db.Begin()
-	defer tx.Rollback()
-
-	user, err := u.UserRepository.GetUser(model.UserParam{
-		UserID: userID,
-	})
+	token, expiresAt, err := u.JwtAuth.CreateSessionToken(user.UserID, role.RoleName, param.RememberMe)
 	if err != nil {
-		return "", errors.New("user not found")
+		return result, errors.New("failed to create token")
 	}
 
-	paymentURL, err := u.Supabase.UploadFile(file)
+	err = u.recordLogin(ctx, user, param.LoginClient)
 	if err != nil {
-		return "", err
+		return result, err
 	}
+	metrics.Logins.WithLabelValues("password").Inc()
 
-	user.PaymentTransc = paymentURL
+	result.Token = token
+	result.ExpiresAt = expiresAt
+	result.User = loginUser(user, role)
 
-	err = u.UserRepository.UpdateUser(tx, user)
-	if err != nil {
-		return "", err
-	}
+	return result, nil
+}
+
+// LoginWithGoogle signs in with a Google ID token, creating an already active
+// account on first use. Emails that belong to a password account are refused
+// instead of being linked silently.
+func (u *UserService) LoginWithGoogle(ctx context.Context, param model.GoogleLoginRequest) (model.LoginResponse, error) {
+	var result model.LoginResponse
 
-	err = tx.Commit().Error
+	payload, err := u.Google.VerifyIDToken(ctx, param.IDToken)
 	if err != nil {
-		return "", err
+		return result, err
 	}
 
-	return paymentURL, nil
-}
-
-func (u *UserService) UploadKTM(userID uuid.UUID, file *multipart.FileHeader) error {
-	maxSize := int64(1024 * 1024)
-	if file.Size > maxSize {
-		return errors.New("file size exceeds maximum limit of 1MB")
+	user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+		Email: payload.Email,
+	})
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, err
 	}
 
-	tx := u.db.Begin()
-	defer tx.Rollback()
+	if user == nil {
+		user, err = u.createGoogleUser(ctx, payload)
+		if errors.Is(err, model.ErrEmailAlreadyRegistered) {
+			// A simultaneous request created the account first.
+			user, err = u.UserRepository.GetUser(ctx, model.UserParam{
+				Email: payload.Email,
+			})
+		}
+		if err != nil {
+			return result, err
+		}
+	}
 
-	user, err := u.UserRepository.GetUser(model.UserParam{
-		UserID: userID,
-	})
-	if err != nil {
-		return err
+	if user.AuthProvider != "google" {
+		return result, model.ErrEmailRegisteredWithPassword
 	}
 
-	ktmURL, err := u.Supabase.UploadFile(file)
+	role, err := u.RoleRepository.GetRoleByID(u.db.WithContext(ctx), user.RoleID)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	user.StudentCardLink = ktmURL
-
-	err = u.UserRepository.UpdateUser(tx, user)
+	token, expiresAt, err := u.JwtAuth.CreateSessionToken(user.UserID, role.RoleName, param.RememberMe)
 	if err != nil {
-		return err
+		return result, errors.New("failed to create token")
 	}
 
-	err = tx.Commit().Error
+	err = u.recordLogin(ctx, user, param.LoginClient)
 	if err != nil {
-		return err
+		return result, err
 	}
+	metrics.Logins.WithLabelValues("google").Inc()
 
-	return nil
+	result.Token = token
+	result.ExpiresAt = expiresAt
+	result.User = loginUser(user, role)
 
+	return result, nil
 }
 
-func (u *UserService) VerifyUser(param model.VerifyUser) error {
-	tx := u.db.Begin()
-	defer tx.Rollback()
+// recordLogin stores the login time and the client's IP address. The user is
+// emailed when the IP hasn't been seen for them before, except on their very
+// first login.
+func (u *UserService) recordLogin(ctx context.Context, user *entity.User, client model.LoginClient) error {
+	now := u.Clock.Now()
 
-	otp, err := u.OtpRepository.GetOtp(tx, model.GetOtp{
-		UserID: param.UserID,
-	})
-	if err != nil {
-		return err
-	}
+	return withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		err := u.UserRepository.UpdateUserColumns(tx, user.UserID, map[string]interface{}{
+			"last_login_at": now,
+		})
+		if err != nil {
+			return err
+		}
 
-	if otp.Code != param.OtpCode {
-		return errors.New("invalid otp code")
-	}
+		fingerprint, err := u.LoginFingerprintRepository.GetLoginFingerprint(tx, user.UserID, client.IPAddress)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			known, err := u.LoginFingerprintRepository.CountLoginFingerprints(tx, user.UserID)
+			if err != nil {
+				return err
+			}
+
+			if known > 0 {
+				mail.SendAsync(u.Mailer, user.Email, "Login Baru ke Akun IT FEST", newLoginMailBody(client, now))
+			}
+
+			fingerprint = &entity.LoginFingerprint{
+				LoginFingerprintID: uuid.New(),
+				UserID:             user.UserID,
+				IPAddress:          client.IPAddress,
+			}
+		} else if err != nil {
+			return err
+		}
 
-	expiredTime, err := strconv.Atoi(os.Getenv("EXPIRED_OTP"))
-	if err != nil {
-		return err
-	}
+		fingerprint.UserAgent = truncate(client.UserAgent, 255)
+		fingerprint.LastSeenAt = now
 
-	expiredThreshold := time.Now().UTC().Add(-time.Duration(expiredTime) * time.Minute)
-	if otp.UpdatedAt.Before(expiredThreshold) {
-		return errors.New("otp expired")
-	}
+		err = u.LoginFingerprintRepository.SaveLoginFingerprint(tx, fingerprint)
+		if err != nil {
+			return err
+		}
 
-	user, err := u.UserRepository.GetUser(model.UserParam{
-		UserID: param.UserID,
+		err = u.LoginFingerprintRepository.DeleteStaleLoginFingerprints(tx, user.UserID, loginFingerprintLimit)
+		if err != nil {
+			return err
+		}
+
+		err = u.LoginHistoryRepository.CreateLoginHistory(tx, &entity.LoginHistory{
+			LoginHistoryID: uuid.New(),
+			UserID:         user.UserID,
+			IPAddress:      client.IPAddress,
+			UserAgent:      truncate(client.UserAgent, 255),
+			CreatedAt:      now,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(u.AuditLogRepository, tx, model.AuditEntry{
+			ActorID:    &user.UserID,
+			Action:     model.AuditActionLogin,
+			TargetType: "user",
+			TargetID:   user.UserID.String(),
+			Metadata: map[string]interface{}{
+				"auth_provider": user.AuthProvider,
+				"ip_address":    client.IPAddress,
+				"user_agent":    truncate(client.UserAgent, 255),
+			},
+		})
 	})
-	if err != nil {
-		return err
-	}
+}
 
-	user.StatusAccount = "active"
-	err = u.UserRepository.UpdateUser(tx, user)
+// upgradePasswordHash re-hashes the password with the current bcrypt cost. It
+// runs after a successful login, so a failure is only logged and the old hash
+// keeps working.
+func (u *UserService) upgradePasswordHash(ctx context.Context, user *entity.User, password string) {
+	hash, err := u.BCrypt.GenerateFromPassword(password)
 	if err != nil {
-		return err
+		u.Logger.ErrorContext(ctx, "failed to rehash password", "user_id", user.UserID, "error", err)
+		return
 	}
 
-	err = u.OtpRepository.DeleteOtp(tx, otp)
+	err = withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		return u.UserRepository.UpdateUserColumns(tx, user.UserID, map[string]interface{}{
+			"password": hash,
+		})
+	})
 	if err != nil {
-		return err
+		u.Logger.ErrorContext(ctx, "failed to store rehashed password", "user_id", user.UserID, "error", err)
+		return
 	}
 
-	err = tx.Commit().Error
-	if err != nil {
-		return err
+	user.Password = hash
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
 	}
 
-	return nil
+	return s[:max]
 }
 
-func (u *UserService) UpdateProfile(userID uuid.UUID, param model.UpdateProfile) (*model.UpdateProfile, error) {
-	tx := u.db.Begin()
-	defer tx.Rollback()
-
-	user, err := u.UserRepository.GetUser(model.UserParam{
-		UserID: userID,
-	})
+// defaultCompetitionID returns the competition a new team is placed in until its
+// leader registers for one. It is read from DEFAULT_COMPETITION_ID, falls back
+// to 1, and must exist so the team never points at a missing competition.
+func defaultCompetitionID(competitionRepository repository.ICompetitionRepository, tx *gorm.DB, cfg *config.Config) (int, error) {
+	competitionID := cfg.App.DefaultCompetitionID
 
+	exists, err := competitionRepository.CompetitionExists(tx, competitionID)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	user.FullName = param.FullName
-	user.StudentNumber = param.StudentNumber
-	user.University = param.University
-	user.Major = param.Major
-	user.PhoneNumber = param.PhoneNumber
-
-	err = u.UserRepository.UpdateUser(tx, user)
-	if err != nil {
-		return nil, err
+	if !exists {
+		return 0, fmt.Errorf("default competition %d does not exist", competitionID)
 	}
 
-	response := &model.UpdateProfile{
+	return competitionID, nil
+}
+
+func loginUser(user *entity.User, role *entity.Role) model.LoginUser {
+	return model.LoginUser{
+		UserID:        user.UserID,
 		FullName:      user.FullName,
-		StudentNumber: user.StudentNumber,
-		University:    user.University,
-		Major:         user.Major,
-		PhoneNumber:   user.PhoneNumber,
+		Email:         user.Email,
+		RoleID:        user.RoleID,
+		RoleName:      role.RoleName,
+		StatusAccount: user.StatusAccount,
 	}
+}
 
-	err = tx.Commit().Error
-	if err != nil {
-		return nil, err
-	}
+func (u *UserService) createGoogleUser(ctx context.Context, payload *google.Payload) (*entity.User, error) {
+	user := &entity.User{
+		UserID:        uuid.New(),
+		FullName:      payload.Name,
+		Email:         payload.Email,
+		StatusAccount: "active",
+		AuthProvider:  "google",
+		RoleID:        entity.RoleUser,
+	}
+
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		_, err := u.UserRepository.CreateUser(tx, user)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.ErrEmailAlreadyRegistered
+		}
+		if err != nil {
+			return err
+		}
 
-	return response, nil
-}
+		competitionID, err := defaultCompetitionID(u.CompetitionRepository, tx, u.cfg)
+		if err != nil {
+			return err
+		}
 
-func (u *UserService) GetUserProfile(userID uuid.UUID) (model.UserProfile, error) {
-	var result model.UserProfile
+		err = u.TeamRepository.CreateTeam(tx, &entity.Team{
+			TeamID:        uuid.New(),
+			TeamName:      "",
+			TeamStatus:    "belum terverifikasi",
+			UserID:        user.UserID,
+			CompetitionID: competitionID,
+		})
+		if err != nil {
+			return err
+		}
 
-	user, err := u.UserRepository.GetUser(model.UserParam{
-		UserID: userID,
+		return nil
 	})
 	if err != nil {
-		return result, err
+		if !errors.Is(err, model.ErrEmailAlreadyRegistered) {
+			u.Logger.ErrorContext(ctx, "failed to register user", "operation", "google_register", "error", err)
+		}
+		return nil, err
 	}
 
-	result.FullName = user.FullName
-	result.StudentNumber = user.StudentNumber
-	result.University = user.University
-	result.Major = user.Major
-	result.Email = user.Email
-
-	return result, nil
-}
+	metrics.Registrations.WithLabelValues("google").Inc()
+	u.Logger.InfoContext(ctx, "user registered", "user_id", user.UserID, "method", "google")
 
-func (u *UserService) GetUser(param model.UserParam) (*entity.User, error) {
-	return u.UserRepository.GetUser(param)
+	return user, nil
 }
 
-func (u *UserService) GetMyTeamProfile(userID uuid.UUID) (*model.UserTeamProfile, error) {
-	tx := u.db.Begin()
-	defer tx.Rollback()
-
-	user, err := u.UserRepository.GetUser(model.UserParam{
-		UserID: userID,
-	})
-	if err != nil {
-		return nil, err
+func (u *UserService) UploadPayment(ctx context.Context, userID uuid.UUID, file *multipart.FileHeader, idempotencyKey string) (string, error) {
+	maxSize := int64(1024 * 1024)
+	if file.Size > maxSize {
+		return "", errors.New("file size exceeds maximum limit of 1MB")
 	}
 
-	team, err := u.TeamRepository.GetTeamByUserID(tx, userID)
-	if err != nil {
-		return nil, err
-	}
+	var paymentURL, uploadedURL string
 
-	members, err := u.TeamRepository.GetTeamMemberByTeamID(tx, team.TeamID)
-	if err != nil {
-		return nil, err
-	}
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		if idempotencyKey != "" {
+			stored, err := u.IdempotencyRepository.GetIdempotencyKey(tx, idempotencyScopePayment, userID, idempotencyKey, u.Clock.Now().Add(-idempotencyKeyTTL))
+			if err == nil {
+				paymentURL = stored.Response
+				return nil
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
 
-	var memberResponse []model.MemberResponse
-	for _, v := range members {
-		memberResponse = append(memberResponse, model.MemberResponse{
-			FullName:      v.MemberName,
-			StudentNumber: v.StudentNumber,
+		user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+			UserID: userID,
 		})
-	}
+		if err != nil {
+			return errors.New("user not found")
+		}
 
-	competititon, err := u.CompetitionRepository.GetCompetitionByID(tx, team.CompetitionID)
-	if err != nil {
-		return nil, err
-	}
+		team, err := u.TeamRepository.GetTeamByUserID(tx, userID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if team != nil && team.WaitlistedAt != nil {
+			return model.ErrTeamWaitlisted
+		}
 
-	TeamProfileResponse := &model.UserTeamProfile{
-		LeaderName:          user.FullName,
-		TeamName:            team.TeamName,
-		StudentNumber:       user.StudentNumber,
-		Deadline:            competititon.Deadline,
-		CompetitionCategory: competititon.CompetitionName,
-		Members:             memberResponse,
-	}
+		data, filename, err := readUpload(file)
+		if err != nil {
+			return err
+		}
 
-	err = tx.Commit().Error
-	if err != nil {
-		return nil, err
-	}
+		data, filename, err = prepareImage(u.cfg.Image, data, filename)
+		if err != nil {
+			return err
+		}
 
-	return TeamProfileResponse, nil
+		paymentURL, err = u.Storage.UploadFileContext(ctx, data, filename)
+		if err != nil {
+			return err
+		}
+		uploadedURL = paymentURL
 
-}
+		user.PaymentTransc = paymentURL
 
-func (u *UserService) ChangePassword(email string) (string, error) {
-	tx := u.db.Begin()
-	defer tx.Rollback()
+		err = u.UserRepository.UpdateUser(tx, user)
+		if err != nil {
+			return err
+		}
 
-	user, err := u.UserRepository.GetUser(model.UserParam{
-		Email: email,
-	})
-	if err != nil {
-		return "", err
-	}
+		if idempotencyKey != "" {
+			err = u.IdempotencyRepository.DeleteExpiredIdempotencyKeys(tx, u.Clock.Now().Add(-idempotencyKeyTTL))
+			if err != nil {
+				return err
+			}
+
+			err = u.IdempotencyRepository.CreateIdempotencyKey(tx, &entity.IdempotencyKey{
+				IdempotencyKeyID: uuid.New(),
+				Key:              idempotencyKey,
+				Scope:            idempotencyScopePayment,
+				UserID:           userID,
+				Response:         paymentURL,
+			})
+			if err != nil {
+				return err
+			}
+		}
 
-	otp := mail.GenerateCode()
-	err = u.OtpRepository.CreateOtp(tx, &entity.OtpCode{
-		OtpID:  uuid.New(),
-		UserID: user.UserID,
-		Code:   otp,
+		return nil
 	})
 	if err != nil {
+		u.deleteOrphanedUpload(uploadedURL)
 		return "", err
 	}
 
-	err = mail.SendEmail(user.Email, "OTP Atur Ulang Kata Sandi", fmt.Sprintf(`
-		<!DOCTYPE html>
-		<html lang="id">
+	return paymentURL, nil
+}
+
+func (u *UserService) UploadKTM(ctx context.Context, userID uuid.UUID, file *multipart.FileHeader) error {
+	maxSize := int64(1024 * 1024)
+	if file.Size > maxSize {
+		return errors.New("file size exceeds maximum limit of 1MB")
+	}
+
+	var uploadedURL string
+
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		data, filename, err := readUpload(file)
+		if err != nil {
+			return err
+		}
+
+		data, filename, err = prepareImage(config.ImageCompression{}, data, filename)
+		if err != nil {
+			return err
+		}
+
+		ktmURL, err := u.Storage.UploadFileContext(ctx, data, filename)
+		if err != nil {
+			return err
+		}
+		uploadedURL = ktmURL
+
+		user.StudentCardLink = ktmURL
+
+		err = u.UserRepository.UpdateUser(tx, user)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		u.deleteOrphanedUpload(uploadedURL)
+		return err
+	}
+
+	return nil
+
+}
+
+// deleteOrphanedUpload removes a file that was uploaded by a request whose
+// transaction then failed, so storage doesn't fill with unreferenced files.
+func (u *UserService) deleteOrphanedUpload(fileURL string) {
+	if fileURL == "" {
+		return
+	}
+
+	err := u.Storage.DeleteFile(fileURL)
+	if err != nil {
+		u.Logger.Warn("failed to delete orphaned upload", "url", fileURL, "error", err)
+	}
+}
+
+func (u *UserService) VerifyUser(ctx context.Context, param model.VerifyUser) error {
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+			UserID: param.UserID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if user.StatusAccount == "active" {
+			return model.ErrAccountAlreadyVerified
+		}
+
+		otp, err := u.OtpRepository.GetOtp(tx, model.GetOtp{
+			UserID:  param.UserID,
+			Purpose: model.OtpPurposeVerify,
+		})
+		if err != nil {
+			return err
+		}
+
+		if otp.Code != param.OtpCode {
+			return model.ErrInvalidOtpCode
+		}
+
+		if otpExpired(otp, u.cfg.Otp.Verify, u.Clock.Now()) {
+			return model.ErrOtpExpired
+		}
+
+		user.StatusAccount = "active"
+		err = u.UserRepository.UpdateUser(tx, user)
+		if err != nil {
+			return err
+		}
+
+		err = u.OtpRepository.DeleteOtp(tx, otp)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	metrics.OtpVerifications.WithLabelValues(model.OtpPurposeVerify, metrics.Result(err)).Inc()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// VerifyUserByToken activates an account from the emailed verification link.
+// The link carries the account's current verification OTP, so it goes through
+// the same checks as VerifyUser and stops working once the OTP is used or resent.
+func (u *UserService) VerifyUserByToken(ctx context.Context, token string) error {
+	claims, err := u.JwtAuth.ValidateVerificationToken(token)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return model.ErrOtpExpired
+	} else if err != nil {
+		return model.ErrInvalidVerificationLink
+	}
+
+	err = u.VerifyUser(ctx, model.VerifyUser{
+		UserID:  claims.UserID,
+		OtpCode: claims.Code,
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, model.ErrInvalidOtpCode) {
+		return model.ErrInvalidVerificationLink
+	}
+
+	return err
+}
+
+func (u *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, param model.UpdateProfile) (*model.UpdateProfile, error) {
+	err := param.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	var response *model.UpdateProfile
+
+	err = withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		user.FullName = param.FullName
+		user.StudentNumber = param.StudentNumber
+		user.University = param.University
+		user.Major = param.Major
+		user.PhoneNumber = param.PhoneNumber
+
+		err = u.UserRepository.UpdateUser(tx, user)
+		if err != nil {
+			return err
+		}
+
+		response = &model.UpdateProfile{
+			FullName:      user.FullName,
+			StudentNumber: user.StudentNumber,
+			University:    user.University,
+			Major:         user.Major,
+			PhoneNumber:   user.PhoneNumber,
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// RequestEmailChange keeps the new address as pending and sends a verification
+// code to it. The account email stays the same until ConfirmEmailChange, and the
+// current address is told that a change was requested.
+func (u *UserService) RequestEmailChange(ctx context.Context, userID uuid.UUID, newEmail string) error {
+	newEmail = model.NormalizeEmail(newEmail)
+
+	var currentEmail string
+
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if user.AuthProvider == "google" {
+			return model.ErrEmailManagedByGoogle
+		}
+
+		if strings.EqualFold(user.Email, newEmail) {
+			return model.ErrSameEmail
+		}
+
+		currentEmail = user.Email
+
+		err = u.checkEmailAvailable(ctx, newEmail)
+		if err != nil {
+			return err
+		}
+
+		err = u.UserRepository.UpdateUserColumns(tx, userID, map[string]interface{}{
+			"pending_email": newEmail,
+		})
+		if err != nil {
+			return err
+		}
+
+		code := u.GenerateCode()
+		err = u.OtpRepository.CreateOtp(tx, &entity.OtpCode{
+			OtpID:   uuid.New(),
+			UserID:  userID,
+			Code:    code,
+			Purpose: model.OtpPurposeEmailChange,
+		})
+		if err != nil {
+			return err
+		}
+
+		return u.Mailer.Send(newEmail, "Email Change Verification", emailChangeMailBody(code))
+	})
+	if err != nil {
+		return err
+	}
+
+	mail.SendAsync(u.Mailer, currentEmail, "Permintaan Penggantian Email", emailChangeNoticeMailBody(newEmail))
+
+	return nil
+}
+
+// ConfirmEmailChange applies the pending email once the code sent to it is
+// verified, and revokes every token issued before the change.
+func (u *UserService) ConfirmEmailChange(ctx context.Context, userID uuid.UUID, code string) error {
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if user.PendingEmail == "" {
+			return model.ErrNoPendingEmailChange
+		}
+
+		otp, err := u.OtpRepository.GetOtp(tx, model.GetOtp{
+			UserID:  userID,
+			Code:    code,
+			Purpose: model.OtpPurposeEmailChange,
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrInvalidOtpCode
+		} else if err != nil {
+			return err
+		}
+
+		if otpExpired(otp, u.cfg.Otp.Verify, u.Clock.Now()) {
+			return model.ErrOtpExpired
+		}
+
+		// JWT issued-at claims only have second precision, so the cutoff is rounded
+		// up to make sure a token issued just before the change is rejected.
+		revokedAt := u.Clock.Now().Truncate(time.Second).Add(time.Second)
+		err = u.UserRepository.UpdateUserColumns(tx, userID, map[string]interface{}{
+			"email":             user.PendingEmail,
+			"pending_email":     "",
+			"tokens_revoked_at": revokedAt,
+		})
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.ErrEmailAlreadyRegistered
+		}
+		if err != nil {
+			return err
+		}
+
+		return u.OtpRepository.DeleteOtp(tx, otp)
+	})
+	metrics.OtpVerifications.WithLabelValues(model.OtpPurposeEmailChange, metrics.Result(err)).Inc()
+
+	return err
+}
+
+// DeleteAccount soft-deletes the user together with their team, so the team
+// drops out of listings, counts and exports until the account is restored.
+func (u *UserService) DeleteAccount(ctx context.Context, userID uuid.UUID) error {
+	return withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		err := u.UserRepository.DeleteUser(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		return u.TeamRepository.DeleteTeamByUserID(tx, userID)
+	})
+}
+
+func (u *UserService) RestoreAccount(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) error {
+	return withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		// The email may have been registered again while the account was deleted.
+		_, err := u.UserRepository.RestoreUser(tx, userID)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.ErrEmailAlreadyRegistered
+		}
+		if err != nil {
+			return err
+		}
+
+		err = u.TeamRepository.RestoreTeamByUserID(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(u.AuditLogRepository, tx, model.AuditEntry{
+			ActorID:    &actorID,
+			Action:     model.AuditActionRestore,
+			TargetType: "user",
+			TargetID:   userID.String(),
+		})
+	})
+}
+
+// IsEmailAvailable reports whether an email can still be used to register.
+func (u *UserService) IsEmailAvailable(ctx context.Context, email string) (bool, error) {
+	err := u.checkEmailAvailable(ctx, model.NormalizeEmail(email))
+	if errors.Is(err, model.ErrEmailAlreadyRegistered) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (u *UserService) checkEmailAvailable(ctx context.Context, email string) error {
+	_, err := u.UserRepository.GetUser(ctx, model.UserParam{
+		Email: email,
+	})
+	if err == nil {
+		return model.ErrEmailAlreadyRegistered
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+func (u *UserService) GetUserProfile(ctx context.Context, userID uuid.UUID) (model.UserProfile, error) {
+	var result model.UserProfile
+
+	user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+		UserID: userID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.FullName = user.FullName
+	result.StudentNumber = user.StudentNumber
+	result.University = user.University
+	result.Major = user.Major
+	result.Email = user.Email
+	result.PhoneNumber = user.PhoneNumber
+
+	return result, nil
+}
+
+func (u *UserService) GetUser(ctx context.Context, param model.UserParam) (*entity.User, error) {
+	return u.UserRepository.GetUser(ctx, param)
+}
+
+func (u *UserService) GetUserRole(ctx context.Context, userID uuid.UUID) (*model.UserRole, error) {
+	return u.UserRepository.GetUserRole(ctx, userID)
+}
+
+// HasPermission reports whether the role grants permission.
+func (u *UserService) HasPermission(ctx context.Context, roleID int, permission string) (bool, error) {
+	return u.RoleRepository.HasPermission(ctx, roleID, permission)
+}
+
+func (u *UserService) ListRoles(ctx context.Context) ([]model.RoleResponse, error) {
+	roles, err := u.RoleRepository.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.RoleResponse, 0, len(roles))
+	for _, v := range roles {
+		permissions := make([]string, 0, len(v.Permissions))
+		for _, p := range v.Permissions {
+			permissions = append(permissions, p.Permission)
+		}
+
+		result = append(result, model.RoleResponse{
+			RoleID:      v.RoleID,
+			RoleName:    v.RoleName,
+			Permissions: permissions,
+		})
+	}
+
+	return result, nil
+}
+
+// AssignRole gives a user the named role. Admins can't change their own role,
+// so the last admin can't demote themselves by accident.
+func (u *UserService) AssignRole(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, param model.AssignRoleRequest) error {
+	if actorID == userID {
+		return model.ErrOwnRoleChange
+	}
+
+	return withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		role, err := u.RoleRepository.GetRoleByName(tx, strings.ToLower(strings.TrimSpace(param.Role)))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrRoleNotFound
+		} else if err != nil {
+			return err
+		}
+
+		user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if user.RoleID == role.RoleID {
+			return nil
+		}
+
+		err = u.UserRepository.UpdateUserColumns(tx, userID, map[string]interface{}{
+			"role_id": role.RoleID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(u.AuditLogRepository, tx, model.AuditEntry{
+			ActorID:    &actorID,
+			Action:     model.AuditActionRoleChange,
+			TargetType: "user",
+			TargetID:   userID.String(),
+			Metadata: map[string]interface{}{
+				"from_role_id": user.RoleID,
+				"to_role_id":   role.RoleID,
+			},
+		})
+	})
+}
+
+func (u *UserService) GetMyTeamProfile(ctx context.Context, userID uuid.UUID) (*model.UserTeamProfile, error) {
+	var TeamProfileResponse *model.UserTeamProfile
+
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		team, err := teamByUserID(u.TeamRepository, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		members, err := u.TeamRepository.GetTeamMemberByTeamID(tx, team.TeamID)
+		if err != nil {
+			return err
+		}
+
+		memberResponse := []model.MemberResponse{}
+		for _, v := range members {
+			memberResponse = append(memberResponse, model.MemberResponse{
+				FullName:      v.MemberName,
+				StudentNumber: v.StudentNumber,
+			})
+		}
+
+		competititon, err := u.CompetitionRepository.GetCompetitionByID(tx, team.CompetitionID)
+		if err != nil {
+			return err
+		}
+
+		unread, err := u.NotificationRepository.CountUnreadNotifications(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		TeamProfileResponse = &model.UserTeamProfile{
+			LeaderName:          user.FullName,
+			TeamName:            team.TeamName,
+			StudentNumber:       user.StudentNumber,
+			PhoneNumber:         user.PhoneNumber,
+			Deadline:            competititon.Deadline,
+			CompetitionCategory: competititon.CompetitionName,
+			TeamStatus:          team.TeamStatus,
+			PaymentUploaded:     user.PaymentTransc != "",
+			MemberCount:         len(memberResponse),
+			MinMembers:          competititon.MinMembers,
+			MaxMembers:          competititon.MaxMembers,
+			IsComplete:          teamComplete(len(memberResponse), competititon),
+			Members:             memberResponse,
+			UnreadNotifications: unread,
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return TeamProfileResponse, nil
+
+}
+
+// teamComplete reports whether a team has enough members to submit, which is
+// the same minimum checkTeamMinimum enforces. A competition without a team
+// size configured never counts as complete.
+func teamComplete(memberCount int, competition *entity.Competition) bool {
+	if competition.MaxMembers <= 0 {
+		return false
+	}
+
+	return memberCount >= competition.MinMembers && memberCount <= competition.MaxMembers
+}
+
+// ExportUserData gathers everything stored about a user into one document
+// they can download. Secrets such as the password hash are left out.
+func (u *UserService) ExportUserData(ctx context.Context, userID uuid.UUID) (*model.UserDataExport, error) {
+	var result *model.UserDataExport
+
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		role, err := u.RoleRepository.GetRoleByID(tx, user.RoleID)
+		if err != nil {
+			return err
+		}
+
+		fingerprints, err := u.LoginFingerprintRepository.GetLoginFingerprints(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		logins := make([]model.UserDataLogin, 0, len(fingerprints))
+		for _, v := range fingerprints {
+			logins = append(logins, model.UserDataLogin{
+				IPAddress:   v.IPAddress,
+				UserAgent:   v.UserAgent,
+				FirstSeenAt: v.CreatedAt,
+				LastSeenAt:  v.LastSeenAt,
+			})
+		}
+
+		result = &model.UserDataExport{
+			ExportedAt: u.Clock.Now(),
+			Profile: model.UserDataProfile{
+				UserID:          user.UserID,
+				FullName:        user.FullName,
+				Email:           user.Email,
+				PendingEmail:    user.PendingEmail,
+				PhoneNumber:     user.PhoneNumber,
+				StudentNumber:   user.StudentNumber,
+				University:      user.University,
+				Major:           user.Major,
+				StudentCardLink: user.StudentCardLink,
+				PaymentProof:    user.PaymentTransc,
+				AuthProvider:    user.AuthProvider,
+				StatusAccount:   user.StatusAccount,
+				RoleName:        role.RoleName,
+				LastLoginAt:     user.LastLoginAt,
+				CreatedAt:       user.CreatedAt,
+				UpdatedAt:       user.UpdatedAt,
+			},
+			Submissions: []model.SubmissionHistory{},
+			Logins:      logins,
+		}
+
+		team, err := teamByUserID(u.TeamRepository, tx, userID)
+		if errors.Is(err, model.ErrNoTeam) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		members, err := u.TeamRepository.GetTeamMemberByTeamID(tx, team.TeamID)
+		if err != nil {
+			return err
+		}
+
+		memberResponse := []model.MemberResponse{}
+		for _, v := range members {
+			memberResponse = append(memberResponse, model.MemberResponse{
+				FullName:      v.MemberName,
+				StudentNumber: v.StudentNumber,
+			})
+		}
+
+		competition, err := u.CompetitionRepository.GetCompetitionByID(tx, team.CompetitionID)
+		if err != nil {
+			return err
+		}
+
+		extraValues, err := teamExtraFieldValues(team)
+		if err != nil {
+			return err
+		}
+
+		submissions, err := u.SubmissionRepository.GetSubmissionHistory(tx, team.TeamID)
+		if err != nil {
+			return err
+		}
+
+		result.Team = &model.UserDataTeam{
+			TeamID:          team.TeamID,
+			TeamName:        team.TeamName,
+			TeamStatus:      team.TeamStatus,
+			PaymentUploaded: user.PaymentTransc != "",
+			CompetitionID:   competition.CompetitionID,
+			CompetitionName: competition.CompetitionName,
+			ExtraFields:     extraValues,
+			Members:         memberResponse,
+		}
+		result.Submissions = submissions
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetLoginHistory returns the user's most recent logins, newest first.
+func (u *UserService) GetLoginHistory(ctx context.Context, userID uuid.UUID) ([]model.LoginEvent, error) {
+	history, err := u.LoginHistoryRepository.GetLoginHistory(u.db.WithContext(ctx), userID, loginHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]model.LoginEvent, 0, len(history))
+	for _, v := range history {
+		events = append(events, model.LoginEvent{
+			LoggedInAt: v.CreatedAt,
+			IPAddress:  v.IPAddress,
+			UserAgent:  v.UserAgent,
+		})
+	}
+
+	return events, nil
+}
+
+// PruneLoginHistory deletes logins older than LOGIN_HISTORY_RETENTION_DAYS
+// (default 90). It runs once a day.
+func (u *UserService) PruneLoginHistory(ctx context.Context) error {
+	deleted, err := u.LoginHistoryRepository.DeleteLoginHistoryBefore(u.db.WithContext(ctx), u.Clock.Now().Add(-u.cfg.App.LoginHistoryRetention))
+	if err != nil {
+		return err
+	}
+
+	u.Logger.InfoContext(ctx, "pruned login history", "count", deleted)
+
+	return nil
+}
+
+// ChangePassword emails a reset code. It succeeds for unknown emails too, and a
+// google account is only told to sign in with Google, so the endpoint doesn't
+// reveal which emails have a password account.
+func (u *UserService) ChangePassword(ctx context.Context, email string) error {
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+			Email: email,
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if user.AuthProvider == "google" {
+			return u.Mailer.Send(user.Email, "Atur Ulang Kata Sandi", noticeMailBody("Masuk dengan Google", user.FullName,
+				"Kami menerima permintaan untuk mengatur ulang kata sandi akun IT FEST Anda, tetapi akun ini masuk dengan Google dan tidak memiliki kata sandi. Silakan masuk menggunakan tombol Google."))
+		}
+
+		otp := u.GenerateCode()
+		err = u.OtpRepository.CreateOtp(tx, &entity.OtpCode{
+			OtpID:   uuid.New(),
+			UserID:  user.UserID,
+			Code:    otp,
+			Purpose: model.OtpPurposeReset,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = u.Mailer.Send(user.Email, "OTP Atur Ulang Kata Sandi", fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html lang="id">
+		<head>
+			<style>
+				body, table, td, a {
+					-webkit-text-size-adjust: 100%%;
+					-ms-text-size-adjust: 100%%;
+				}
+
+				table, td {
+					mso-table-lspace: 0pt;
+					mso-table-rspace: 0pt;
+				}
+
+				img {
+					-ms-interpolation-mode: bicubic;
+					border: 0;
+					height: auto;
+					line-height: 100%%;
+					outline: none;
+					text-decoration: none;
+				}
+
+				body {
+					height: 100%% !important;
+					margin: 0 !important;
+					padding: 0 !important;
+					width: 100%% !important;
+				}
+			</style>
+		</head>
+
+		<body style="margin: 0; padding: 0; background-color: #030D35; background: linear-gradient(to bottom, #030D35 0%%, #19217C 100%%);">
+			<table border="0" cellpadding="0" cellspacing="0" width="100%%" style="max-width: 600px; margin: 0 auto;">
+				<tr>
+					<td align="center" valign="top" style="padding: 40px 20px 20px 20px;">
+						<table border="0" cellpadding="0" cellspacing="0" width="100%%">
+
+							<tr>
+								<td align="center" style="padding-bottom: 20px;">
+									<img src="https://i.postimg.cc/9QHJbbGw/it-fest-2025.png" width="300" alt="IT FEST 2025 Logo" style="display: block; width: 300px; max-width: 100%%; min-width: 100px; font-family: Arial, sans-serif; color: #ffffff;">
+								</td>
+							</tr>
+
+							<tr>
+								<td align="center" style="padding: 20px 0;">
+									<img src="https://i.postimg.cc/pdCm4W3M/kode.png" width="200" alt="Email Icon" style="display: block; width: 200px;">
+								</td>
+							</tr>
+
+							<tr>
+								<td align="center" style="padding: 10px 0; font-family: Arial, sans-serif; font-size: 24px; font-weight: bold; color: #ffffff;">
+									Kode Atur Ulang Kata Sandi Anda
+								</td>
+							</tr>
+
+							<tr>
+								<td align="center" style="padding: 10px 20px; font-family: Arial, sans-serif; font-size: 16px; line-height: 1.5; color: #d1d1d1;">
+									Kami menerima permintaan untuk mengatur ulang kata sandi akun IT FEST Anda. Gunakan kode di bawah ini pada halaman yang tersedia. Kode ini hanya berlaku selama %d menit.
+								</td>
+							</tr>
+
+							<tr>
+								<td align="center" style="padding: 30px 0;">
+									<table border="0" cellspacing="0" cellpadding="0" width="100%%" style="max-width: 576px;">
+										<tr>
+											<td align="center" style="border-radius: 8px; background-color: #072547; padding: 20px 25px;">
+												<div style="font-family: Arial, sans-serif; font-size: 36px; font-weight: bold; color: #85FFF5; letter-spacing: 5px; text-shadow: 0px 0px 15px rgba(255,255,255,0.6);">
+													%s
+												</div>
+											</td>
+										</tr>
+									</table>
+								</td>
+							</tr>
+
+							<tr>
+								<td align="center" style="padding: 30px 20px 20px 20px; font-family: Arial, sans-serif; font-size: 14px; line-height: 1.5; color: #a0a0a0;">
+									Jika Anda tidak merasa mendaftar untuk IT FEST, abaikan saja email ini.
+								</td>
+							</tr>
+
+							<tr>
+								<td align="center" style="padding: 0 20px 40px 20px; font-family: Arial, sans-serif; font-size: 12px; line-height: 1.5; color: #a0a0a0 !important;">
+									Keluarga Besar Mahasiswa Departemen Sistem Informasi<br>
+									Universitas Brawijaya
+								</td>
+							</tr>
+
+						</table>
+					</td>
+				</tr>
+			</table>
+		</body>
+		</html>
+	`, int(u.cfg.Otp.Reset.Minutes()), otp))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *UserService) VerifyOtpChangePassword(ctx context.Context, param model.VerifyToken) error {
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		_, _, err := u.resetOtp(ctx, tx, param.Email, param.OTP)
+		return err
+	})
+	metrics.OtpVerifications.WithLabelValues(model.OtpPurposeReset, metrics.Result(err)).Inc()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// resetOtp looks up the account and its unexpired reset code. An unknown
+// email reads as a wrong code, so the reset endpoints don't reveal which
+// emails have accounts.
+func (u *UserService) resetOtp(ctx context.Context, tx *gorm.DB, email, code string) (*entity.User, *entity.OtpCode, error) {
+	user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+		Email: email,
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, errors.New("invalid token")
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	otp, err := u.OtpRepository.GetOtp(tx, model.GetOtp{
+		UserID:  user.UserID,
+		Code:    code,
+		Purpose: model.OtpPurposeReset,
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, errors.New("invalid token")
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if otp.Code != code {
+		return nil, nil, errors.New("invalid token")
+	}
+
+	if otpExpired(otp, u.cfg.Otp.Reset, u.Clock.Now()) {
+		return nil, nil, errors.New("token expired")
+	}
+
+	return user, otp, nil
+}
+
+func (u *UserService) ChangePasswordAfterVerify(ctx context.Context, param model.ResetPasswordRequest) error {
+	err := withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		if param.NewPassword != param.ConfirmPassword {
+			return errors.New("password mismatch")
+		}
+
+		user, otp, err := u.resetOtp(ctx, tx, param.Email, param.OTP)
+		if err != nil {
+			return err
+		}
+
+		err = u.OtpRepository.DeleteOtp(tx, otp)
+		if err != nil {
+			return err
+		}
+
+		err = u.checkPasswordReuse(tx, user, param.NewPassword)
+		if err != nil {
+			return err
+		}
+
+		hashPassword, err := u.BCrypt.GenerateFromPassword(param.NewPassword)
+		if err != nil {
+			return err
+		}
+
+		err = u.PasswordHistoryRepository.CreatePasswordHistory(tx, &entity.PasswordHistory{
+			PasswordHistoryID: uuid.New(),
+			UserID:            user.UserID,
+			PasswordHash:      user.Password,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = u.PasswordHistoryRepository.DeleteStalePasswordHistory(tx, user.UserID, u.cfg.App.PasswordHistorySize)
+		if err != nil {
+			return err
+		}
+
+		user.Password = hashPassword
+
+		err = u.UserRepository.UpdateUser(tx, user)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(u.AuditLogRepository, tx, model.AuditEntry{
+			ActorID:    &user.UserID,
+			Action:     model.AuditActionPasswordChange,
+			TargetType: "user",
+			TargetID:   user.UserID.String(),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkPasswordReuse rejects the current password and the last
+// PASSWORD_HISTORY_SIZE previous ones.
+func (u *UserService) checkPasswordReuse(tx *gorm.DB, user *entity.User, password string) error {
+	hashes, err := u.PasswordHistoryRepository.GetRecentPasswordHashes(tx, user.UserID, u.cfg.App.PasswordHistorySize)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range append([]string{user.Password}, hashes...) {
+		if u.BCrypt.CompareAndHashPassword(hash, password) == nil {
+			return model.ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+// CompetitionRegistration registers the user's team for a competition. Once the
+// competition is at capacity, new teams are put on its waitlist instead and
+// wait for an admin to promote them.
+func (u *UserService) CompetitionRegistration(ctx context.Context, userID uuid.UUID, competitionID int, param model.CompetitionRegistrationRequest) (model.CompetitionRegistrationResponse, error) {
+	var result model.CompetitionRegistrationResponse
+
+	err := param.Validate()
+	if err != nil {
+		return result, err
+	}
+
+	var competitionName string
+	var newlyWaitlisted bool
+
+	err = withTransaction(ctx, u.db, func(tx *gorm.DB) error {
+		user, err := u.UserRepository.GetUser(ctx, model.UserParam{
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Registrations for the same competition wait on this lock, so the
+		// capacity count below can't be raced.
+		competition, err := u.CompetitionRepository.GetCompetitionForUpdate(tx, competitionID)
+		if err != nil {
+			return err
+		}
+
+		err = checkRegistrationWindow(competition, u.Clock.Now())
+		if err != nil {
+			return err
+		}
+
+		extraFields, err := competitionExtraFields(competition)
+		if err != nil {
+			return err
+		}
+
+		extraValues, err := model.CheckExtraFieldValues(extraFields, param.ExtraFields)
+		if err != nil {
+			return err
+		}
+
+		team, err := teamByUserID(u.TeamRepository, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if team.CompetitionID != competitionID && (team.TeamStatus != "belum terverifikasi" || user.PaymentTransc != "") {
+			return model.ErrCompetitionLocked
+		}
+
+		competitionName = competition.CompetitionName
+
+		registered := team.CompetitionID == competitionID && team.RegisteredAt != nil
+		if !registered {
+			now := u.Clock.Now()
+			team.RegisteredAt = &now
+			team.WaitlistedAt = nil
+
+			if competition.Capacity > 0 {
+				count, err := u.TeamRepository.CountRegisteredTeams(tx, competitionID, team.TeamID)
+				if err != nil {
+					return err
+				}
+				if count >= int64(competition.Capacity) {
+					team.WaitlistedAt = &now
+					newlyWaitlisted = true
+				}
+			}
+		}
+
+		user.FullName = param.FullName
+		user.StudentNumber = param.StudentNumber
+		user.University = param.University
+		user.Major = param.Major
+		// Older clients don't send a phone number; keep the one on file.
+		if param.PhoneNumber != "" {
+			user.PhoneNumber = param.PhoneNumber
+		}
+
+		err = u.UserRepository.UpdateUser(tx, user)
+		if err != nil {
+			return err
+		}
+
+		if param.CouponCode != "" {
+			err = applyCoupon(u.CouponRepository, tx, param.CouponCode, competition, team, u.Clock.Now())
+			if err != nil {
+				return err
+			}
+		}
+
+		team.CompetitionID = competitionID
+		team.ExtraFields, err = json.Marshal(extraValues)
+		if err != nil {
+			return err
+		}
+
+		err = u.TeamRepository.UpdateTeam(tx, team)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.ErrTeamNameTaken
+		}
+		if err != nil {
+			return err
+		}
+
+		if team.WaitlistedAt != nil {
+			position, err := u.TeamRepository.CountWaitlistPosition(tx, competitionID, *team.WaitlistedAt)
+			if err != nil {
+				return err
+			}
+			result.Waitlisted = true
+			result.WaitlistPosition = int(position)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return model.CompetitionRegistrationResponse{}, err
+	}
+
+	if newlyWaitlisted {
+		u.Logger.InfoContext(ctx, "team waitlisted", "user_id", userID, "competition_id", competitionID, "position", result.WaitlistPosition)
+		notify(u.Logger, u.NotificationRepository, u.db.WithContext(ctx), userID, model.NotificationWaitlist, fmt.Sprintf("Kuota %s sudah penuh. Tim Anda masuk daftar tunggu di urutan ke-%d dan akan kami kabari jika ada slot yang kosong.", competitionName, result.WaitlistPosition))
+	}
+
+	return result, nil
+}
+
+func (u *UserService) GetUserPaymentStatus(ctx context.Context, page model.PaginationQuery) (*model.Paginated[*model.GetUserPaymentStatus], error) {
+	page.Normalize()
+
+	users, total, err := u.UserRepository.GetUsersWithTeamPage(ctx, page.Offset(), page.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	competitions, err := u.CompetitionRepository.GetAllCompetitions(u.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	competitionByID := make(map[int]*entity.Competition, len(competitions))
+	for _, v := range competitions {
+		competitionByID[v.CompetitionID] = v
+	}
+
+	couponIDs := []uuid.UUID{}
+	for _, v := range users {
+		if v.Team.CouponID != nil {
+			couponIDs = append(couponIDs, *v.Team.CouponID)
+		}
+	}
+
+	coupons, err := u.CouponRepository.GetCouponsByIDs(u.db.WithContext(ctx), couponIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	couponByID := make(map[uuid.UUID]*entity.Coupon, len(coupons))
+	for _, v := range coupons {
+		couponByID[v.CouponID] = v
+	}
+
+	items := []*model.GetUserPaymentStatus{}
+	for _, v := range users {
+		competition, ok := competitionByID[v.Team.CompetitionID]
+		if !ok {
+			continue
+		}
+
+		expectedFee := competition.Fee
+		if v.Team.CouponID != nil {
+			if coupon, ok := couponByID[*v.Team.CouponID]; ok {
+				expectedFee -= couponDiscount(coupon, competition.Fee)
+			}
+		}
+
+		items = append(items, &model.GetUserPaymentStatus{
+			FullName:        v.FullName,
+			StudentNumber:   v.StudentNumber,
+			Email:           v.Email,
+			PaymentTransc:   v.PaymentTransc,
+			TeamName:        v.Team.TeamName,
+			TeamStatus:      v.Team.TeamStatus,
+			CompetitionName: competition.CompetitionName,
+			ExpectedFee:     expectedFee,
+		})
+	}
+
+	return model.NewPaginated(items, page, total), nil
+}
+
+func (u *UserService) GetTotalParticipant(ctx context.Context) (*model.GetTotalParticipant, error) {
+
+	counts, err := u.UserRepository.CountParticipantsByCompetition(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &model.GetTotalParticipant{
+		TotalUIUX: int(counts[2]),
+		TotalBP:   int(counts[3]),
+	}
+
+	return res, nil
+}
+
+func emailChangeMailBody(code string) string {
+	return fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html lang="id">
 		<head>
 			<style>
 				body, table, td, a {
@@ -560,15 +1939,6 @@ func (u *UserService) ChangePassword(email string) (string, error) {
 					mso-table-rspace: 0pt;
 				}
 
-				img {
-					-ms-interpolation-mode: bicubic;
-					border: 0;
-					height: auto;
-					line-height: 100%%;
-					outline: none;
-					text-decoration: none;
-				}
-
 				body {
 					height: 100%% !important;
 					margin: 0 !important;
@@ -590,21 +1960,15 @@ func (u *UserService) ChangePassword(email string) (string, error) {
 								</td>
 							</tr>
 
-							<tr>
-								<td align="center" style="padding: 20px 0;">
-									<img src="https://i.postimg.cc/pdCm4W3M/kode.png" width="200" alt="Email Icon" style="display: block; width: 200px;">
-								</td>
-							</tr>
-
 							<tr>
 								<td align="center" style="padding: 10px 0; font-family: Arial, sans-serif; font-size: 24px; font-weight: bold; color: #ffffff;">
-									Kode Atur Ulang Kata Sandi Anda
+									Verifikasi Email Baru
 								</td>
 							</tr>
 
 							<tr>
 								<td align="center" style="padding: 10px 20px; font-family: Arial, sans-serif; font-size: 16px; line-height: 1.5; color: #d1d1d1;">
-									Kami menerima permintaan untuk mengatur ulang kata sandi akun IT FEST Anda. Gunakan kode di bawah ini pada halaman yang tersedia. Kode ini hanya berlaku selama 5 menit.
+									Gunakan kode di bawah ini untuk mengganti email akun IT FEST Anda ke alamat ini.
 								</td>
 							</tr>
 
@@ -624,7 +1988,7 @@ func (u *UserService) ChangePassword(email string) (string, error) {
 
 							<tr>
 								<td align="center" style="padding: 30px 20px 20px 20px; font-family: Arial, sans-serif; font-size: 14px; line-height: 1.5; color: #a0a0a0;">
-									Jika Anda tidak merasa mendaftar untuk IT FEST, abaikan saja email ini.
+									Jika Anda tidak meminta penggantian email, abaikan saja email ini.
 								</td>
 							</tr>
 
@@ -641,200 +2005,147 @@ func (u *UserService) ChangePassword(email string) (string, error) {
 			</table>
 		</body>
 		</html>
-	`, otp))
-	if err != nil {
-		return "", err
-	}
-
-	jwtToken, err := u.JwtAuth.CreateJWTToken(user.UserID, false)
-	if err != nil {
-		return "", nil
-	}
-
-	err = tx.Commit().Error
-	if err != nil {
-		return "", err
-	}
-
-	return jwtToken, nil
-}
-
-func (u *UserService) VerifyOtpChangePassword(param model.VerifyToken) error {
-	tx := u.db.Begin()
-	defer tx.Rollback()
-
-	otp, err := u.OtpRepository.GetOtp(tx, model.GetOtp{
-		UserID: param.UserID,
-		Code:   param.OTP,
-	})
-	if err != nil {
-		return err
-	}
-
-	if otp.Code != param.OTP {
-		return errors.New("invalid token")
-	}
-
-	expiredTime, err := strconv.Atoi(os.Getenv("EXPIRED_OTP"))
-	if err != nil {
-		return err
-	}
-
-	expiredThreshold := time.Now().UTC().Add(-time.Duration(expiredTime) * time.Minute)
-	if otp.UpdatedAt.Before(expiredThreshold) {
-		return errors.New("token expired")
-	}
-
-	err = u.OtpRepository.DeleteOtp(tx, otp)
-	if err != nil {
-		return err
-	}
-
-	err = tx.Commit().Error
-	if err != nil {
-		return err
-	}
-
-	return nil
+	`, code)
 }
 
-func (u *UserService) ChangePasswordAfterVerify(param model.ResetPasswordRequest) error {
-	tx := u.db.Begin()
-	defer tx.Rollback()
-
-	user, err := u.UserRepository.GetUser(model.UserParam{
-		UserID: param.UserID,
-	})
-	if err != nil {
-		return err
-	}
-
-	if param.NewPassword != param.ConfirmPassword {
-		return errors.New("password mismatch")
-	}
-
-	hashPassword, err := u.BCrypt.GenerateFromPassword(param.NewPassword)
-	if err != nil {
-		return err
-	}
-
-	err = u.BCrypt.CompareAndHashPassword(user.Password, param.NewPassword)
-	if err == nil {
-		return errors.New("new password cannot be same as old password")
-	}
-
-	user.Password = hashPassword
-
-	err = u.UserRepository.UpdateUser(tx, user)
-	if err != nil {
-		return err
-	}
-
-	err = tx.Commit().Error
-	if err != nil {
-		return err
-	}
+func newLoginMailBody(client model.LoginClient, at time.Time) string {
+	return fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html lang="id">
+		<head>
+			<style>
+				body, table, td, a {
+					-webkit-text-size-adjust: 100%%;
+					-ms-text-size-adjust: 100%%;
+				}
 
-	return nil
-}
+				table, td {
+					mso-table-lspace: 0pt;
+					mso-table-rspace: 0pt;
+				}
 
-func (u *UserService) CompetitionRegistration(userID uuid.UUID, competitionID int, param model.CompetitionRegistrationRequest) error {
-	tx := u.db.Begin()
-	defer tx.Rollback()
+				body {
+					height: 100%% !important;
+					margin: 0 !important;
+					padding: 0 !important;
+					width: 100%% !important;
+				}
+			</style>
+		</head>
 
-	user, err := u.UserRepository.GetUser(model.UserParam{
-		UserID: userID,
-	})
-	if err != nil {
-		return err
-	}
+		<body style="margin: 0; padding: 0; background-color: #030D35; background: linear-gradient(to bottom, #030D35 0%%, #19217C 100%%);">
+			<table border="0" cellpadding="0" cellspacing="0" width="100%%" style="max-width: 600px; margin: 0 auto;">
+				<tr>
+					<td align="center" valign="top" style="padding: 40px 20px 20px 20px;">
+						<table border="0" cellpadding="0" cellspacing="0" width="100%%">
 
-	user.FullName = param.FullName
-	user.StudentNumber = param.StudentNumber
-	user.University = param.University
-	user.Major = param.Major
+							<tr>
+								<td align="center" style="padding-bottom: 20px;">
+									<img src="https://i.postimg.cc/9QHJbbGw/it-fest-2025.png" width="300" alt="IT FEST 2025 Logo" style="display: block; width: 300px; max-width: 100%%; min-width: 100px; font-family: Arial, sans-serif; color: #ffffff;">
+								</td>
+							</tr>
 
-	err = u.UserRepository.UpdateUser(tx, user)
-	if err != nil {
-		return err
-	}
+							<tr>
+								<td align="center" style="padding: 10px 0; font-family: Arial, sans-serif; font-size: 24px; font-weight: bold; color: #ffffff;">
+									Login dari Perangkat Baru
+								</td>
+							</tr>
 
-	team, err := u.TeamRepository.GetTeamByUserID(tx, userID)
-	if err != nil {
-		return err
-	}
+							<tr>
+								<td align="center" style="padding: 10px 20px; font-family: Arial, sans-serif; font-size: 16px; line-height: 1.5; color: #d1d1d1;">
+									Akun IT FEST Anda baru saja digunakan untuk login pada %s dari alamat IP %s (%s).
+								</td>
+							</tr>
 
-	team.CompetitionID = competitionID
-	err = u.TeamRepository.UpdateTeam(tx, team)
-	if err != nil {
-		return err
-	}
+							<tr>
+								<td align="center" style="padding: 30px 20px 20px 20px; font-family: Arial, sans-serif; font-size: 14px; line-height: 1.5; color: #a0a0a0;">
+									Jika ini bukan Anda, segera ganti kata sandi akun Anda.
+								</td>
+							</tr>
 
-	err = tx.Commit().Error
-	if err != nil {
-		return err
-	}
+							<tr>
+								<td align="center" style="padding: 0 20px 40px 20px; font-family: Arial, sans-serif; font-size: 12px; line-height: 1.5; color: #a0a0a0 !important;">
+									Keluarga Besar Mahasiswa Departemen Sistem Informasi<br>
+									Universitas Brawijaya
+								</td>
+							</tr>
 
-	return nil
+						</table>
+					</td>
+				</tr>
+			</table>
+		</body>
+		</html>
+	`, at.Format("02 Jan 2006 15:04 MST"), html.EscapeString(client.IPAddress), html.EscapeString(client.UserAgent))
 }
 
-func (u *UserService) GetUserPaymentStatus() ([]*model.GetUserPaymentStatus, error) {
-	var res []*model.GetUserPaymentStatus
-
-	tx := u.db.Begin()
-	defer tx.Rollback()
-
-	users, err := u.UserRepository.GetAllUser()
-	if err != nil {
-		return nil, err
-	}
+func emailChangeNoticeMailBody(newEmail string) string {
+	return fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html lang="id">
+		<head>
+			<style>
+				body, table, td, a {
+					-webkit-text-size-adjust: 100%%;
+					-ms-text-size-adjust: 100%%;
+				}
 
-	for _, v := range users {
-		competition, err := u.CompetitionRepository.GetCompetitionByID(tx, v.Team.CompetitionID)
-		if err != nil {
-			continue
-		}
-		res = append(res, &model.GetUserPaymentStatus{
-			FullName:        v.FullName,
-			StudentNumber:   v.StudentNumber,
-			Email:           v.Email,
-			PaymentTransc:   v.PaymentTransc,
-			TeamName:        v.Team.TeamName,
-			TeamStatus:      v.Team.TeamStatus,
-			CompetitionName: competition.CompetitionName,
-		})
-	}
+				table, td {
+					mso-table-lspace: 0pt;
+					mso-table-rspace: 0pt;
+				}
 
-	return res, nil
-}
+				body {
+					height: 100%% !important;
+					margin: 0 !important;
+					padding: 0 !important;
+					width: 100%% !important;
+				}
+			</style>
+		</head>
 
-func (u *UserService) GetTotalParticipant() (*model.GetTotalParticipant, error) {
+		<body style="margin: 0; padding: 0; background-color: #030D35; background: linear-gradient(to bottom, #030D35 0%%, #19217C 100%%);">
+			<table border="0" cellpadding="0" cellspacing="0" width="100%%" style="max-width: 600px; margin: 0 auto;">
+				<tr>
+					<td align="center" valign="top" style="padding: 40px 20px 20px 20px;">
+						<table border="0" cellpadding="0" cellspacing="0" width="100%%">
 
-	var (
-		totalUIUX int
-		totalBP   int
-	)
+							<tr>
+								<td align="center" style="padding-bottom: 20px;">
+									<img src="https://i.postimg.cc/9QHJbbGw/it-fest-2025.png" width="300" alt="IT FEST 2025 Logo" style="display: block; width: 300px; max-width: 100%%; min-width: 100px; font-family: Arial, sans-serif; color: #ffffff;">
+								</td>
+							</tr>
 
-	tx := u.db.Begin()
-	defer tx.Rollback()
+							<tr>
+								<td align="center" style="padding: 10px 0; font-family: Arial, sans-serif; font-size: 24px; font-weight: bold; color: #ffffff;">
+									Permintaan Penggantian Email
+								</td>
+							</tr>
 
-	users, err := u.UserRepository.GetAllUser()
-	if err != nil {
-		return nil, err
-	}
+							<tr>
+								<td align="center" style="padding: 10px 20px; font-family: Arial, sans-serif; font-size: 16px; line-height: 1.5; color: #d1d1d1;">
+									Ada permintaan untuk mengganti email akun IT FEST Anda menjadi %s. Email akun baru akan berubah setelah kode verifikasi yang dikirim ke alamat tersebut dikonfirmasi.
+								</td>
+							</tr>
 
-	for _, v := range users {
-		if v.Team.CompetitionID == 2 {
-			totalUIUX++
-		} else if v.Team.CompetitionID == 3 {
-			totalBP++
-		}
-	}
+							<tr>
+								<td align="center" style="padding: 30px 20px 20px 20px; font-family: Arial, sans-serif; font-size: 14px; line-height: 1.5; color: #a0a0a0;">
+									Jika ini bukan Anda, segera ganti kata sandi akun Anda.
+								</td>
+							</tr>
 
-	res := &model.GetTotalParticipant{
-		TotalUIUX: totalUIUX,
-		TotalBP:   totalBP,
-	}
+							<tr>
+								<td align="center" style="padding: 0 20px 40px 20px; font-family: Arial, sans-serif; font-size: 12px; line-height: 1.5; color: #a0a0a0 !important;">
+									Keluarga Besar Mahasiswa Departemen Sistem Informasi<br>
+									Universitas Brawijaya
+								</td>
+							</tr>
 
-	return res, nil
+						</table>
+					</td>
+				</tr>
+			</table>
+		</body>
+		</html>
+	`, html.EscapeString(newEmail))
 }
@@ -0,0 +1,954 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"itfest-2025/entity"
+	"itfest-2025/model"
+	"itfest-2025/pkg/bcrypt"
+	"itfest-2025/pkg/captcha"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRegisterVerifiesCaptchaOnce(t *testing.T) {
+	f := newUserServiceFixture(t)
+	expectTransaction(f.mock, 1)
+
+	_, err := f.service.Register(context.Background(), &model.UserRegister{
+		Email:        "leader@example.com",
+		Password:     "password123",
+		CaptchaToken: "token-1",
+	}, "")
+	if err != nil {
+		t.Fatalf("Register() error = %v, want nil", err)
+	}
+
+	if err := f.mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterRejectsReusedCaptchaToken(t *testing.T) {
+	f := newUserServiceFixture(t)
+	expectTransaction(f.mock, 1)
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+
+	_, err := f.service.Register(context.Background(), &model.UserRegister{
+		Email:        "first@example.com",
+		Password:     "password123",
+		CaptchaToken: "token-1",
+	}, "")
+	if err != nil {
+		t.Fatalf("first Register() error = %v, want nil", err)
+	}
+
+	_, err = f.service.Register(context.Background(), &model.UserRegister{
+		Email:        "second@example.com",
+		Password:     "password123",
+		CaptchaToken: "token-1",
+	}, "")
+	if !errors.Is(err, captcha.ErrVerificationFailed) {
+		t.Fatalf("second Register() error = %v, want %v", err, captcha.ErrVerificationFailed)
+	}
+
+	if _, err := f.users.GetUser(context.Background(), model.UserParam{Email: "second@example.com"}); err == nil {
+		t.Error("the second account was created despite the reused token")
+	}
+}
+
+func TestRegisterReplayDoesNotVerifyCaptchaAgain(t *testing.T) {
+	f := newUserServiceFixture(t)
+	expectTransaction(f.mock, 2)
+
+	param := &model.UserRegister{
+		Email:        "leader@example.com",
+		Password:     "password123",
+		CaptchaToken: "token-1",
+	}
+
+	first, err := f.service.Register(context.Background(), param, "key-1")
+	if err != nil {
+		t.Fatalf("first Register() error = %v, want nil", err)
+	}
+
+	// The retry resends the token the first request already used.
+	second, err := f.service.Register(context.Background(), param, "key-1")
+	if err != nil {
+		t.Fatalf("retried Register() error = %v, want nil", err)
+	}
+
+	if second.UserID != first.UserID {
+		t.Errorf("retried Register() user ID = %s, want the original %s", second.UserID, first.UserID)
+	}
+}
+
+func TestRegisterReplayIgnoresNewPassword(t *testing.T) {
+	f := newUserServiceFixture(t)
+	expectTransaction(f.mock, 2)
+
+	first, err := f.service.Register(context.Background(), &model.UserRegister{
+		Email:        "leader@example.com",
+		Password:     "password123",
+		CaptchaToken: "token-1",
+	}, "key-1")
+	if err != nil {
+		t.Fatalf("first Register() error = %v, want nil", err)
+	}
+
+	// Someone who only knows the email and the key, not the password.
+	replay, err := f.service.Register(context.Background(), &model.UserRegister{
+		Email:        "leader@example.com",
+		Password:     "another-password",
+		CaptchaToken: "token-2",
+	}, "key-1")
+	if err != nil {
+		t.Fatalf("replayed Register() error = %v, want nil", err)
+	}
+
+	if replay.UserID != first.UserID {
+		t.Errorf("replayed Register() user ID = %s, want %s", replay.UserID, first.UserID)
+	}
+}
+
+func TestLoginActiveAccount(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	expectTransaction(f.mock, 1)
+
+	result, err := f.service.Login(context.Background(), model.UserLogin{
+		Email:    "leader@example.com",
+		Password: "password123",
+	})
+	if err != nil {
+		t.Fatalf("Login() error = %v, want nil", err)
+	}
+
+	if result.Token == "" {
+		t.Error("Login() returned no token")
+	}
+	if result.User.UserID != user.UserID {
+		t.Errorf("Login() user ID = %s, want %s", result.User.UserID, user.UserID)
+	}
+	if err := f.mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoginInactiveAccount(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	user.StatusAccount = "inactive"
+	f.users.put(user)
+
+	result, err := f.service.Login(context.Background(), model.UserLogin{
+		Email:    "leader@example.com",
+		Password: "password123",
+	})
+
+	var unverifiedErr *model.UnverifiedAccountError
+	if !errors.As(err, &unverifiedErr) {
+		t.Fatalf("Login() error = %v, want %T", err, unverifiedErr)
+	}
+	if unverifiedErr.UserID != user.UserID {
+		t.Errorf("UnverifiedAccountError.UserID = %s, want %s", unverifiedErr.UserID, user.UserID)
+	}
+	if result.Token != "" {
+		t.Error("Login() returned a token for an inactive account")
+	}
+	if len(f.loginHistory.entries) != 0 {
+		t.Error("Login() recorded a login for an inactive account")
+	}
+}
+
+func TestResetPasswordRequiresOtp(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	expectTransaction(f.mock, 1)
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+	expectTransaction(f.mock, 1)
+
+	err := f.service.ChangePassword(context.Background(), "leader@example.com")
+	if err != nil {
+		t.Fatalf("ChangePassword() error = %v, want nil", err)
+	}
+
+	err = f.service.ChangePasswordAfterVerify(context.Background(), model.ResetPasswordRequest{
+		Email:           "leader@example.com",
+		OTP:             "000000",
+		NewPassword:     "new-password",
+		ConfirmPassword: "new-password",
+	})
+	if err == nil || err.Error() != "invalid token" {
+		t.Fatalf("ChangePasswordAfterVerify() with a wrong code error = %v, want invalid token", err)
+	}
+	if f.service.BCrypt.CompareAndHashPassword(f.users.get(user.UserID).Password, "password123") != nil {
+		t.Fatal("the password changed without the reset code")
+	}
+
+	err = f.service.ChangePasswordAfterVerify(context.Background(), model.ResetPasswordRequest{
+		Email:           "leader@example.com",
+		OTP:             "123456",
+		NewPassword:     "new-password",
+		ConfirmPassword: "new-password",
+	})
+	if err != nil {
+		t.Fatalf("ChangePasswordAfterVerify() error = %v, want nil", err)
+	}
+	if f.service.BCrypt.CompareAndHashPassword(f.users.get(user.UserID).Password, "new-password") != nil {
+		t.Error("the password was not changed")
+	}
+	if f.otps.count() != 0 {
+		t.Error("the reset code was not used up")
+	}
+}
+
+func TestVerifyOtpChangePasswordKeepsCode(t *testing.T) {
+	f := newUserServiceFixture(t)
+	f.addUser(t, "leader@example.com", "password123")
+	expectTransaction(f.mock, 2)
+
+	err := f.service.ChangePassword(context.Background(), "leader@example.com")
+	if err != nil {
+		t.Fatalf("ChangePassword() error = %v, want nil", err)
+	}
+
+	err = f.service.VerifyOtpChangePassword(context.Background(), model.VerifyToken{
+		Email: "leader@example.com",
+		OTP:   "123456",
+	})
+	if err != nil {
+		t.Fatalf("VerifyOtpChangePassword() error = %v, want nil", err)
+	}
+
+	if f.otps.count() != 1 {
+		t.Error("checking the code used it up before the reset")
+	}
+}
+
+func TestLoginGoogleAccountLooksLikeUnknownEmail(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "google@example.com", "password123")
+	user.AuthProvider = "google"
+	user.Password = ""
+	f.users.put(user)
+
+	_, googleErr := f.service.Login(context.Background(), model.UserLogin{
+		Email:    "google@example.com",
+		Password: "password123",
+	})
+	_, unknownErr := f.service.Login(context.Background(), model.UserLogin{
+		Email:    "unknown@example.com",
+		Password: "password123",
+	})
+
+	if googleErr == nil || unknownErr == nil {
+		t.Fatalf("Login() errors = %v, %v, want both to fail", googleErr, unknownErr)
+	}
+	if googleErr.Error() != unknownErr.Error() {
+		t.Errorf("Login() for a google account error = %q, want the unknown email error %q", googleErr, unknownErr)
+	}
+}
+
+func TestForgotPasswordDoesNotRevealAccounts(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "google@example.com", "password123")
+	user.AuthProvider = "google"
+	user.Password = ""
+	f.users.put(user)
+	expectTransaction(f.mock, 2)
+
+	err := f.service.ChangePassword(context.Background(), "unknown@example.com")
+	if err != nil {
+		t.Fatalf("ChangePassword() for an unknown email error = %v, want nil", err)
+	}
+	if len(f.mailer.Sent()) != 0 {
+		t.Error("ChangePassword() sent an email to an unknown address")
+	}
+
+	err = f.service.ChangePassword(context.Background(), "google@example.com")
+	if err != nil {
+		t.Fatalf("ChangePassword() for a google account error = %v, want nil", err)
+	}
+	if f.otps.count() != 0 {
+		t.Error("ChangePassword() created a reset code for a google account")
+	}
+	if sent := f.mailer.Sent(); len(sent) != 1 || sent[0].To != "google@example.com" {
+		t.Errorf("ChangePassword() sent %v, want one notice to the google account", sent)
+	}
+}
+
+func TestLoginPropagatesDatabaseErrors(t *testing.T) {
+	dbErr := errors.New("connection refused")
+
+	t.Run("user lookup", func(t *testing.T) {
+		f := newUserServiceFixture(t)
+		f.addUser(t, "leader@example.com", "password123")
+		f.users.GetErr = dbErr
+
+		_, err := f.service.Login(context.Background(), model.UserLogin{
+			Email:    "leader@example.com",
+			Password: "password123",
+		})
+		if !errors.Is(err, dbErr) {
+			t.Fatalf("Login() error = %v, want %v", err, dbErr)
+		}
+	})
+
+	t.Run("login record", func(t *testing.T) {
+		f := newUserServiceFixture(t)
+		f.addUser(t, "leader@example.com", "password123")
+		f.loginHistory.CreateErr = dbErr
+		f.mock.ExpectBegin()
+		f.mock.ExpectRollback()
+
+		result, err := f.service.Login(context.Background(), model.UserLogin{
+			Email:    "leader@example.com",
+			Password: "password123",
+		})
+		if !errors.Is(err, dbErr) {
+			t.Fatalf("Login() error = %v, want %v", err, dbErr)
+		}
+		if result.Token != "" {
+			t.Error("Login() returned a token despite the failure")
+		}
+	})
+}
+
+func TestLoginCommitFailure(t *testing.T) {
+	f := newUserServiceFixture(t)
+	f.addUser(t, "leader@example.com", "password123")
+	commitErr := errors.New("commit failed")
+	f.mock.ExpectBegin()
+	f.mock.ExpectCommit().WillReturnError(commitErr)
+
+	result, err := f.service.Login(context.Background(), model.UserLogin{
+		Email:    "leader@example.com",
+		Password: "password123",
+	})
+	if !errors.Is(err, commitErr) {
+		t.Fatalf("Login() error = %v, want %v", err, commitErr)
+	}
+	if result.Token != "" {
+		t.Error("Login() returned a token although its commit failed")
+	}
+	if err := f.mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetUserPaymentStatusSubtractsCoupon(t *testing.T) {
+	f := newUserServiceFixture(t)
+	f.competitions.competitions[1] = entity.Competition{CompetitionID: 1, CompetitionName: "Business Plan", Fee: 150000}
+
+	percent := &entity.Coupon{CouponID: uuid.New(), Code: "HALF", DiscountPercent: 50}
+	amount := &entity.Coupon{CouponID: uuid.New(), Code: "MINUS200", DiscountAmount: 200000}
+	f.coupons.put(percent)
+	f.coupons.put(amount)
+
+	tests := []struct {
+		email    string
+		couponID *uuid.UUID
+		want     int
+	}{
+		{email: "a@example.com", want: 150000},
+		{email: "b@example.com", couponID: &percent.CouponID, want: 75000},
+		{email: "c@example.com", couponID: &amount.CouponID, want: 0},
+	}
+	for _, tt := range tests {
+		f.users.put(&entity.User{
+			UserID: uuid.New(),
+			Email:  tt.email,
+			Team: entity.Team{
+				TeamID:        uuid.New(),
+				CompetitionID: 1,
+				CouponID:      tt.couponID,
+			},
+		})
+	}
+
+	result, err := f.service.GetUserPaymentStatus(context.Background(), model.PaginationQuery{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetUserPaymentStatus() error = %v, want nil", err)
+	}
+
+	if len(result.Items) != len(tests) {
+		t.Fatalf("GetUserPaymentStatus() returned %d items, want %d", len(result.Items), len(tests))
+	}
+	for i, tt := range tests {
+		if got := result.Items[i].ExpectedFee; got != tt.want {
+			t.Errorf("ExpectedFee for %s = %d, want %d", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestGetMyTeamProfileIsComplete(t *testing.T) {
+	tests := []struct {
+		name       string
+		minMembers int
+		maxMembers int
+		members    int
+		want       bool
+	}{
+		{name: "no team size configured", minMembers: 0, maxMembers: 0, members: 0, want: false},
+		{name: "below the minimum", minMembers: 2, maxMembers: 3, members: 1, want: false},
+		{name: "at the minimum", minMembers: 2, maxMembers: 3, members: 2, want: true},
+		{name: "full team", minMembers: 2, maxMembers: 3, members: 3, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newUserServiceFixture(t)
+			f.competitions.competitions[1] = entity.Competition{CompetitionID: 1, CompetitionName: "Business Plan", MinMembers: tt.minMembers, MaxMembers: tt.maxMembers}
+			user := f.addUser(t, "leader@example.com", "password123")
+			team, _ := f.teams.GetTeamByUserID(nil, user.UserID)
+			for i := 0; i < tt.members; i++ {
+				f.teams.CreateTeamMember(nil, &entity.TeamMember{TeamID: team.TeamID, MemberName: fmt.Sprintf("Member %d", i)})
+			}
+			expectTransaction(f.mock, 1)
+
+			profile, err := f.service.GetMyTeamProfile(context.Background(), user.UserID)
+			if err != nil {
+				t.Fatalf("GetMyTeamProfile() error = %v, want nil", err)
+			}
+			if profile.IsComplete != tt.want {
+				t.Errorf("IsComplete = %v, want %v", profile.IsComplete, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetMyTeamProfileWithoutMembersMarshalsEmptyList(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	expectTransaction(f.mock, 1)
+
+	profile, err := f.service.GetMyTeamProfile(context.Background(), user.UserID)
+	if err != nil {
+		t.Fatalf("GetMyTeamProfile() error = %v, want nil", err)
+	}
+
+	body, err := json.Marshal(profile)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(body), `"members":[]`) {
+		t.Errorf("profile JSON = %s, want \"members\":[]", body)
+	}
+}
+
+func TestGetUserPaymentStatusWithoutUsersMarshalsEmptyList(t *testing.T) {
+	f := newUserServiceFixture(t)
+
+	result, err := f.service.GetUserPaymentStatus(context.Background(), model.PaginationQuery{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetUserPaymentStatus() error = %v, want nil", err)
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(body), `"items":[]`) {
+		t.Errorf("payment status JSON = %s, want \"items\":[]", body)
+	}
+}
+
+func TestVerifyUserOtpExpiry(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		wantErr error
+	}{
+		{name: "before expiry", elapsed: 10*time.Minute - time.Second},
+		{name: "after expiry", elapsed: 10*time.Minute + time.Second, wantErr: model.ErrOtpExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newUserServiceFixture(t)
+			expectTransaction(f.mock, 1)
+			f.mock.ExpectBegin()
+			if tt.wantErr == nil {
+				f.mock.ExpectCommit()
+			} else {
+				f.mock.ExpectRollback()
+			}
+
+			registered, err := f.service.Register(context.Background(), &model.UserRegister{
+				Email:        "leader@example.com",
+				Password:     "password123",
+				CaptchaToken: "token-1",
+			}, "")
+			if err != nil {
+				t.Fatalf("Register() error = %v, want nil", err)
+			}
+
+			f.clock.Advance(tt.elapsed)
+
+			err = f.service.VerifyUser(context.Background(), model.VerifyUser{
+				UserID:  registered.UserID,
+				OtpCode: "123456",
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyUser() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResetPasswordOtpExpiry(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		wantErr string
+	}{
+		{name: "before expiry", elapsed: 5*time.Minute - time.Second},
+		{name: "after expiry", elapsed: 5*time.Minute + time.Second, wantErr: "token expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newUserServiceFixture(t)
+			f.addUser(t, "leader@example.com", "password123")
+			expectTransaction(f.mock, 1)
+			f.mock.ExpectBegin()
+			if tt.wantErr == "" {
+				f.mock.ExpectCommit()
+			} else {
+				f.mock.ExpectRollback()
+			}
+
+			err := f.service.ChangePassword(context.Background(), "leader@example.com")
+			if err != nil {
+				t.Fatalf("ChangePassword() error = %v, want nil", err)
+			}
+
+			f.clock.Advance(tt.elapsed)
+
+			err = f.service.VerifyOtpChangePassword(context.Background(), model.VerifyToken{
+				Email: "leader@example.com",
+				OTP:   "123456",
+			})
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("VerifyOtpChangePassword() error = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("VerifyOtpChangePassword() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterIdempotencyKeyExpires(t *testing.T) {
+	f := newUserServiceFixture(t)
+	expectTransaction(f.mock, 1)
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+
+	_, err := f.service.Register(context.Background(), &model.UserRegister{
+		Email:        "leader@example.com",
+		Password:     "password123",
+		CaptchaToken: "token-1",
+	}, "key-1")
+	if err != nil {
+		t.Fatalf("first Register() error = %v, want nil", err)
+	}
+
+	f.clock.Advance(idempotencyKeyTTL + time.Minute)
+
+	// The key has expired, so the retry is a new signup for a taken email.
+	_, err = f.service.Register(context.Background(), &model.UserRegister{
+		Email:        "leader@example.com",
+		Password:     "password123",
+		CaptchaToken: "token-2",
+	}, "key-1")
+	if !errors.Is(err, model.ErrEmailAlreadyRegistered) {
+		t.Fatalf("Register() after the key expired error = %v, want %v", err, model.ErrEmailAlreadyRegistered)
+	}
+}
+
+func TestRequestEmailChangeNormalizesEmail(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	expectTransaction(f.mock, 1)
+
+	err := f.service.RequestEmailChange(context.Background(), user.UserID, "  New.Leader@Example.COM ")
+	if err != nil {
+		t.Fatalf("RequestEmailChange() error = %v, want nil", err)
+	}
+
+	if got := f.users.get(user.UserID).PendingEmail; got != "new.leader@example.com" {
+		t.Errorf("pending email = %q, want %q", got, "new.leader@example.com")
+	}
+	if sent := f.mailer.Sent(); len(sent) == 0 || sent[0].To != "new.leader@example.com" {
+		t.Errorf("verification email sent to %v, want new.leader@example.com", sent)
+	}
+}
+
+func TestConfirmEmailChangeSwapsEmail(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	expectTransaction(f.mock, 2)
+
+	err := f.service.RequestEmailChange(context.Background(), user.UserID, "new.leader@example.com")
+	if err != nil {
+		t.Fatalf("RequestEmailChange() error = %v, want nil", err)
+	}
+	err = f.service.ConfirmEmailChange(context.Background(), user.UserID, "123456")
+	if err != nil {
+		t.Fatalf("ConfirmEmailChange() error = %v, want nil", err)
+	}
+
+	updated := f.users.get(user.UserID)
+	if updated.Email != "new.leader@example.com" {
+		t.Errorf("email = %q, want %q", updated.Email, "new.leader@example.com")
+	}
+	if updated.PendingEmail != "" {
+		t.Errorf("pending email = %q, want it cleared", updated.PendingEmail)
+	}
+	if updated.TokensRevokedAt == nil {
+		t.Error("tokens issued before the change were not revoked")
+	}
+}
+
+func TestRequestEmailChangeTakenAddress(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	f.addUser(t, "taken@example.com", "password123")
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+
+	err := f.service.RequestEmailChange(context.Background(), user.UserID, "Taken@Example.com")
+	if !errors.Is(err, model.ErrEmailAlreadyRegistered) {
+		t.Fatalf("RequestEmailChange() error = %v, want %v", err, model.ErrEmailAlreadyRegistered)
+	}
+	if got := f.users.get(user.UserID).PendingEmail; got != "" {
+		t.Errorf("pending email = %q, want none", got)
+	}
+	if sent := f.mailer.Sent(); len(sent) != 0 {
+		t.Errorf("sent %d emails, want none", len(sent))
+	}
+}
+
+func TestConfirmEmailChangeAddressTakenMeanwhile(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	expectTransaction(f.mock, 1)
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+
+	err := f.service.RequestEmailChange(context.Background(), user.UserID, "new.leader@example.com")
+	if err != nil {
+		t.Fatalf("RequestEmailChange() error = %v, want nil", err)
+	}
+	// Someone else signs up with the address before the code is confirmed.
+	f.addUser(t, "new.leader@example.com", "password123")
+
+	err = f.service.ConfirmEmailChange(context.Background(), user.UserID, "123456")
+	if !errors.Is(err, model.ErrEmailAlreadyRegistered) {
+		t.Fatalf("ConfirmEmailChange() error = %v, want %v", err, model.ErrEmailAlreadyRegistered)
+	}
+	if got := f.users.get(user.UserID).Email; got != "leader@example.com" {
+		t.Errorf("email = %q, want it unchanged", got)
+	}
+}
+
+func registrationRequest() model.CompetitionRegistrationRequest {
+	return model.CompetitionRegistrationRequest{
+		FullName:      "Leader",
+		StudentNumber: "225150400111001",
+		University:    "Universitas Brawijaya",
+		Major:         "Sistem Informasi",
+		PhoneNumber:   "081234567890",
+	}
+}
+
+func TestCompetitionRegistrationAfterClose(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	closeAt := f.clock.Now().Add(-time.Minute)
+	f.competitions.competitions[1] = entity.Competition{CompetitionID: 1, CompetitionName: "Business Plan", IsRegistrationOpen: true, RegistrationClose: &closeAt}
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+
+	_, err := f.service.CompetitionRegistration(context.Background(), user.UserID, 1, registrationRequest())
+	if !errors.Is(err, model.ErrRegistrationClosed) {
+		t.Fatalf("CompetitionRegistration() error = %v, want %v", err, model.ErrRegistrationClosed)
+	}
+
+	team, _ := f.teams.GetTeamByUserID(nil, user.UserID)
+	if team.RegisteredAt != nil {
+		t.Error("the team was registered after registration closed")
+	}
+}
+
+func TestCompetitionRegistrationVerifiedTeamCannotSwitch(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	f.competitions.competitions[1] = entity.Competition{CompetitionID: 1, CompetitionName: "Business Plan", IsRegistrationOpen: true}
+	f.competitions.competitions[2] = entity.Competition{CompetitionID: 2, CompetitionName: "UI/UX", IsRegistrationOpen: true}
+
+	team, _ := f.teams.GetTeamByUserID(nil, user.UserID)
+	team.TeamStatus = "terverifikasi"
+	f.teams.put(team)
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+
+	_, err := f.service.CompetitionRegistration(context.Background(), user.UserID, 2, registrationRequest())
+	if !errors.Is(err, model.ErrCompetitionLocked) {
+		t.Fatalf("CompetitionRegistration() error = %v, want %v", err, model.ErrCompetitionLocked)
+	}
+
+	team, _ = f.teams.GetTeamByUserID(nil, user.UserID)
+	if team.CompetitionID != 1 {
+		t.Errorf("team competition = %d, want it to stay 1", team.CompetitionID)
+	}
+}
+
+func TestCompetitionRegistrationPaidTeamCannotSwitch(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	user.PaymentTransc = "https://files.example.com/payment.jpg"
+	f.users.put(user)
+	f.competitions.competitions[1] = entity.Competition{CompetitionID: 1, CompetitionName: "Business Plan", IsRegistrationOpen: true}
+	f.competitions.competitions[2] = entity.Competition{CompetitionID: 2, CompetitionName: "UI/UX", IsRegistrationOpen: true}
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+
+	_, err := f.service.CompetitionRegistration(context.Background(), user.UserID, 2, registrationRequest())
+	if !errors.Is(err, model.ErrCompetitionLocked) {
+		t.Fatalf("CompetitionRegistration() error = %v, want %v", err, model.ErrCompetitionLocked)
+	}
+}
+
+func TestVerifyUserAlreadyVerified(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	f.otps.CreateOtp(nil, &entity.OtpCode{
+		OtpID:   uuid.New(),
+		UserID:  user.UserID,
+		Code:    "123456",
+		Purpose: model.OtpPurposeVerify,
+	})
+	f.mock.ExpectBegin()
+	f.mock.ExpectRollback()
+
+	err := f.service.VerifyUser(context.Background(), model.VerifyUser{
+		UserID:  user.UserID,
+		OtpCode: "123456",
+	})
+	if !errors.Is(err, model.ErrAccountAlreadyVerified) {
+		t.Fatalf("VerifyUser() error = %v, want %v", err, model.ErrAccountAlreadyVerified)
+	}
+	if got := f.otps.count(); got != 1 {
+		t.Errorf("stored OTPs = %d, want the code left in place", got)
+	}
+}
+
+func TestRegisterUsesDefaultCompetition(t *testing.T) {
+	tests := []struct {
+		name          string
+		competitionID int
+		wantErr       bool
+	}{
+		{name: "configured competition exists", competitionID: 2},
+		{name: "configured competition missing", competitionID: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newUserServiceFixture(t)
+			f.service.cfg.App.DefaultCompetitionID = tt.competitionID
+			f.competitions.competitions[2] = entity.Competition{CompetitionID: 2, CompetitionName: "UI/UX"}
+			f.mock.ExpectBegin()
+			if tt.wantErr {
+				f.mock.ExpectRollback()
+			} else {
+				f.mock.ExpectCommit()
+			}
+
+			registered, err := f.service.Register(context.Background(), &model.UserRegister{
+				Email:        "leader@example.com",
+				Password:     "password123",
+				CaptchaToken: "token-1",
+			}, "")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Register() error = nil, want an error for the missing competition")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Register() error = %v, want nil", err)
+			}
+
+			team, err := f.teams.GetTeamByUserID(nil, registered.UserID)
+			if err != nil {
+				t.Fatalf("GetTeamByUserID() error = %v, want nil", err)
+			}
+			if team.CompetitionID != tt.competitionID {
+				t.Errorf("team competition = %d, want %d", team.CompetitionID, tt.competitionID)
+			}
+		})
+	}
+}
+
+func TestLoginRehashesWeakerPassword(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	f.service.BCrypt = bcrypt.Init(5)
+	expectTransaction(f.mock, 2)
+
+	_, err := f.service.Login(context.Background(), model.UserLogin{
+		Email:    "leader@example.com",
+		Password: "password123",
+	})
+	if err != nil {
+		t.Fatalf("Login() error = %v, want nil", err)
+	}
+
+	stored, err := f.users.GetUser(context.Background(), model.UserParam{UserID: user.UserID})
+	if err != nil {
+		t.Fatalf("GetUser() error = %v, want nil", err)
+	}
+	if stored.Password == user.Password {
+		t.Fatal("the cost 4 hash was not replaced")
+	}
+	if f.service.BCrypt.NeedsRehash(stored.Password) {
+		t.Error("the stored hash is still below the configured cost")
+	}
+	if err := f.service.BCrypt.CompareAndHashPassword(stored.Password, "password123"); err != nil {
+		t.Errorf("the new hash does not match the password: %v", err)
+	}
+	if err := f.mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChangePasswordAfterVerifyRejectsRecentPasswords(t *testing.T) {
+	f := newUserServiceFixture(t)
+	f.addUser(t, "leader@example.com", "password0")
+
+	reset := func(password string, wantErr error) {
+		t.Helper()
+
+		expectTransaction(f.mock, 1)
+		f.mock.ExpectBegin()
+		if wantErr == nil {
+			f.mock.ExpectCommit()
+		} else {
+			f.mock.ExpectRollback()
+		}
+
+		err := f.service.ChangePassword(context.Background(), "leader@example.com")
+		if err != nil {
+			t.Fatalf("ChangePassword() error = %v, want nil", err)
+		}
+
+		err = f.service.ChangePasswordAfterVerify(context.Background(), model.ResetPasswordRequest{
+			Email:           "leader@example.com",
+			OTP:             "123456",
+			NewPassword:     password,
+			ConfirmPassword: password,
+		})
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("ChangePasswordAfterVerify(%q) error = %v, want %v", password, err, wantErr)
+		}
+	}
+
+	reset("password1", nil)
+	reset("password2", nil)
+	reset("password3", nil)
+
+	// PASSWORD_HISTORY_SIZE is 3: the current password and the three before it
+	// are refused.
+	for _, password := range []string{"password3", "password2", "password1", "password0"} {
+		reset(password, model.ErrPasswordReused)
+	}
+
+	// One more change pushes password0 out of the history.
+	reset("password4", nil)
+	reset("password0", nil)
+
+	if err := f.mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterConcurrentSameEmail(t *testing.T) {
+	const signups = 8
+
+	f := newUserServiceFixture(t)
+	f.mock.MatchExpectationsInOrder(false)
+	for i := 0; i < signups; i++ {
+		f.mock.ExpectBegin()
+	}
+	f.mock.ExpectCommit()
+	for i := 1; i < signups; i++ {
+		f.mock.ExpectRollback()
+	}
+
+	errs := make(chan error, signups)
+	var wg sync.WaitGroup
+	for i := 0; i < signups; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			_, err := f.service.Register(context.Background(), &model.UserRegister{
+				Email:        "leader@example.com",
+				Password:     "password123",
+				CaptchaToken: fmt.Sprintf("token-%d", i),
+			}, "")
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	var succeeded int
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		} else if !errors.Is(err, model.ErrEmailAlreadyRegistered) {
+			t.Errorf("Register() error = %v, want nil or %v", err, model.ErrEmailAlreadyRegistered)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d registrations succeeded, want exactly 1", succeeded)
+	}
+	if err := f.mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompetitionRegistrationWithoutPhoneNumber(t *testing.T) {
+	f := newUserServiceFixture(t)
+	user := f.addUser(t, "leader@example.com", "password123")
+	user.PhoneNumber = "+6281234567890"
+	f.users.put(user)
+	f.competitions.competitions[1] = entity.Competition{CompetitionID: 1, CompetitionName: "Business Plan", IsRegistrationOpen: true}
+	expectTransaction(f.mock, 1)
+
+	param := registrationRequest()
+	param.PhoneNumber = ""
+
+	_, err := f.service.CompetitionRegistration(context.Background(), user.UserID, 1, param)
+	if err != nil {
+		t.Fatalf("CompetitionRegistration() error = %v, want nil", err)
+	}
+
+	stored, _ := f.users.GetUser(context.Background(), model.UserParam{UserID: user.UserID})
+	if stored.PhoneNumber != "+6281234567890" {
+		t.Errorf("PhoneNumber = %q, want the number on file kept", stored.PhoneNumber)
+	}
+}
@@ -7,12 +7,57 @@ import (
 
 var ErrUserRecordNotFound = errors.New("Not Found data user")
 
+// RequestAnnouncement creates or updates an announcement. A nil CompetitionID
+// targets every participant and a nil PublishedAt publishes it right away.
 type RequestAnnouncement struct {
-	Message string `json:"message" binding:"required"`
+	Title         string     `json:"title" binding:"max=255"`
+	Message       string     `json:"message" binding:"required"`
+	CompetitionID *int       `json:"competition_id"`
+	PublishedAt   *time.Time `json:"published_at"`
+}
+
+// AnnouncementQuery pages through announcements, optionally only those
+// published after Since, given in RFC 3339.
+type AnnouncementQuery struct {
+	PaginationQuery
+	Since *time.Time `form:"since"`
+}
+
+// UnreadAnnouncements counts the announcements published since the user last
+// marked them read. ReadAt is nil when they never have.
+type UnreadAnnouncements struct {
+	Unread int64      `json:"unread"`
+	ReadAt *time.Time `json:"read_at"`
 }
 
 type ResponseAnnouncement struct {
 	AnnouncementID string    `json:"id_announcement"`
+	Title          string    `json:"title"`
 	Message        string    `json:"message_announcement"`
+	CompetitionID  *int      `json:"competition_id"`
+	PublishedAt    time.Time `json:"published_at"`
 	Date           time.Time `json:"date_announcement"`
 }
+
+// BroadcastEmailRequest is an email to every participant of a competition.
+// Subject and Body are Go templates that can use {{.Name}}, {{.Email}} and
+// {{.TeamName}}; values are HTML-escaped in the body.
+type BroadcastEmailRequest struct {
+	Subject string `json:"subject" binding:"required,max=150"`
+	Body    string `json:"body" binding:"required"`
+}
+
+type BroadcastRecipient struct {
+	UserID   string
+	FullName string
+	Email    string
+	TeamName string
+}
+
+// BroadcastSummary reports how many emails were queued. Invalid lists addresses
+// that failed validation and Failed those the mail queue had no room for.
+type BroadcastSummary struct {
+	Queued  int      `json:"queued"`
+	Invalid []string `json:"invalid"`
+	Failed  []string `json:"failed"`
+}
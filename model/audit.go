@@ -0,0 +1,54 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditActionLogin          = "user.login"
+	AuditActionPasswordChange = "user.password_change"
+	AuditActionPaymentApprove = "team.payment_approve"
+	AuditActionPaymentReject  = "team.payment_reject"
+	AuditActionRoleChange     = "user.role_change"
+	AuditActionRestore        = "user.restore"
+
+	AuditActionSubmissionStatus = "submission.status_change"
+	AuditActionSubmissionGrade  = "submission.grade"
+	AuditActionTeamCompetition  = "team.competition_change"
+	AuditActionWaitlistPromote  = "team.waitlist_promote"
+
+	AuditActionCompetitionFee          = "competition.fee_change"
+	AuditActionCompetitionCapacity     = "competition.capacity_change"
+	AuditActionCompetitionRegistration = "competition.registration_change"
+	AuditActionCompetitionExtraFields  = "competition.extra_fields_change"
+)
+
+// AuditEntry is what a service records about an action. Metadata is stored
+// as JSON.
+type AuditEntry struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	Metadata   map[string]interface{}
+}
+
+// AuditLogQuery filters the audit log by actor ID and action, both optional.
+type AuditLogQuery struct {
+	PaginationQuery
+	ActorID string `form:"actor_id" binding:"omitempty,uuid"`
+	Action  string `form:"action"`
+}
+
+type AuditLogResponse struct {
+	AuditLogID string          `json:"audit_log_id"`
+	ActorID    *uuid.UUID      `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Metadata   json.RawMessage `json:"metadata"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
@@ -1,7 +1,85 @@
 package model
 
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrRegistrationNotOpen = errors.New("registration has not opened yet")
+	ErrRegistrationClosed  = errors.New("registration is closed")
+	ErrRegistrationPaused  = errors.New("registration is currently closed")
+	ErrCompetitionLocked   = errors.New("competition cannot be changed after the team is verified or payment has been submitted, please contact the committee")
+	ErrTeamWaitlisted      = errors.New("your team is on the waitlist, payment opens once a slot frees up")
+)
+
 type GetAllCompetitionsResponse struct {
-	CompetitionID   int    `json:"competition_id"`
-	CompetitionName string `json:"competition_name"`
-	Description     string `json:"description"`
+	CompetitionID     int        `json:"competition_id"`
+	CompetitionName   string     `json:"competition_name"`
+	Category          string     `json:"category"`
+	Description       string     `json:"description"`
+	MinMembers        int        `json:"min_members"`
+	MaxMembers        int        `json:"max_members"`
+	Fee               int        `json:"fee"`
+	Capacity          int        `json:"capacity"`
+	RegistrationOpen  *time.Time `json:"registration_open"`
+	RegistrationClose *time.Time `json:"registration_close"`
+	IsRegistrationOn  bool       `json:"is_registration_on"`
+}
+
+type GetCompetitionResponse struct {
+	CompetitionID     int          `json:"competition_id"`
+	CompetitionName   string       `json:"competition_name"`
+	Category          string       `json:"category"`
+	Description       string       `json:"description"`
+	MinMembers        int          `json:"min_members"`
+	MaxMembers        int          `json:"max_members"`
+	Fee               int          `json:"fee"`
+	Capacity          int          `json:"capacity"`
+	Deadline          time.Time    `json:"deadline"`
+	RegistrationOpen  *time.Time   `json:"registration_open"`
+	RegistrationClose *time.Time   `json:"registration_close"`
+	IsRegistrationOn  bool         `json:"is_registration_on"`
+	ExtraFields       []ExtraField `json:"extra_fields"`
+}
+
+// ExtraField describes a registration field that only some competitions ask
+// for, such as a portfolio link. Type is "text" or "url", and MaxLength
+// defaults to DefaultExtraFieldMaxLength.
+type ExtraField struct {
+	Name      string `json:"name" binding:"required,max=50"`
+	Label     string `json:"label" binding:"required,max=100"`
+	Type      string `json:"type" binding:"omitempty,oneof=text url"`
+	Required  bool   `json:"required"`
+	MaxLength int    `json:"max_length" binding:"omitempty,min=1,max=2000"`
+}
+
+const DefaultExtraFieldMaxLength = 255
+
+type ReqUpdateExtraFields struct {
+	ExtraFields []ExtraField `json:"extra_fields" binding:"dive"`
+}
+
+type ReqUpdateCompetitionFee struct {
+	Fee int `json:"fee" binding:"min=0"`
+}
+
+// ReqUpdateCompetitionCapacity sets how many teams may register. Zero means no
+// limit.
+type ReqUpdateCompetitionCapacity struct {
+	Capacity int `json:"capacity" binding:"min=0"`
+}
+
+type ReqUpdateRegistrationStatus struct {
+	IsRegistrationOpen *bool `json:"is_registration_open" binding:"required"`
+}
+
+// StageSchedule is one stage of a competition's schedule. Deadline is given in
+// the configured TIMEZONE.
+type StageSchedule struct {
+	StageID    int       `json:"stage_id"`
+	StageName  string    `json:"stage_name"`
+	StageOrder int       `json:"stage_order"`
+	Deadline   time.Time `json:"deadline"`
+	Timezone   string    `json:"timezone"`
 }
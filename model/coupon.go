@@ -0,0 +1,34 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrCouponNotFound            = errors.New("coupon not found")
+	ErrCouponExpired             = errors.New("coupon has expired")
+	ErrCouponExhausted           = errors.New("coupon has no uses left")
+	ErrCouponCompetitionMismatch = errors.New("coupon is not valid for this competition")
+	ErrCouponAlreadyApplied      = errors.New("team has already used a different coupon")
+	ErrCouponCodeTaken           = errors.New("coupon code already exists")
+)
+
+// CreateCouponRequest needs exactly one of DiscountPercent and DiscountAmount.
+type CreateCouponRequest struct {
+	Code            string     `json:"code" binding:"required,max=30"`
+	CompetitionID   *int       `json:"competition_id"`
+	DiscountPercent int        `json:"discount_percent" binding:"min=0,max=100"`
+	DiscountAmount  int        `json:"discount_amount" binding:"min=0"`
+	UsageLimit      int        `json:"usage_limit" binding:"required,min=1"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+}
+
+type CouponResult struct {
+	Code          string `json:"code"`
+	CompetitionID int    `json:"competition_id"`
+	OriginalFee   int    `json:"original_fee"`
+	Discount      int    `json:"discount"`
+	FinalFee      int    `json:"final_fee"`
+	RemainingUses int    `json:"remaining_uses"`
+}
@@ -1,10 +1,16 @@
 package model
 
 import (
+	"errors"
 	"mime/multipart"
 	"net/http"
 )
 
+var (
+	ErrInvalidImage        = errors.New("file is not a valid image")
+	ErrUnsupportedFileType = errors.New("file must be a JPEG, PNG or PDF")
+)
+
 type Image struct {
 	File *multipart.FileHeader `form:"file" validate:"required, image_type,image_size"`
 }
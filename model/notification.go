@@ -0,0 +1,26 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+const (
+	NotificationPaymentStatus    = "payment_status"
+	NotificationSubmissionStatus = "submission_status"
+	NotificationGrade            = "grade"
+	NotificationDeadlineReminder = "deadline_reminder"
+	NotificationWaitlist         = "waitlist"
+)
+
+type NotificationResponse struct {
+	NotificationID uuid.UUID  `json:"notification_id"`
+	Type           string     `json:"type"`
+	Message        string     `json:"message"`
+	ReadAt         *time.Time `json:"read_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -1,9 +1,40 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OTP purposes keep the codes of different flows apart, so a user can have a
+// verification code and a password reset code at the same time.
+const (
+	OtpPurposeVerify      = "verify"
+	OtpPurposeReset       = "reset"
+	OtpPurposeEmailChange = "email-change"
+)
 
 type GetOtp struct {
-	OtpID  uuid.UUID `json:"otp_id"`
-	UserID uuid.UUID `json:"user_id"`
-	Code   string    `json:"code"`
+	OtpID   uuid.UUID `json:"otp_id"`
+	UserID  uuid.UUID `json:"user_id"`
+	Code    string    `json:"code"`
+	Purpose string    `json:"-"`
+}
+
+// CooldownError is returned when an email, such as an OTP, is requested again
+// before its cooldown has passed.
+type CooldownError struct {
+	Action    string
+	Cooldown  time.Duration
+	Remaining time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("you can only %s every %d minutes, try again in %d seconds", e.Action, int(e.Cooldown.Minutes()), e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds rounds the remaining cooldown up so clients never retry too early.
+func (e *CooldownError) RetryAfterSeconds() int {
+	return int((e.Remaining + time.Second - 1) / time.Second)
 }
@@ -0,0 +1,60 @@
+package model
+
+const (
+	defaultPageLimit = 10
+	maxPageLimit     = 100
+)
+
+// PaginationQuery is bound from the page and limit query parameters.
+type PaginationQuery struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1"`
+}
+
+// Normalize fills in the defaults and caps the limit, so an out-of-range page
+// or limit is clamped rather than rejected.
+func (p *PaginationQuery) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+
+	if p.Limit < 1 {
+		p.Limit = defaultPageLimit
+	} else if p.Limit > maxPageLimit {
+		p.Limit = maxPageLimit
+	}
+}
+
+func (p PaginationQuery) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// Paginated is the envelope of every list response.
+type Paginated[T any] struct {
+	Items      []T   `json:"items"`
+	Page       int   `json:"page"`
+	Size       int   `json:"size"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// NewPaginated wraps one page of items fetched with a normalized page query.
+// Items is never nil, so an empty page is encoded as [].
+func NewPaginated[T any](items []T, page PaginationQuery, total int64) *Paginated[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	totalPages := 0
+	if page.Limit > 0 {
+		totalPages = int((total + int64(page.Limit) - 1) / int64(page.Limit))
+	}
+
+	return &Paginated[T]{
+		Items:      items,
+		Page:       page.Page,
+		Size:       page.Limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRoleNotFound  = errors.New("role not found")
+	ErrOwnRoleChange = errors.New("admins cannot change their own role")
+)
+
+type RoleResponse struct {
+	RoleID      int      `json:"role_id"`
+	RoleName    string   `json:"role_name"`
+	Permissions []string `json:"permissions"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required,max=20"`
+}
+
+// UserRole is a user together with the name of their role.
+type UserRole struct {
+	UserID   uuid.UUID `json:"user_id"`
+	FullName string    `json:"full_name"`
+	Email    string    `json:"email"`
+	RoleID   int       `json:"role_id"`
+	RoleName string    `json:"role_name"`
+}
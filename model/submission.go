@@ -49,3 +49,61 @@ type ResCurrentSubmission struct {
 type RequestUpdateStatusSubmission struct {
 	SubmissionStatus string `json:"submission_status" binding:"oneof='diproses' 'lolos' 'tidak lolos'"`
 }
+
+// StageProgress is one stage of a team's competition with what the team
+// submitted for it. SubmittedAt and OnTime are nil when nothing was submitted.
+type StageProgress struct {
+	StageID       int        `json:"stage_id"`
+	StageName     string     `json:"stage_name"`
+	StageOrder    int        `json:"stage_order"`
+	Deadline      time.Time  `json:"deadline"`
+	SubmissionURL string     `json:"submission_url"`
+	Status        string     `json:"status"`
+	SubmittedAt   *time.Time `json:"submitted_at"`
+	OnTime        *bool      `json:"on_time"`
+}
+
+// GradeSubmissionRequest scores a stage submission. The team leader is emailed
+// the score and feedback when Notify is set.
+type GradeSubmissionRequest struct {
+	Score    *float64 `json:"score" binding:"required"`
+	Feedback string   `json:"feedback" binding:"max=2000"`
+	Notify   bool     `json:"notify"`
+}
+
+type StageLeaderboardEntry struct {
+	Rank           int       `json:"rank"`
+	TeamProgressID int       `json:"team_progress_id"`
+	TeamID         string    `json:"team_id"`
+	TeamName       string    `json:"team_name"`
+	Score          float64   `json:"score"`
+	Status         string    `json:"status"`
+	SubmittedAt    time.Time `json:"submitted_at"`
+}
+
+// LeaderboardQuery pages the competition leaderboard. With CompletedOnly set,
+// only stages whose deadline has passed are counted.
+type LeaderboardQuery struct {
+	PaginationQuery
+	CompletedOnly bool `form:"completed_only"`
+}
+
+type LeaderboardEntry struct {
+	Rank            int       `json:"rank"`
+	TeamID          string    `json:"team_id"`
+	TeamName        string    `json:"team_name"`
+	TotalScore      float64   `json:"total_score"`
+	GradedStages    int       `json:"graded_stages"`
+	LastSubmittedAt time.Time `json:"last_submitted_at"`
+}
+
+type DeadlineReminder struct {
+	TeamID    uuid.UUID
+	TeamName  string
+	UserID    uuid.UUID
+	FullName  string
+	Email     string
+	StageID   int
+	StageName string
+	Deadline  time.Time
+}
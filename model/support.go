@@ -0,0 +1,14 @@
+package model
+
+import "github.com/google/uuid"
+
+// SupportMessage is a message from a participant to the organizers. UserID
+// and IPAddress are filled in by the handler, not by the client.
+type SupportMessage struct {
+	UserID    *uuid.UUID `json:"-"`
+	IPAddress string     `json:"-"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email" binding:"required,email"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+}
@@ -1,11 +1,64 @@
 package model
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrNoTeam             = errors.New("user does not have a team")
+	ErrTeamNameTaken      = errors.New("team name is already used in this competition")
+	ErrTeamNameNotAllowed = errors.New("team name contains a word that is not allowed")
+
+	ErrTeamNotFound           = errors.New("team not found")
+	ErrNoPaymentProof         = errors.New("team has not uploaded a payment proof")
+	ErrPaymentAlreadyApproved = errors.New("payment is already approved")
+	ErrPaymentNotApproved     = errors.New("payment has not been approved yet")
+)
+
+// TeamSizeError is returned when a team has more members than its competition
+// allows, or fewer than it needs to submit. Counts exclude the leader.
+type TeamSizeError struct {
+	Members int
+	Min     int
+	Max     int
+}
+
+func (e *TeamSizeError) Error() string {
+	if e.Members > e.Max {
+		return fmt.Sprintf("this competition allows at most %d team members besides the leader, got %d", e.Max, e.Members)
+	}
+
+	return fmt.Sprintf("this competition needs at least %d team members besides the leader, the team has %d", e.Min, e.Members)
+}
+
+// StudentNumberConflict is a team that already has a student as its leader
+// or as a member.
+type StudentNumberConflict struct {
+	StudentNumber string
+	TeamID        uuid.UUID
+	TeamName      string
+}
+
+// DuplicateMemberError is returned when a team lists a student who already
+// belongs to another team in the same competition.
+type DuplicateMemberError struct {
+	StudentNumber string
+	TeamID        uuid.UUID
+	TeamName      string
+}
+
+func (e *DuplicateMemberError) Error() string {
+	if e.TeamName == "" {
+		return fmt.Sprintf("student number %s is already registered in another team of this competition", e.StudentNumber)
+	}
+
+	return fmt.Sprintf("student number %s is already registered in team %q", e.StudentNumber, e.TeamName)
+}
+
 type AddTeamMemberRequest struct {
 	MemberName string    `json:"member_name" binding:"required"`
 	TeamID     uuid.UUID `json:"team_id"`
@@ -16,6 +69,14 @@ type UpsertTeamRequest struct {
 	Members  []TeamMemberRequest `json:"members" binding:"required"`
 }
 
+type SetTeamNameRequest struct {
+	TeamName string `json:"team_name" binding:"required"`
+}
+
+type SetTeamNameResponse struct {
+	TeamName string `json:"team_name"`
+}
+
 type UpsertTeamResponse struct {
 	TeamName string              `json:"team_name" binding:"required"`
 	Members  []TeamMemberRequest `json:"members" binding:"required"`
@@ -53,7 +114,11 @@ type GetTeamMembers struct {
 
 type ReqUpdateStatusTeam struct {
 	TeamID        string `json:"team_id"`
-	PaymentStatus string `json:"payment_status" binding:"oneof='belum terverifikasi' 'terverifikasi'"`
+	PaymentStatus string `json:"payment_status" binding:"oneof='belum terverifikasi' 'terverifikasi' 'ditolak'"`
+}
+
+type ReqUpdateTeamCompetition struct {
+	CompetitionID int `json:"competition_id" binding:"required"`
 }
 
 type TeamInfoResponseAdmin struct {
@@ -88,3 +153,52 @@ type Stages struct {
 	GdriveLink string    `json:"link_submission"`
 	Status     string    `json:"status_submission"`
 }
+
+// WebhookEventTeamStatusChanged is sent to WEBHOOK_URL whenever an admin sets
+// a team's payment status.
+const WebhookEventTeamStatusChanged = "team.status_changed"
+
+type TeamStatusWebhook struct {
+	Event         string    `json:"event"`
+	TeamID        uuid.UUID `json:"team_id"`
+	Status        string    `json:"status"`
+	CompetitionID int       `json:"competition_id"`
+	Competition   string    `json:"competition"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+type BulkApprovePaymentsRequest struct {
+	TeamIDs []uuid.UUID `json:"team_ids" binding:"required,min=1,max=200"`
+}
+
+// BulkResult reports which IDs of a bulk action succeeded and why the others
+// failed.
+type BulkResult struct {
+	Succeeded []uuid.UUID   `json:"succeeded"`
+	Failed    []BulkFailure `json:"failed"`
+}
+
+// WaitlistPromotionResult lists the teams that were given a slot, in the order
+// they joined the waitlist.
+type WaitlistPromotionResult struct {
+	Promoted []uuid.UUID `json:"promoted"`
+}
+
+type BulkFailure struct {
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
+}
+
+// PaymentReviewTeam is a team with what an admin needs to decide on its
+// payment and notify its leader.
+type PaymentReviewTeam struct {
+	TeamID          uuid.UUID
+	TeamStatus      string
+	CompetitionID   int
+	CompetitionName string
+	UserID          uuid.UUID
+	FullName        string
+	Email           string
+	PhoneNumber     string
+	PaymentTransc   string
+}
@@ -1,28 +1,109 @@
 package model
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrEmailRegisteredWithPassword = errors.New("email is already registered with a password, please login with your password")
+	ErrEmailAlreadyRegistered      = errors.New("email already registered")
+	ErrEmailManagedByGoogle        = errors.New("the email of a google account can't be changed")
+	ErrSameEmail                   = errors.New("new email is the same as the current email")
+	ErrNoPendingEmailChange        = errors.New("there is no pending email change")
+	ErrInvalidOtpCode              = errors.New("invalid otp code")
+	ErrOtpExpired                  = errors.New("otp expired")
+	ErrInvalidVerificationLink     = errors.New("verification link is invalid or has already been used")
+	ErrAccountAlreadyVerified      = errors.New("account already verified")
+	ErrPasswordReused              = errors.New("new password cannot be same as a recent password")
+	ErrAccountNotVerified          = errors.New("please verify your email before logging in")
+)
+
+// UnverifiedAccountError is returned when an inactive account logs in with the
+// right password. UserID is what the client needs to resend the OTP and verify.
+type UnverifiedAccountError struct {
+	UserID uuid.UUID
+}
+
+func (e *UnverifiedAccountError) Error() string {
+	return ErrAccountNotVerified.Error()
+}
+
+func (e *UnverifiedAccountError) Unwrap() error {
+	return ErrAccountNotVerified
+}
+
 type UserRegister struct {
-	Email           string `json:"email" binding:"required,email"`
-	Password        string `json:"password" binding:"required,min=8"`
-	ConfirmPassword string `json:"confirm_password" binding:"required"`
+	Email           string `json:"email" binding:"required,email,max=50"`
+	Password        string `json:"password" binding:"required,min=8,max=72"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
+	CaptchaToken    string `json:"captcha_token"`
+	IPAddress       string `json:"-"`
+}
+
+type EmailAvailabilityQuery struct {
+	Email string `form:"email" binding:"required,email,max=50"`
 }
 
+type EmailAvailabilityResponse struct {
+	Email     string `json:"email"`
+	Available bool   `json:"available"`
+}
+
+// RegisterResponse carries no token: the account is inactive until the email
+// is verified, and the client needs only the user ID to verify or resend the
+// OTP. A replayed Idempotency-Key gets the same response.
 type RegisterResponse struct {
-	Token string `json:"token"`
+	UserID uuid.UUID `json:"user_id"`
 }
 
 type UserLogin struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email,max=50"`
+	Password   string `json:"password" binding:"required,max=72"`
+	RememberMe bool   `json:"remember_me"`
+	// CaptchaToken is only checked when CAPTCHA_ON_LOGIN is set.
+	CaptchaToken string `json:"captcha_token"`
+	LoginClient
 }
 
+type GoogleLoginRequest struct {
+	IDToken    string `json:"id_token" binding:"required"`
+	RememberMe bool   `json:"remember_me"`
+	LoginClient
+}
+
+// LoginClient describes where a login request came from. It is filled in by
+// the handler, never from the request body.
+type LoginClient struct {
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// LoginEvent is one entry of a user's login history.
+type LoginEvent struct {
+	LoggedInAt time.Time `json:"logged_in_at"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+}
+
+// LoginResponse carries a session token. It expires after JWT_EXP_TIME hours,
+// or after JWT_REMEMBER_ME_EXP_TIME hours when the login asked to be remembered;
+// ExpiresAt tells the client which one applies.
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      LoginUser `json:"user"`
+}
+
+type LoginUser struct {
+	UserID        uuid.UUID `json:"user_id"`
+	FullName      string    `json:"full_name"`
+	Email         string    `json:"email"`
+	RoleID        int       `json:"role_id"`
+	RoleName      string    `json:"role_name"`
+	StatusAccount string    `json:"status_account"`
 }
 
 type UserProfile struct {
@@ -31,13 +112,26 @@ type UserProfile struct {
 	University    string `json:"university"`
 	Major         string `json:"major"`
 	Email         string `json:"email"`
+	PhoneNumber   string `json:"phone_number"`
+}
+
+// CompetitionRegistrationResponse tells the team whether it got a slot or was
+// put on the waitlist of a full competition, and where in the line it is.
+type CompetitionRegistrationResponse struct {
+	Waitlisted       bool `json:"waitlisted"`
+	WaitlistPosition int  `json:"waitlist_position,omitempty"`
 }
 
 type CompetitionRegistrationRequest struct {
-	FullName      string `json:"full_name" binding:"required"`
-	StudentNumber string `json:"student_number" binding:"required"`
-	University    string `json:"university" binding:"required"`
-	Major         string `json:"major" binding:"required"`
+	FullName      string `json:"full_name" binding:"required,max=70"`
+	StudentNumber string `json:"student_number" binding:"required,max=20"`
+	University    string `json:"university" binding:"required,max=80"`
+	Major         string `json:"major" binding:"required,max=80"`
+	PhoneNumber   string `json:"phone_number" binding:"max=20"`
+	CouponCode    string `json:"coupon_code" binding:"max=30"`
+
+	// ExtraFields holds the values of the competition's extra fields, by name.
+	ExtraFields map[string]string `json:"extra_fields"`
 }
 
 type UpdateProfile struct {
@@ -60,28 +154,49 @@ type VerifyUser struct {
 	OtpCode string    `json:"otp_code" binding:"required"`
 }
 
+type RequestEmailChange struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+}
+
+type ConfirmEmailChange struct {
+	OtpCode string `json:"otp_code" binding:"required"`
+}
+
 type ForgotPasswordRequest struct {
 	Email string `json:"email" binding:"required,email"`
 }
 
+// VerifyToken checks a reset code without using it up, so the client can
+// validate the code before asking for the new password.
 type VerifyToken struct {
-	UserID uuid.UUID `json:"user_id"`
-	OTP    string    `json:"otp" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+	OTP   string `json:"otp" binding:"required"`
 }
 
+// ResetPasswordRequest carries the reset code again, since the reset itself is
+// what consumes it.
 type ResetPasswordRequest struct {
-	UserID          uuid.UUID `json:"user_id"`
-	NewPassword     string    `json:"new_password" binding:"required,min=8"`
-	ConfirmPassword string    `json:"confirm_password" binding:"required,min=8"`
+	Email           string `json:"email" binding:"required,email"`
+	OTP             string `json:"otp" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,min=8"`
 }
 
 type UserTeamProfile struct {
 	LeaderName          string           `json:"leader_name"`
 	TeamName            string           `json:"team_name"`
 	StudentNumber       string           `json:"student_number"`
+	PhoneNumber         string           `json:"phone_number"`
 	CompetitionCategory string           `json:"competition_category"`
 	Deadline            time.Time        `json:"deadline"`
+	TeamStatus          string           `json:"team_status"`
+	PaymentUploaded     bool             `json:"payment_uploaded"`
+	MemberCount         int              `json:"member_count"`
+	MinMembers          int              `json:"min_members"`
+	MaxMembers          int              `json:"max_members"`
+	IsComplete          bool             `json:"is_complete"`
 	Members             []MemberResponse `json:"members"`
+	UnreadNotifications int64            `json:"unread_notifications"`
 }
 
 type MemberResponse struct {
@@ -97,9 +212,73 @@ type GetUserPaymentStatus struct {
 	TeamName        string `json:"team_name"`
 	TeamStatus      string `json:"team_status"`
 	CompetitionName string `json:"competition_name"`
+	ExpectedFee     int    `json:"expected_fee"`
 }
 
 type GetTotalParticipant struct {
 	TotalUIUX int `json:"total_uiux"`
 	TotalBP   int `json:"total_bp"`
 }
+
+// UserDataExport is everything kept about a participant, for them to
+// download. Team is nil when they have no team.
+type UserDataExport struct {
+	ExportedAt  time.Time           `json:"exported_at"`
+	Profile     UserDataProfile     `json:"profile"`
+	Team        *UserDataTeam       `json:"team"`
+	Submissions []SubmissionHistory `json:"submissions"`
+	Logins      []UserDataLogin     `json:"logins"`
+}
+
+type UserDataProfile struct {
+	UserID          uuid.UUID  `json:"user_id"`
+	FullName        string     `json:"full_name"`
+	Email           string     `json:"email"`
+	PendingEmail    string     `json:"pending_email"`
+	PhoneNumber     string     `json:"phone_number"`
+	StudentNumber   string     `json:"student_number"`
+	University      string     `json:"university"`
+	Major           string     `json:"major"`
+	StudentCardLink string     `json:"student_card_link"`
+	PaymentProof    string     `json:"payment_proof"`
+	AuthProvider    string     `json:"auth_provider"`
+	StatusAccount   string     `json:"status_account"`
+	RoleName        string     `json:"role_name"`
+	LastLoginAt     *time.Time `json:"last_login_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type UserDataTeam struct {
+	TeamID          uuid.UUID         `json:"team_id"`
+	TeamName        string            `json:"team_name"`
+	TeamStatus      string            `json:"team_status"`
+	PaymentUploaded bool              `json:"payment_uploaded"`
+	CompetitionID   int               `json:"competition_id"`
+	CompetitionName string            `json:"competition_name"`
+	ExtraFields     map[string]string `json:"extra_fields"`
+	Members         []MemberResponse  `json:"members"`
+}
+
+// SubmissionHistory is one submission of a team, with its stage.
+type SubmissionHistory struct {
+	TeamProgressID int        `json:"team_progress_id"`
+	StageID        int        `json:"stage_id"`
+	StageName      string     `json:"stage_name"`
+	StageOrder     int        `json:"stage_order"`
+	Status         string     `json:"status"`
+	GdriveLink     string     `json:"gdrive_link"`
+	Score          *float64   `json:"score"`
+	Feedback       string     `json:"feedback"`
+	GradedAt       *time.Time `json:"graded_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// UserDataLogin is an IP address the account recently signed in from.
+type UserDataLogin struct {
+	IPAddress   string    `json:"ip_address"`
+	UserAgent   string    `json:"user_agent"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
@@ -0,0 +1,293 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	studentNumberPattern  = regexp.MustCompile(`^[0-9A-Z]{5,20}$`)
+	phoneNumberPattern    = regexp.MustCompile(`^\+628[1-9][0-9]{6,10}$`)
+	extraFieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+	ErrDuplicateStudentNumber = errors.New("student number is used more than once in the team")
+)
+
+// ValidationErrors maps a request field name to the reason it was rejected.
+type ValidationErrors map[string]string
+
+func (v ValidationErrors) Error() string {
+	fields := make([]string, 0, len(v))
+	for field := range v {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field, v[field]))
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+func (v ValidationErrors) maxLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		v[field] = fmt.Sprintf("must be at most %d characters", max)
+	}
+}
+
+func (v ValidationErrors) required(field, value string) {
+	if value == "" {
+		v[field] = "is required"
+	}
+}
+
+// teamName checks a normalized team name. An empty name is left to required.
+func (v ValidationErrors) teamName(field, value string) {
+	v.maxLength(field, value, 50)
+	if value != "" && utf8.RuneCountInString(value) < 3 {
+		v[field] = "must be at least 3 characters"
+	}
+}
+
+func (v ValidationErrors) studentNumber(field, value string) {
+	if value != "" && !studentNumberPattern.MatchString(value) {
+		v[field] = "must be 5-20 letters or digits"
+	}
+}
+
+func (v ValidationErrors) phoneNumber(field, value string) {
+	if value != "" && !phoneNumberPattern.MatchString(value) {
+		v[field] = "must be a valid Indonesian mobile number"
+	}
+}
+
+func (v ValidationErrors) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	return v
+}
+
+// NormalizeStudentNumber uppercases a student number and removes all whitespace
+// so the same student is always stored in one canonical form.
+func NormalizeStudentNumber(studentNumber string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(studentNumber), ""))
+}
+
+// NormalizeEmail trims an email and lowercases it.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizePhoneNumber converts the 08..., 628... and +628... forms of an
+// Indonesian mobile number to +628... and drops common separators.
+func NormalizePhoneNumber(phoneNumber string) string {
+	phoneNumber = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(phoneNumber)
+
+	switch {
+	case strings.HasPrefix(phoneNumber, "+62"):
+		return phoneNumber
+	case strings.HasPrefix(phoneNumber, "62"):
+		return "+" + phoneNumber
+	case strings.HasPrefix(phoneNumber, "0"):
+		return "+62" + phoneNumber[1:]
+	}
+
+	return phoneNumber
+}
+
+// Validate trims every field and checks it against the users table column sizes.
+func (p *UpdateProfile) Validate() error {
+	p.FullName = strings.TrimSpace(p.FullName)
+	p.StudentNumber = NormalizeStudentNumber(p.StudentNumber)
+	p.University = strings.TrimSpace(p.University)
+	p.Major = strings.TrimSpace(p.Major)
+	p.PhoneNumber = NormalizePhoneNumber(p.PhoneNumber)
+
+	errs := ValidationErrors{}
+	errs.maxLength("full_name", p.FullName, 70)
+	errs.maxLength("student_number", p.StudentNumber, 20)
+	errs.studentNumber("student_number", p.StudentNumber)
+	errs.maxLength("university", p.University, 80)
+	errs.maxLength("major", p.Major, 80)
+	errs.maxLength("phone_number", p.PhoneNumber, 20)
+	errs.phoneNumber("phone_number", p.PhoneNumber)
+
+	return errs.err()
+}
+
+// Validate trims every field, requires all of them except the phone number and
+// coupon code to be present and checks them against the users table column
+// sizes.
+func (p *CompetitionRegistrationRequest) Validate() error {
+	p.FullName = strings.TrimSpace(p.FullName)
+	p.StudentNumber = NormalizeStudentNumber(p.StudentNumber)
+	p.University = strings.TrimSpace(p.University)
+	p.Major = strings.TrimSpace(p.Major)
+	p.PhoneNumber = NormalizePhoneNumber(p.PhoneNumber)
+	p.CouponCode = NormalizeCouponCode(p.CouponCode)
+
+	errs := ValidationErrors{}
+	errs.required("full_name", p.FullName)
+	errs.maxLength("full_name", p.FullName, 70)
+	errs.required("student_number", p.StudentNumber)
+	errs.maxLength("student_number", p.StudentNumber, 20)
+	errs.studentNumber("student_number", p.StudentNumber)
+	errs.required("university", p.University)
+	errs.maxLength("university", p.University, 80)
+	errs.required("major", p.Major)
+	errs.maxLength("major", p.Major, 80)
+	errs.maxLength("phone_number", p.PhoneNumber, 20)
+	errs.phoneNumber("phone_number", p.PhoneNumber)
+
+	return errs.err()
+}
+
+// Validate trims every field and checks it against the support_messages
+// column sizes.
+func (p *SupportMessage) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Email = strings.TrimSpace(p.Email)
+	p.Subject = strings.TrimSpace(p.Subject)
+	p.Body = strings.TrimSpace(p.Body)
+
+	errs := ValidationErrors{}
+	errs.maxLength("name", p.Name, 70)
+	errs.maxLength("email", p.Email, 50)
+	errs.required("subject", p.Subject)
+	errs.maxLength("subject", p.Subject, 150)
+	errs.required("body", p.Body)
+	errs.maxLength("body", p.Body, 5000)
+
+	return errs.err()
+}
+
+// NormalizeTeamName trims a team name and collapses runs of whitespace.
+func NormalizeTeamName(teamName string) string {
+	return strings.Join(strings.Fields(teamName), " ")
+}
+
+// Validate normalizes the team name and checks it against the teams table
+// column size.
+func (p *SetTeamNameRequest) Validate() error {
+	p.TeamName = NormalizeTeamName(p.TeamName)
+
+	errs := ValidationErrors{}
+	errs.required("team_name", p.TeamName)
+	errs.teamName("team_name", p.TeamName)
+
+	return errs.err()
+}
+
+// NormalizeCouponCode uppercases a coupon code and trims surrounding whitespace.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate normalizes the code and checks that exactly one kind of discount is set.
+func (p *CreateCouponRequest) Validate() error {
+	p.Code = NormalizeCouponCode(p.Code)
+
+	errs := ValidationErrors{}
+	errs.required("code", p.Code)
+	if (p.DiscountPercent > 0) == (p.DiscountAmount > 0) {
+		errs["discount_percent"] = "set either discount_percent or discount_amount"
+	}
+
+	return errs.err()
+}
+
+// Validate normalizes every member's student number and checks its format.
+func (p *UpsertTeamRequest) Validate() error {
+	p.TeamName = NormalizeTeamName(p.TeamName)
+
+	errs := ValidationErrors{}
+	errs.teamName("team_name", p.TeamName)
+	for i := range p.Members {
+		p.Members[i].Name = strings.TrimSpace(p.Members[i].Name)
+		p.Members[i].StudentNumber = NormalizeStudentNumber(p.Members[i].StudentNumber)
+
+		field := fmt.Sprintf("members[%d].student_number", i)
+		errs.maxLength(fmt.Sprintf("members[%d].name", i), p.Members[i].Name, 70)
+		errs.studentNumber(field, p.Members[i].StudentNumber)
+	}
+
+	return errs.err()
+}
+
+// ValidateExtraFields checks a competition's extra field definitions: names
+// must be unique snake_case identifiers.
+func ValidateExtraFields(fields []ExtraField) error {
+	errs := ValidationErrors{}
+	seen := map[string]bool{}
+	for i, v := range fields {
+		field := fmt.Sprintf("extra_fields[%d].name", i)
+		if !extraFieldNamePattern.MatchString(v.Name) {
+			errs[field] = "must be lowercase letters, digits and underscores, starting with a letter"
+		} else if seen[v.Name] {
+			errs[field] = "is used more than once"
+		}
+		seen[v.Name] = true
+	}
+
+	return errs.err()
+}
+
+// CheckExtraFieldValues validates registration values against a competition's
+// extra fields and returns them trimmed, without empty ones. Errors are keyed
+// by extra_fields.<name>.
+func CheckExtraFieldValues(fields []ExtraField, values map[string]string) (map[string]string, error) {
+	errs := ValidationErrors{}
+	result := map[string]string{}
+
+	known := map[string]bool{}
+	for _, v := range fields {
+		known[v.Name] = true
+
+		key := "extra_fields." + v.Name
+		value := strings.TrimSpace(values[v.Name])
+		if value == "" {
+			if v.Required {
+				errs[key] = "is required"
+			}
+			continue
+		}
+
+		maxLength := v.MaxLength
+		if maxLength == 0 {
+			maxLength = DefaultExtraFieldMaxLength
+		}
+		errs.maxLength(key, value, maxLength)
+
+		if v.Type == "url" && !isWebURL(value) {
+			errs[key] = "must be an http or https link"
+		}
+
+		result[v.Name] = value
+	}
+
+	for name := range values {
+		if !known[name] {
+			errs["extra_fields."+name] = "is not a field of this competition"
+		}
+	}
+
+	return result, errs.err()
+}
+
+func isWebURL(value string) bool {
+	u, err := url.ParseRequestURI(value)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,44 @@
+package model
+
+import "testing"
+
+func TestCompetitionRegistrationRequestPhoneNumber(t *testing.T) {
+	tests := []struct {
+		name        string
+		phoneNumber string
+		want        string
+		wantErr     bool
+	}{
+		{name: "omitted", phoneNumber: "", want: ""},
+		{name: "local form", phoneNumber: "081234567890", want: "+6281234567890"},
+		{name: "international form", phoneNumber: "+62 812-3456-7890", want: "+6281234567890"},
+		{name: "not a mobile number", phoneNumber: "12345", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := CompetitionRegistrationRequest{
+				FullName:      "Leader",
+				StudentNumber: "225150400111001",
+				University:    "Universitas Brawijaya",
+				Major:         "Sistem Informasi",
+				PhoneNumber:   tt.phoneNumber,
+			}
+
+			err := param.Validate()
+			if tt.wantErr {
+				errs, ok := err.(ValidationErrors)
+				if !ok || errs["phone_number"] == "" {
+					t.Fatalf("Validate() error = %v, want a phone_number error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() error = %v, want nil", err)
+			}
+			if param.PhoneNumber != tt.want {
+				t.Errorf("PhoneNumber = %q, want %q", param.PhoneNumber, tt.want)
+			}
+		})
+	}
+}
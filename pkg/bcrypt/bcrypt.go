@@ -1,19 +1,35 @@
 package bcrypt
 
-import lib_bcrypt "golang.org/x/crypto/bcrypt"
+import (
+	"log"
+
+	lib_bcrypt "golang.org/x/crypto/bcrypt"
+)
 
 type Interface interface {
 	GenerateFromPassword(password string) (string, error)
 	CompareAndHashPassword(hashPassword, password string) error
+	NeedsRehash(hashPassword string) bool
+	CompareDummy(password string)
 }
 
 type bcrypt struct {
-	cost int
+	cost      int
+	dummyHash []byte
 }
 
-func Init() Interface {
+// Init hashes with the given work factor, which config.Load has already
+// checked against bcrypt's limits.
+func Init(cost int) Interface {
+
+	dummyHash, err := lib_bcrypt.GenerateFromPassword([]byte("itfest-dummy-password"), cost)
+	if err != nil {
+		log.Fatalf("error init bcrypt %v", err)
+	}
+
 	return &bcrypt{
-		cost: 10,
+		cost:      cost,
+		dummyHash: dummyHash,
 	}
 }
 
@@ -26,6 +42,17 @@ func (b *bcrypt) GenerateFromPassword(password string) (string, error) {
 	return string(bytePass), nil
 }
 
+// NeedsRehash reports whether the hash was made with a lower cost than the
+// configured one.
+func (b *bcrypt) NeedsRehash(hashPassword string) bool {
+	cost, err := lib_bcrypt.Cost([]byte(hashPassword))
+	if err != nil {
+		return false
+	}
+
+	return cost < b.cost
+}
+
 func (b *bcrypt) CompareAndHashPassword(hashPassword, password string) error {
 	err := lib_bcrypt.CompareHashAndPassword([]byte(hashPassword), []byte(password))
 	if err != nil {
@@ -34,3 +61,10 @@ func (b *bcrypt) CompareAndHashPassword(hashPassword, password string) error {
 
 	return nil
 }
+
+// CompareDummy runs a comparison against a fixed hash of the configured cost and
+// discards the result. Login calls it for unknown emails so they take about as
+// long as a wrong password for a registered one.
+func (b *bcrypt) CompareDummy(password string) {
+	_ = lib_bcrypt.CompareHashAndPassword(b.dummyHash, []byte(password))
+}
@@ -0,0 +1,51 @@
+package bcrypt
+
+import (
+	"testing"
+
+	lib_bcrypt "golang.org/x/crypto/bcrypt"
+)
+
+func TestGenerateFromPasswordUsesCost(t *testing.T) {
+	for _, cost := range []int{4, 6} {
+		hash, err := Init(cost).GenerateFromPassword("password123")
+		if err != nil {
+			t.Fatalf("GenerateFromPassword() error = %v, want nil", err)
+		}
+
+		got, err := lib_bcrypt.Cost([]byte(hash))
+		if err != nil {
+			t.Fatalf("Cost() error = %v, want nil", err)
+		}
+		if got != cost {
+			t.Errorf("hash cost = %d, want %d", got, cost)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hash, err := Init(5).GenerateFromPassword("password123")
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() error = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name string
+		cost int
+		hash string
+		want bool
+	}{
+		{name: "configured cost raised", cost: 6, hash: hash, want: true},
+		{name: "same cost", cost: 5, hash: hash, want: false},
+		{name: "configured cost lowered", cost: 4, hash: hash, want: false},
+		{name: "not a bcrypt hash", cost: 6, hash: "plain", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Init(tt.cost).NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
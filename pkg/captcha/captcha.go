@@ -0,0 +1,103 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"itfest-2025/pkg/config"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const siteVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+
+var ErrVerificationFailed = errors.New("captcha verification failed")
+
+type Interface interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+type recaptcha struct {
+	secretKey string
+	client    *http.Client
+}
+
+type disabled struct{}
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Init returns a verifier that accepts every request when CAPTCHA_ENABLED is
+// off, so local development isn't blocked by a missing token.
+func Init(cfg config.Captcha) Interface {
+	if !cfg.Enabled {
+		return disabled{}
+	}
+
+	return &recaptcha{
+		secretKey: cfg.SecretKey,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Verify asks reCAPTCHA whether the token the client solved is valid. A missing,
+// expired or reused token is reported as ErrVerificationFailed; anything else
+// means reCAPTCHA could not be asked.
+func (r *recaptcha) Verify(ctx context.Context, token, remoteIP string) error {
+	if token == "" {
+		return ErrVerificationFailed
+	}
+
+	form := url.Values{
+		"secret":   {r.secretKey},
+		"response": {token},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, siteVerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("recaptcha siteverify responded with status %d", res.StatusCode)
+	}
+
+	var body siteVerifyResponse
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return err
+	}
+
+	if !body.Success {
+		for _, code := range body.ErrorCodes {
+			// The server is misconfigured, the user did nothing wrong.
+			if code == "missing-input-secret" || code == "invalid-input-secret" {
+				return errors.New("recaptcha rejected CAPTCHA_SECRET_KEY")
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrVerificationFailed, strings.Join(body.ErrorCodes, ", "))
+	}
+
+	return nil
+}
+
+func (disabled) Verify(ctx context.Context, token, remoteIP string) error {
+	return nil
+}
@@ -0,0 +1,29 @@
+package captcha
+
+import (
+	"context"
+	"sync"
+)
+
+// Fake accepts any non-empty token once, the way reCAPTCHA consumes a token on
+// its first check.
+type Fake struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (f *Fake) Verify(ctx context.Context, token, remoteIP string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if token == "" || f.seen[token] {
+		return ErrVerificationFailed
+	}
+
+	if f.seen == nil {
+		f.seen = map[string]bool{}
+	}
+	f.seen[token] = true
+
+	return nil
+}
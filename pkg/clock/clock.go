@@ -0,0 +1,20 @@
+package clock
+
+import "time"
+
+// Clock tells the current time. Services take one instead of calling time.Now
+// so time-dependent checks can be driven by a Fake.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+// Real returns a Clock backed by time.Now.
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
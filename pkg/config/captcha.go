@@ -0,0 +1,26 @@
+package config
+
+// Captcha guards signup, and optionally login, with Google reCAPTCHA. It is off
+// by default so local setups don't need a reCAPTCHA site.
+type Captcha struct {
+	Enabled   bool
+	SecretKey string
+	// OnLogin also asks for a captcha token when logging in with a password.
+	OnLogin bool
+}
+
+// loadCaptcha reads CAPTCHA_ENABLED. CAPTCHA_SECRET_KEY is only needed when it
+// is on.
+func loadCaptcha(l *loader) Captcha {
+	captcha := Captcha{
+		Enabled: l.bool("CAPTCHA_ENABLED", false),
+	}
+	if !captcha.Enabled {
+		return captcha
+	}
+
+	captcha.SecretKey = l.required("CAPTCHA_SECRET_KEY")
+	captcha.OnLogin = l.bool("CAPTCHA_ON_LOGIN", false)
+
+	return captcha
+}
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"errors"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,3 +16,143 @@ func LoadEnvironment() error {
 	}
 	return nil
 }
+
+// Config holds every setting read from the environment. It is loaded once at
+// startup and passed to the packages that need it.
+type Config struct {
+	Server     Server
+	Database   Database
+	JWT        JWT
+	BcryptCost int
+	SMTP       SMTP
+	Storage    Storage
+	Google     Google
+	Captcha    Captcha
+	WhatsApp   WhatsApp
+	Webhook    Webhook
+	Otp        OtpExpiry
+	Score      ScoreRange
+	Image      ImageCompression
+	App        App
+}
+
+type Server struct {
+	Address string
+	Port    string
+	// Timeout is the default request budget; zero disables it.
+	Timeout time.Duration
+	// MetricsPort serves /metrics on its own port, so it can be kept off the
+	// public one. When empty, /metrics is served on Port.
+	MetricsPort string
+}
+
+type JWT struct {
+	SecretKey             string
+	ExpiredTime           time.Duration
+	RememberMeExpiredTime time.Duration
+}
+
+type Google struct {
+	ClientID string
+}
+
+// WhatsApp and Webhook are optional; leaving them empty disables the feature.
+type WhatsApp struct {
+	URL   string
+	Token string
+}
+
+type Webhook struct {
+	URL    string
+	Secret string
+}
+
+// App holds the business rules that can be tuned per deployment.
+type App struct {
+	FrontendURL          string
+	DefaultCompetitionID int
+	PasswordHistorySize  int
+	// LoginHistoryRetention is how long login history is kept.
+	LoginHistoryRetention time.Duration
+	ReminderWindow        time.Duration
+	SupportInboxEmail     string
+	TeamNameBlocklist     []string
+	// Timezone is the zone deadlines are shown in.
+	Timezone *time.Location
+}
+
+const (
+	minBcryptCost = 4
+	maxBcryptCost = 31
+)
+
+// Load reads and validates the environment. Every missing or malformed value
+// is reported in the returned error, so a bad deployment fails at startup
+// instead of in the middle of a request.
+func Load() (*Config, error) {
+	var l loader
+
+	cfg := &Config{
+		Server: Server{
+			Address:     l.optional("ADDRESS"),
+			Port:        l.required("PORT"),
+			Timeout:     time.Duration(l.int("TIME_OUT_LIMIT", 0, 0)) * time.Second,
+			MetricsPort: l.optional("METRICS_PORT"),
+		},
+		Database: Database{
+			User:     l.required("DB_USER"),
+			Password: l.optional("DB_PASSWORD"),
+			Host:     l.required("DB_HOST"),
+			Port:     l.required("DB_PORT"),
+			Name:     l.required("DB_NAME"),
+			Pool: ConnectionPool{
+				MaxOpenConns:    l.int("DB_MAX_OPEN_CONNS", 25, 1),
+				MaxIdleConns:    l.int("DB_MAX_IDLE_CONNS", 10, 1),
+				ConnMaxLifetime: time.Duration(l.int("DB_CONN_MAX_LIFETIME", 5, 1)) * time.Minute,
+			},
+		},
+		JWT: JWT{
+			SecretKey:             l.required("JWT_SECRET_KEY"),
+			ExpiredTime:           time.Duration(l.requiredInt("JWT_EXP_TIME", 1)) * time.Hour,
+			RememberMeExpiredTime: time.Duration(l.int("JWT_REMEMBER_ME_EXP_TIME", 30*24, 1)) * time.Hour,
+		},
+		BcryptCost: l.int("BCRYPT_COST", 10, minBcryptCost),
+		SMTP:       loadSMTP(&l),
+		Storage:    loadStorage(&l),
+		Google: Google{
+			ClientID: l.optional("GOOGLE_CLIENT_ID"),
+		},
+		Captcha: loadCaptcha(&l),
+		WhatsApp: WhatsApp{
+			URL:   l.optional("WHATSAPP_API_URL"),
+			Token: l.optional("WHATSAPP_API_TOKEN"),
+		},
+		Webhook: Webhook{
+			URL:    l.optional("WEBHOOK_URL"),
+			Secret: l.optional("WEBHOOK_SECRET"),
+		},
+		Otp:   loadOtpExpiry(&l),
+		Score: loadScoreRange(&l),
+		Image: loadImageCompression(&l),
+		App: App{
+			FrontendURL:           strings.TrimSuffix(l.optional("FRONTEND_URL"), "/"),
+			DefaultCompetitionID:  l.int("DEFAULT_COMPETITION_ID", 1, 1),
+			PasswordHistorySize:   l.int("PASSWORD_HISTORY_SIZE", 3, 1),
+			LoginHistoryRetention: time.Duration(l.int("LOGIN_HISTORY_RETENTION_DAYS", 90, 1)) * 24 * time.Hour,
+			ReminderWindow:        time.Duration(l.int("REMINDER_WINDOW_HOURS", 24, 1)) * time.Hour,
+			SupportInboxEmail:     l.optional("SUPPORT_INBOX_EMAIL"),
+			TeamNameBlocklist:     l.list("TEAM_NAME_BLOCKLIST"),
+			Timezone:              l.location("TIMEZONE", "Asia/Jakarta"),
+		},
+	}
+
+	if cfg.BcryptCost > maxBcryptCost {
+		l.fail("BCRYPT_COST must be at most %d, got %d", maxBcryptCost, cfg.BcryptCost)
+	}
+
+	if len(l.errs) > 0 {
+		return nil, errors.Join(l.errs...)
+	}
+
+	return cfg, nil
+}
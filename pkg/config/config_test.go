@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadBcryptCost(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr string
+	}{
+		{name: "unset", want: 10},
+		{name: "within range", value: "12", want: 12},
+		{name: "below minimum", value: "3", wantErr: "BCRYPT_COST must be at least 4"},
+		{name: "above maximum", value: "32", wantErr: "BCRYPT_COST must be at most 31"},
+		{name: "not a number", value: "high", wantErr: "BCRYPT_COST must be a whole number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range map[string]string{
+				"PORT":              "8080",
+				"DB_USER":           "root",
+				"DB_HOST":           "localhost",
+				"DB_PORT":           "3306",
+				"DB_NAME":           "itfest",
+				"JWT_SECRET_KEY":    "secret",
+				"JWT_EXP_TIME":      "1",
+				"SMTP_HOST":         "smtp.example.com",
+				"SMTP_PORT":         "587",
+				"SMTP_USERNAME":     "itfest",
+				"SMTP_PASSWORD":     "secret",
+				"EXPIRED_OTP":       "5",
+				"STORAGE_BACKEND":   "local",
+				"LOCAL_STORAGE_URL": "http://localhost:8080/uploads",
+			} {
+				t.Setenv(key, value)
+			}
+			t.Setenv("BCRYPT_COST", tt.value)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v, want nil", err)
+			}
+			if cfg.BcryptCost != tt.want {
+				t.Errorf("BcryptCost = %d, want %d", cfg.BcryptCost, tt.want)
+			}
+		})
+	}
+}
@@ -2,14 +2,33 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"time"
 )
 
-func LoadDataSourceName() string {
+type Database struct {
+	User     string
+	Password string
+	Host     string
+	Port     string
+	Name     string
+	Pool     ConnectionPool
+}
+
+func (d Database) DataSourceName() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=True",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NAME"))
+		d.User,
+		d.Password,
+		d.Host,
+		d.Port,
+		d.Name)
+}
+
+// ConnectionPool holds the database/sql pool limits applied to the MariaDB
+// connection. Read from DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and
+// DB_CONN_MAX_LIFETIME (in minutes); the defaults stay well below MariaDB's
+// default max_connections of 151.
+type ConnectionPool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
 }
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	// Embedded so TIMEZONE works on images without a zoneinfo database.
+	_ "time/tzdata"
+)
+
+// loader reads environment variables and collects every problem it finds,
+// so Load can report them all at once.
+type loader struct {
+	errs []error
+}
+
+func (l *loader) fail(format string, args ...interface{}) {
+	l.errs = append(l.errs, fmt.Errorf(format, args...))
+}
+
+func (l *loader) optional(key string) string {
+	return strings.TrimSpace(os.Getenv(key))
+}
+
+func (l *loader) required(key string) string {
+	value := l.optional(key)
+	if value == "" {
+		l.fail("%s is required", key)
+	}
+
+	return value
+}
+
+// int reads a whole number of at least min, using fallback when key is unset.
+func (l *loader) int(key string, fallback int, min int) int {
+	value := l.optional(key)
+	if value == "" {
+		return fallback
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		l.fail("%s must be a whole number, got %q", key, value)
+		return fallback
+	}
+
+	if number < min {
+		l.fail("%s must be at least %d, got %d", key, min, number)
+		return fallback
+	}
+
+	return number
+}
+
+// bool reads true or false (or anything strconv.ParseBool accepts), using
+// fallback when key is unset.
+func (l *loader) bool(key string, fallback bool) bool {
+	value := l.optional(key)
+	if value == "" {
+		return fallback
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		l.fail("%s must be true or false, got %q", key, value)
+		return fallback
+	}
+
+	return enabled
+}
+
+func (l *loader) requiredInt(key string, min int) int {
+	if l.required(key) == "" {
+		return 0
+	}
+
+	return l.int(key, 0, min)
+}
+
+// location loads an IANA time zone name such as "Asia/Jakarta".
+func (l *loader) location(key string, fallback string) *time.Location {
+	name := l.optional(key)
+	if name == "" {
+		name = fallback
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		l.fail("%s must be an IANA time zone name, got %q", key, name)
+		return time.UTC
+	}
+
+	return location
+}
+
+// list splits a comma-separated value, dropping empty entries.
+func (l *loader) list(key string) []string {
+	var values []string
+	for _, value := range strings.Split(l.optional(key), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
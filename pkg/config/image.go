@@ -0,0 +1,27 @@
+package config
+
+// ImageCompression controls the optional downscaling of payment proof images
+// before they are stored.
+type ImageCompression struct {
+	Enabled bool
+	// Threshold is the size in bytes above which an image is compressed.
+	Threshold    int64
+	MaxDimension int
+	Quality      int
+}
+
+// loadImageCompression reads IMAGE_COMPRESSION and its tuning values. It is
+// off unless IMAGE_COMPRESSION is true.
+func loadImageCompression(l *loader) ImageCompression {
+	compression := ImageCompression{
+		Enabled:      l.bool("IMAGE_COMPRESSION", false),
+		Threshold:    int64(l.int("IMAGE_COMPRESSION_THRESHOLD_KB", 300, 0)) * 1024,
+		MaxDimension: l.int("IMAGE_MAX_DIMENSION", 1600, 1),
+		Quality:      l.int("IMAGE_JPEG_QUALITY", 80, 1),
+	}
+	if compression.Quality > 100 {
+		l.fail("IMAGE_JPEG_QUALITY must be at most 100, got %d", compression.Quality)
+	}
+
+	return compression
+}
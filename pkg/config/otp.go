@@ -0,0 +1,29 @@
+package config
+
+import (
+	"log"
+	"time"
+)
+
+const defaultOtpExpiryMinutes = 5
+
+// OtpExpiry is how long an OTP stays valid after it was last issued.
+type OtpExpiry struct {
+	Verify time.Duration
+	Reset  time.Duration
+}
+
+// loadOtpExpiry reads OTP_VERIFY_EXPIRY and OTP_RESET_EXPIRY in minutes. Either
+// one falls back to EXPIRED_OTP, and then to 5 minutes, when unset.
+func loadOtpExpiry(l *loader) OtpExpiry {
+	if l.optional("EXPIRED_OTP") == "" && (l.optional("OTP_VERIFY_EXPIRY") == "" || l.optional("OTP_RESET_EXPIRY") == "") {
+		log.Printf("warning: EXPIRED_OTP is not set, OTPs without their own expiry default to %d minutes", defaultOtpExpiryMinutes)
+	}
+
+	fallback := l.int("EXPIRED_OTP", defaultOtpExpiryMinutes, 1)
+
+	return OtpExpiry{
+		Verify: time.Duration(l.int("OTP_VERIFY_EXPIRY", fallback, 1)) * time.Minute,
+		Reset:  time.Duration(l.int("OTP_RESET_EXPIRY", fallback, 1)) * time.Minute,
+	}
+}
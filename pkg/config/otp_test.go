@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadOtpExpiry(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantVerify time.Duration
+		wantReset  time.Duration
+	}{
+		{
+			name:       "nothing set",
+			wantVerify: 5 * time.Minute,
+			wantReset:  5 * time.Minute,
+		},
+		{
+			name:       "EXPIRED_OTP only",
+			env:        map[string]string{"EXPIRED_OTP": "15"},
+			wantVerify: 15 * time.Minute,
+			wantReset:  15 * time.Minute,
+		},
+		{
+			name:       "own expiries",
+			env:        map[string]string{"EXPIRED_OTP": "15", "OTP_VERIFY_EXPIRY": "30", "OTP_RESET_EXPIRY": "10"},
+			wantVerify: 30 * time.Minute,
+			wantReset:  10 * time.Minute,
+		},
+		{
+			name:       "reset falls back to EXPIRED_OTP",
+			env:        map[string]string{"EXPIRED_OTP": "15", "OTP_VERIFY_EXPIRY": "30"},
+			wantVerify: 30 * time.Minute,
+			wantReset:  15 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"EXPIRED_OTP", "OTP_VERIFY_EXPIRY", "OTP_RESET_EXPIRY"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			var l loader
+			got := loadOtpExpiry(&l)
+
+			if len(l.errs) != 0 {
+				t.Fatalf("loadOtpExpiry() errors = %v", l.errs)
+			}
+			if got.Verify != tt.wantVerify {
+				t.Errorf("Verify = %v, want %v", got.Verify, tt.wantVerify)
+			}
+			if got.Reset != tt.wantReset {
+				t.Errorf("Reset = %v, want %v", got.Reset, tt.wantReset)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import "math"
+
+// ScoreRange is the inclusive range judges can score a stage submission in.
+type ScoreRange struct {
+	Min float64
+	Max float64
+}
+
+// loadScoreRange reads SCORE_MIN and SCORE_MAX, defaulting to 0-100.
+func loadScoreRange(l *loader) ScoreRange {
+	scoreRange := ScoreRange{
+		Min: float64(l.int("SCORE_MIN", 0, math.MinInt)),
+		Max: float64(l.int("SCORE_MAX", 100, math.MinInt)),
+	}
+	if scoreRange.Min >= scoreRange.Max {
+		l.fail("SCORE_MIN must be below SCORE_MAX, got %g and %g", scoreRange.Min, scoreRange.Max)
+	}
+
+	return scoreRange
+}
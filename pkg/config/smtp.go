@@ -0,0 +1,32 @@
+package config
+
+type SMTP struct {
+	Host              string
+	Port              string
+	Username          string
+	Password          string
+	SendRatePerMinute int
+	// DryRun logs emails instead of sending them, for staging and load tests.
+	DryRun bool
+}
+
+// loadSMTP reads the SMTP account. With MAIL_DRY_RUN set nothing is sent, so
+// the account may be left out.
+func loadSMTP(l *loader) SMTP {
+	smtp := SMTP{
+		DryRun:            l.bool("MAIL_DRY_RUN", false),
+		SendRatePerMinute: l.int("MAIL_SEND_RATE_PER_MINUTE", 60, 1),
+	}
+
+	value := l.required
+	if smtp.DryRun {
+		value = l.optional
+	}
+
+	smtp.Host = value("SMTP_HOST")
+	smtp.Port = value("SMTP_PORT")
+	smtp.Username = value("SMTP_USERNAME")
+	smtp.Password = value("SMTP_PASSWORD")
+
+	return smtp
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// Storage backends selectable with STORAGE_BACKEND.
+const (
+	StorageSupabase = "supabase"
+	StorageS3       = "s3"
+	StorageLocal    = "local"
+)
+
+type Storage struct {
+	Backend string
+	// UploadRetries is how many times a failed upload is retried; 0 disables
+	// retrying. UploadBackoff is the wait before the first retry and doubles
+	// after each one.
+	UploadRetries int
+	UploadBackoff time.Duration
+
+	Supabase Supabase
+	S3       S3
+	Local    LocalStorage
+}
+
+type Supabase struct {
+	URL    string
+	Token  string
+	Bucket string
+}
+
+// S3 works with AWS and with S3-compatible services. Endpoint is only needed
+// for the latter; PublicURL, when set, is the base of the URLs handed out for
+// stored files instead of the bucket's own address.
+type S3 struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PublicURL       string
+}
+
+// LocalStorage keeps files in Dir, which the API serves at /files. URL is the
+// public address of that route, such as https://api.example.com/files.
+type LocalStorage struct {
+	Dir string
+	URL string
+}
+
+// loadStorage reads STORAGE_BACKEND, defaulting to Supabase, and only
+// requires the settings of the backend it selects.
+func loadStorage(l *loader) Storage {
+	storage := Storage{
+		Backend:       strings.ToLower(l.optional("STORAGE_BACKEND")),
+		UploadRetries: l.int("STORAGE_UPLOAD_RETRIES", 3, 0),
+		UploadBackoff: time.Duration(l.int("STORAGE_UPLOAD_BACKOFF_MS", 500, 1)) * time.Millisecond,
+	}
+	if storage.Backend == "" {
+		storage.Backend = StorageSupabase
+	}
+
+	switch storage.Backend {
+	case StorageSupabase:
+		storage.Supabase = Supabase{
+			URL:    strings.TrimSuffix(l.required("SUPABASE_URL"), "/"),
+			Token:  l.required("SUPABASE_TOKEN"),
+			Bucket: l.required("SUPABASE_BUCKET"),
+		}
+	case StorageS3:
+		storage.S3 = S3{
+			Endpoint:        strings.TrimSuffix(l.optional("S3_ENDPOINT"), "/"),
+			Region:          l.required("S3_REGION"),
+			Bucket:          l.required("S3_BUCKET"),
+			AccessKeyID:     l.required("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: l.required("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       strings.TrimSuffix(l.optional("S3_PUBLIC_URL"), "/"),
+		}
+	case StorageLocal:
+		storage.Local = LocalStorage{
+			Dir: l.optional("LOCAL_STORAGE_DIR"),
+			URL: strings.TrimSuffix(l.required("LOCAL_STORAGE_URL"), "/"),
+		}
+		if storage.Local.Dir == "" {
+			storage.Local.Dir = "uploads"
+		}
+	default:
+		l.fail("STORAGE_BACKEND must be %s, %s or %s, got %q", StorageSupabase, StorageS3, StorageLocal, storage.Backend)
+	}
+
+	return storage
+}
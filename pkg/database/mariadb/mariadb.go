@@ -1,6 +1,8 @@
 package mariadb
 
 import (
+	"context"
+	"errors"
 	"itfest-2025/pkg/config"
 
 	"gorm.io/driver/mysql"
@@ -8,18 +10,44 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-var Connection *gorm.DB
-
-func ConnectDatabase() (*gorm.DB, error) {
-	db, err := gorm.Open(mysql.Open(config.LoadDataSourceName()), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+func ConnectDatabase(cfg config.Database) (*gorm.DB, error) {
+	db, err := gorm.Open(mysql.Open(cfg.DataSourceName()), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Info),
+		TranslateError: true,
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	Connection = db
+	err = registerMetrics(db)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	pool := cfg.Pool
+	sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+
+	return db, nil
+}
+
+// Ping checks that the database behind db is reachable.
+func Ping(ctx context.Context, db *gorm.DB) error {
+	if db == nil {
+		return errors.New("database is not connected")
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
 
-	return Connection, nil
+	return sqlDB.PingContext(ctx)
 }
@@ -0,0 +1,31 @@
+package mariadb
+
+import (
+	"errors"
+	"itfest-2025/pkg/metrics"
+
+	"gorm.io/gorm"
+)
+
+// registerMetrics counts the statements gorm runs. Raw covers db.Exec and Row
+// covers Scan and Row on hand-written queries.
+func registerMetrics(db *gorm.DB) error {
+	callback := db.Callback()
+
+	return errors.Join(
+		callback.Create().After("gorm:create").Register("metrics:create", countQuery("create")),
+		callback.Query().After("gorm:query").Register("metrics:query", countQuery("query")),
+		callback.Update().After("gorm:update").Register("metrics:update", countQuery("update")),
+		callback.Delete().After("gorm:delete").Register("metrics:delete", countQuery("delete")),
+		callback.Row().After("gorm:row").Register("metrics:row", countQuery("row")),
+		callback.Raw().After("gorm:raw").Register("metrics:raw", countQuery("raw")),
+	)
+}
+
+func countQuery(operation string) func(*gorm.DB) {
+	counter := metrics.DBQueries.WithLabelValues(operation)
+
+	return func(*gorm.DB) {
+		counter.Inc()
+	}
+}
@@ -1,14 +1,22 @@
 package mariadb
 
 import (
+	"fmt"
 	"itfest-2025/entity"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func Migrate(db *gorm.DB) error {
+	// Checked before AutoMigrate adds the columns, so the limits are seeded
+	// once and later changes to them are kept.
+	seedTeamSize := db.Migrator().HasTable(&entity.Competition{}) &&
+		(!db.Migrator().HasColumn(&entity.Competition{}, "min_members") || !db.Migrator().HasColumn(&entity.Competition{}, "max_members"))
+
 	err := db.AutoMigrate(
 		&entity.Role{},
+		&entity.RolePermission{},
 		&entity.User{},
 		&entity.OtpCode{},
 		&entity.Competition{},
@@ -17,10 +25,134 @@ func Migrate(db *gorm.DB) error {
 		&entity.Announcement{},
 		&entity.TeamProgress{},
 		&entity.TeamMember{},
+		&entity.IdempotencyKey{},
+		&entity.LoginFingerprint{},
+		&entity.LoginHistory{},
+		&entity.SupportMessage{},
+		&entity.Coupon{},
+		&entity.PasswordHistory{},
+		&entity.AuditLog{},
+		&entity.StageReminder{},
+		&entity.Notification{},
 	)
 	if err != nil {
 		return err
 	}
 
+	err = migrateRoles(db)
+	if err != nil {
+		return err
+	}
+
+	if seedTeamSize {
+		err = migrateCompetitionTeamSize(db)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = migrateTeamNameIndex(db)
+	if err != nil {
+		return err
+	}
+
+	err = migrateUserEmailIndex(db)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// migrateRoles keeps the roles table in line with the role IDs the code checks,
+// renaming rows whose name has drifted, grants the default permissions and
+// moves users whose role no longer exists to the user role.
+func migrateRoles(db *gorm.DB) error {
+	roles := []entity.Role{
+		{RoleID: entity.RoleAdmin, RoleName: entity.RoleNameAdmin},
+		{RoleID: entity.RoleUser, RoleName: entity.RoleNameUser},
+		{RoleID: entity.RoleJudge, RoleName: entity.RoleNameJudge},
+		{RoleID: entity.RoleFinance, RoleName: entity.RoleNameFinance},
+	}
+
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "role_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role_name"}),
+	}).Create(&roles).Error
+	if err != nil {
+		return err
+	}
+
+	var permissions []entity.RolePermission
+	for roleID, perms := range entity.DefaultRolePermissions {
+		for _, v := range perms {
+			permissions = append(permissions, entity.RolePermission{RoleID: roleID, Permission: v})
+		}
+	}
+
+	err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&permissions).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec("UPDATE users SET role_id = ? WHERE role_id IS NULL OR role_id NOT IN (SELECT role_id FROM roles)", entity.RoleUser).Error
+}
+
+// migrateCompetitionTeamSize gives the competitions that existed before team
+// size rules the limits the app enforced until then: a leader with up to two
+// members. The minimum of one member makes every team more than its leader.
+// Competitions with other rules are changed in the database afterwards.
+func migrateCompetitionTeamSize(db *gorm.DB) error {
+	return db.Exec("UPDATE competitions SET min_members = ?, max_members = ?", 1, 2).Error
+}
+
+// migrateTeamNameIndex makes team names unique per competition, ignoring case.
+// Teams that haven't picked a name yet all share the empty name, and MariaDB
+// has no partial indexes, so the index covers a generated column that is NULL
+// for empty names; NULLs never collide in a unique index.
+//
+// Creating the index fails if a competition already has duplicate names. Rename
+// those teams and restart to finish the migration.
+func migrateTeamNameIndex(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&entity.Team{}, "team_name_key") {
+		err := db.Exec("ALTER TABLE teams ADD COLUMN team_name_key varchar(50) " +
+			"GENERATED ALWAYS AS (NULLIF(LOWER(team_name), '')) VIRTUAL").Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if !db.Migrator().HasIndex(&entity.Team{}, "idx_teams_competition_team_name") {
+		err := db.Exec("CREATE UNIQUE INDEX idx_teams_competition_team_name ON teams (competition_id, team_name_key)").Error
+		if err != nil {
+			return fmt.Errorf("creating unique team name index, rename duplicate teams first: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// migrateUserEmailIndex makes emails unique among accounts that aren't deleted,
+// ignoring case. Like the team name index it covers a generated column, which
+// is NULL for deleted accounts so their emails can be registered again.
+//
+// Creating the index fails if two live accounts share an email. Delete one of
+// them and restart to finish the migration.
+func migrateUserEmailIndex(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&entity.User{}, "email_key") {
+		err := db.Exec("ALTER TABLE users ADD COLUMN email_key varchar(50) " +
+			"GENERATED ALWAYS AS (IF(deleted_at IS NULL, LOWER(email), NULL)) VIRTUAL").Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if !db.Migrator().HasIndex(&entity.User{}, "idx_users_email_key") {
+		err := db.Exec("CREATE UNIQUE INDEX idx_users_email_key ON users (email_key)").Error
+		if err != nil {
+			return fmt.Errorf("creating unique email index, remove duplicate accounts first: %w", err)
+		}
+	}
+
 	return nil
 }
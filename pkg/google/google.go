@@ -0,0 +1,98 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"itfest-2025/pkg/config"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+var ErrInvalidIDToken = errors.New("invalid google id token")
+
+type Interface interface {
+	VerifyIDToken(ctx context.Context, idToken string) (*Payload, error)
+}
+
+type Payload struct {
+	Subject string
+	Email   string
+	Name    string
+}
+
+type google struct {
+	clientID string
+	client   *http.Client
+}
+
+type tokenInfo struct {
+	Audience      string `json:"aud"`
+	Issuer        string `json:"iss"`
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified string `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+func Init(cfg config.Google) Interface {
+	return &google{
+		clientID: cfg.ClientID,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// VerifyIDToken lets Google check the token signature and expiry, then makes sure
+// the token was issued for this app and carries a verified email.
+func (g *google) VerifyIDToken(ctx context.Context, idToken string) (*Payload, error) {
+	if g.clientID == "" {
+		return nil, errors.New("google login is not configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenInfoURL+"?id_token="+url.QueryEscape(idToken), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusBadRequest {
+		return nil, ErrInvalidIDToken
+	} else if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google tokeninfo responded with status %d", res.StatusCode)
+	}
+
+	var info tokenInfo
+	err = json.NewDecoder(res.Body).Decode(&info)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Audience != g.clientID {
+		return nil, ErrInvalidIDToken
+	}
+
+	if info.Issuer != "accounts.google.com" && info.Issuer != "https://accounts.google.com" {
+		return nil, ErrInvalidIDToken
+	}
+
+	if info.Email == "" || info.EmailVerified != "true" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return &Payload{
+		Subject: info.Subject,
+		Email:   info.Email,
+		Name:    info.Name,
+	}, nil
+}
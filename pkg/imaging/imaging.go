@@ -0,0 +1,143 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+// stripQuality is high enough that removing metadata doesn't visibly change a
+// photo.
+const stripQuality = 90
+
+// StripMetadata decodes a JPEG or PNG and encodes it again in the same format,
+// which leaves EXIF (including GPS location) and other metadata behind. A
+// JPEG's EXIF orientation is applied to the pixels first so it still displays
+// upright.
+func StripMetadata(data []byte) ([]byte, error) {
+	img, format, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if format == "png" {
+		err = png.Encode(&out, img)
+	} else {
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: stripQuality})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// Downscale decodes a JPEG or PNG and re-encodes it as a JPEG whose longest
+// side is at most maxDimension. Smaller images keep their size and are only
+// re-encoded. Transparent areas are filled with white, since JPEG has no alpha.
+func Downscale(data []byte, maxDimension, quality int) ([]byte, error) {
+	img, _, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, image.Point{}, draw.Over)
+
+	width, height := fit(flat.Bounds().Dx(), flat.Bounds().Dy(), maxDimension)
+
+	var out bytes.Buffer
+	err = jpeg.Encode(&out, shrink(flat, width, height), &jpeg.Options{Quality: quality})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// decode returns the image as RGBA anchored at the origin and turned upright
+// according to its EXIF orientation, along with its format name.
+func decode(data []byte) (*image.RGBA, string, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	if format == "jpeg" {
+		rgba = orient(rgba, jpegOrientation(data))
+	}
+
+	return rgba, format, nil
+}
+
+// fit scales width and height down so neither exceeds max, keeping the aspect
+// ratio.
+func fit(width, height, max int) (int, int) {
+	if width <= max && height <= max {
+		return width, height
+	}
+
+	if width >= height {
+		return max, maxInt(1, height*max/width)
+	}
+
+	return maxInt(1, width*max/height), max
+}
+
+// shrink resizes src to width by height by averaging the source pixels that
+// fall inside each destination pixel.
+func shrink(src *image.RGBA, width, height int) *image.RGBA {
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	if width == srcWidth && height == srcHeight {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := maxInt(y0+1, (y+1)*srcHeight/height)
+
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := maxInt(x0+1, (x+1)*srcWidth/width)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					a += int(p[3])
+					n++
+				}
+			}
+
+			d := dst.Pix[y*dst.Stride+x*4:]
+			d[0] = uint8(r / n)
+			d[1] = uint8(g / n)
+			d[2] = uint8(b / n)
+			d[3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,102 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// jpegWithExif encodes a width x height JPEG and inserts an APP1 EXIF segment
+// holding the given orientation, as a phone camera would.
+func jpegWithExif(t *testing.T, width, height int, orientation uint16) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 40), G: uint8(y * 40), B: 128, A: 255})
+		}
+	}
+
+	var encoded bytes.Buffer
+	err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Little-endian TIFF header followed by an IFD with one orientation entry.
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientationTag)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	data := encoded.Bytes()
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func TestStripMetadataRemovesExif(t *testing.T) {
+	data := jpegWithExif(t, 4, 2, 1)
+	if !bytes.Contains(data, []byte("Exif")) {
+		t.Fatal("test image has no EXIF segment")
+	}
+
+	stripped, err := StripMetadata(data)
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v, want nil", err)
+	}
+	if bytes.Contains(stripped, []byte("Exif")) {
+		t.Error("StripMetadata() kept the EXIF segment")
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(stripped))
+	if err != nil {
+		t.Fatalf("stripped image does not decode: %v", err)
+	}
+	if format != "jpeg" || config.Width != 4 || config.Height != 2 {
+		t.Errorf("stripped image = %s %dx%d, want jpeg 4x2", format, config.Width, config.Height)
+	}
+}
+
+func TestStripMetadataAppliesOrientation(t *testing.T) {
+	tests := []struct {
+		orientation uint16
+		wantWidth   int
+		wantHeight  int
+	}{
+		{orientation: 1, wantWidth: 4, wantHeight: 2},
+		{orientation: 3, wantWidth: 4, wantHeight: 2},
+		{orientation: 6, wantWidth: 2, wantHeight: 4},
+		{orientation: 8, wantWidth: 2, wantHeight: 4},
+	}
+
+	for _, tt := range tests {
+		data := jpegWithExif(t, 4, 2, tt.orientation)
+		if got := jpegOrientation(data); got != int(tt.orientation) {
+			t.Fatalf("jpegOrientation() = %d, want %d", got, tt.orientation)
+		}
+
+		stripped, err := StripMetadata(data)
+		if err != nil {
+			t.Fatalf("StripMetadata() error = %v, want nil", err)
+		}
+
+		config, _, err := image.DecodeConfig(bytes.NewReader(stripped))
+		if err != nil {
+			t.Fatalf("stripped image does not decode: %v", err)
+		}
+		if config.Width != tt.wantWidth || config.Height != tt.wantHeight {
+			t.Errorf("orientation %d: stripped image is %dx%d, want %dx%d", tt.orientation, config.Width, config.Height, tt.wantWidth, tt.wantHeight)
+		}
+	}
+}
@@ -1,11 +1,11 @@
 package jwt
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
 	"itfest-2025/entity"
-	"log"
-	"os"
-	"strconv"
+	"itfest-2025/pkg/config"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,73 +14,124 @@ import (
 )
 
 type Interface interface {
-	CreateJWTToken(userID uuid.UUID, isAdmin bool) (string, error)
-	ValidateToken(tokenString string) (uuid.UUID, error)
+	CreateJWTToken(userID uuid.UUID, role string) (string, error)
+	CreateSessionToken(userID uuid.UUID, role string, rememberMe bool) (string, time.Time, error)
+	ValidateToken(tokenString string) (*Claims, error)
 	GetLoginUser(c *gin.Context) (*entity.User, error)
+	CreateVerificationToken(userID uuid.UUID, code string, lifetime time.Duration) (string, error)
+	ValidateVerificationToken(tokenString string) (*VerificationClaims, error)
 }
 
 type jsonWebToken struct {
-	SecretKey   string
-	ExpiredTime time.Duration
+	SecretKey             string
+	ExpiredTime           time.Duration
+	RememberMeExpiredTime time.Duration
 }
 
+// Claims carry the user's role name. IsAdmin is still set for clients that
+// read it, and is the only role information in tokens issued before Role.
 type Claims struct {
 	UserID  uuid.UUID
+	Role    string `json:"role,omitempty"`
 	IsAdmin bool
 	jwt.RegisteredClaims
 }
 
-func Init() Interface {
-	secretKey := os.Getenv("JWT_SECRET_KEY")
-	expiredTime, err := strconv.Atoi(os.Getenv("JWT_EXP_TIME"))
-	if err != nil {
-		log.Fatalf("error init jwt %v", err)
-	}
+// VerificationClaims back the email verification link. They carry the OTP the
+// link stands for, so the link stops working once that OTP is used or resent.
+type VerificationClaims struct {
+	UserID uuid.UUID
+	Code   string
+	jwt.RegisteredClaims
+}
+
+// ErrTokenExpired is wrapped in the error returned for an expired token.
+var ErrTokenExpired = jwt.ErrTokenExpired
 
+// verificationKeyContext separates the verification link key from the session
+// key, so neither kind of token is accepted in place of the other.
+const verificationKeyContext = "email-verification"
+
+func Init(cfg config.JWT) Interface {
 	return &jsonWebToken{
-		SecretKey:   secretKey,
-		ExpiredTime: time.Duration(expiredTime) * time.Hour,
+		SecretKey:             cfg.SecretKey,
+		ExpiredTime:           cfg.ExpiredTime,
+		RememberMeExpiredTime: cfg.RememberMeExpiredTime,
+	}
+}
+
+func (j *jsonWebToken) CreateJWTToken(userID uuid.UUID, role string) (string, error) {
+	tokenString, _, err := j.createToken(userID, role, j.ExpiredTime)
+	return tokenString, err
+}
+
+// CreateSessionToken issues a login token that lives for JWT_EXP_TIME hours, or
+// for JWT_REMEMBER_ME_EXP_TIME hours when rememberMe is set, and returns its expiry.
+func (j *jsonWebToken) CreateSessionToken(userID uuid.UUID, role string, rememberMe bool) (string, time.Time, error) {
+	if rememberMe {
+		return j.createToken(userID, role, j.RememberMeExpiredTime)
 	}
+
+	return j.createToken(userID, role, j.ExpiredTime)
 }
 
-func (j *jsonWebToken) CreateJWTToken(userID uuid.UUID, isAdmin bool) (string, error) {
+func (j *jsonWebToken) createToken(userID uuid.UUID, role string, lifetime time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(lifetime)
+
 	claims := &Claims{
 		UserID:  userID,
-		IsAdmin: isAdmin,
+		Role:    role,
+		IsAdmin: role == entity.RoleNameAdmin,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.ExpiredTime)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(j.SecretKey))
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
-	return tokenString, nil
+	return tokenString, expiresAt, nil
 }
 
-func (j *jsonWebToken) ValidateToken(tokenString string) (uuid.UUID, error) {
-	var (
-		claim  Claims
-		userID uuid.UUID
-	)
+func (j *jsonWebToken) ValidateToken(tokenString string) (*Claims, error) {
+	var claim Claims
 
 	token, err := jwt.ParseWithClaims(tokenString, &claim, func(t *jwt.Token) (interface{}, error) {
 		return []byte(j.SecretKey), nil
 	})
 
 	if err != nil {
-		return userID, err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return userID, errors.New("token is not valid")
+		return nil, errors.New("token is not valid")
+	}
+
+	return &claim, nil
+}
+
+// IssuedBefore reports whether the token was issued before t. Tokens without an
+// issued-at claim predate it being set and are treated as issued before any t.
+func (c *Claims) IssuedBefore(t time.Time) bool {
+	return c.IssuedAt == nil || c.IssuedAt.Before(t)
+}
+
+// RoleName returns the token's role, falling back to IsAdmin for tokens issued
+// before the role claim existed.
+func (c *Claims) RoleName() string {
+	if c.Role != "" {
+		return c.Role
+	} else if c.IsAdmin {
+		return entity.RoleNameAdmin
 	}
 
-	userID = claim.UserID
-	return userID, nil
+	return entity.RoleNameUser
 }
 
 func (j *jsonWebToken) GetLoginUser(c *gin.Context) (*entity.User, error) {
@@ -91,3 +142,42 @@ func (j *jsonWebToken) GetLoginUser(c *gin.Context) (*entity.User, error) {
 
 	return user.(*entity.User), nil
 }
+
+func (j *jsonWebToken) CreateVerificationToken(userID uuid.UUID, code string, lifetime time.Duration) (string, error) {
+	now := time.Now()
+
+	claims := &VerificationClaims{
+		UserID: userID,
+		Code:   code,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.verificationKey())
+}
+
+func (j *jsonWebToken) ValidateVerificationToken(tokenString string) (*VerificationClaims, error) {
+	var claim VerificationClaims
+
+	token, err := jwt.ParseWithClaims(tokenString, &claim, func(t *jwt.Token) (interface{}, error) {
+		return j.verificationKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("token is not valid")
+	}
+
+	return &claim, nil
+}
+
+func (j *jsonWebToken) verificationKey() []byte {
+	mac := hmac.New(sha256.New, []byte(j.SecretKey))
+	mac.Write([]byte(verificationKeyContext))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,51 @@
+package mail
+
+import "sync"
+
+// Message is an email recorded by FakeMailer.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// FakeMailer records messages instead of sending them, whether they were sent
+// or queued. Err, when set, is returned from every Send after the message is
+// recorded. Enqueue always succeeds and hands Err to its callback right away,
+// as if the queue had already tried the email.
+type FakeMailer struct {
+	Err error
+
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (f *FakeMailer) Send(to, subject, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sent = append(f.sent, Message{
+		To:      to,
+		Subject: subject,
+		Body:    body,
+	})
+
+	return f.Err
+}
+
+func (f *FakeMailer) Enqueue(to, subject, body string, sent func(error)) error {
+	err := f.Send(to, subject, body)
+	if sent != nil {
+		sent(err)
+	}
+
+	return nil
+}
+
+// Sent returns a copy of the messages recorded so far.
+func (f *FakeMailer) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Message(nil), f.sent...)
+}
@@ -1,19 +1,46 @@
 package mail
 
 import (
+	crand "crypto/rand"
 	"fmt"
+	"html"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/metrics"
+	"log/slog"
+	"math/big"
 	"math/rand"
 	"net/smtp"
-	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
+var smtpConfig config.SMTP
+
+// Init sets the SMTP account used by every send and returns a Mailer backed by
+// it. It must be called at startup before any mail is sent.
+func Init(cfg config.SMTP) Mailer {
+	smtpConfig = cfg
+	if cfg.DryRun {
+		slog.Warn("MAIL_DRY_RUN is set, emails are logged instead of sent")
+	}
+	return smtpMailer{}
+}
+
+// SendEmail sends an HTML email through the SMTP account. With MAIL_DRY_RUN
+// set it only logs the recipient, subject and the start of the text, and
+// reports success.
 func SendEmail(to, subject, message string) error {
-	SMTP_HOST := os.Getenv("SMTP_HOST")
-	SMTP_PORT := os.Getenv("SMTP_PORT")
-	SMTP_USERNAME := os.Getenv("SMTP_USERNAME")
-	SMTP_PASSWORD := os.Getenv("SMTP_PASSWORD")
+	if smtpConfig.DryRun {
+		slog.Info("mail dry run, email not sent", "to", to, "subject", subject, "preview", preview(message))
+		return nil
+	}
+
+	SMTP_HOST := smtpConfig.Host
+	SMTP_PORT := smtpConfig.Port
+	SMTP_USERNAME := smtpConfig.Username
+	SMTP_PASSWORD := smtpConfig.Password
 
 	addr := fmt.Sprintf("%s:%s", SMTP_HOST, SMTP_PORT)
 	msg := fmt.Sprintf(
@@ -27,6 +54,7 @@ func SendEmail(to, subject, message string) error {
 	err := smtp.SendMail(addr,
 		smtp.PlainAuth("", SMTP_USERNAME, SMTP_PASSWORD, SMTP_HOST),
 		SMTP_USERNAME, []string{to}, []byte(msg))
+	metrics.EmailSends.WithLabelValues(metrics.Result(err)).Inc()
 
 	if err != nil {
 		return err
@@ -35,10 +63,39 @@ func SendEmail(to, subject, message string) error {
 	return nil
 }
 
+var (
+	styleBlock = regexp.MustCompile(`(?is)<style.*?</style>`)
+	htmlTag    = regexp.MustCompile(`<[^>]*>`)
+)
+
+// previewLength is how many characters of text a dry-run log line shows.
+const previewLength = 300
+
+// preview turns an HTML body into a short line of its text.
+func preview(body string) string {
+	text := htmlTag.ReplaceAllString(styleBlock.ReplaceAllString(body, " "), " ")
+	text = html.UnescapeString(strings.Join(strings.Fields(text), " "))
+
+	runes := []rune(text)
+	if len(runes) > previewLength {
+		return string(runes[:previewLength]) + "..."
+	}
+
+	return text
+}
+
+// GenerateCode returns a random six-digit OTP from crypto/rand. Services hold
+// it in a GenerateCode field so it can be swapped for a fixed code.
 func GenerateCode() string {
-	minRange, maxRange := 100000, 999999
+	minRange, maxRange := int64(100000), int64(999999)
+
+	n, err := crand.Int(crand.Reader, big.NewInt(maxRange-minRange+1))
+	if err != nil {
+		// crypto/rand only fails when the system's random source is unusable.
+		panic(err)
+	}
 
-	return strconv.Itoa(rand.Intn(maxRange-minRange+1) + minRange)
+	return strconv.FormatInt(n.Int64()+minRange, 10)
 }
 
 func GenerateRandomString(length int) string {
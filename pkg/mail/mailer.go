@@ -0,0 +1,34 @@
+package mail
+
+import "log/slog"
+
+// Mailer sends HTML email, either right away or through the rate-limited queue.
+// Services take one instead of calling SendEmail or the package-level Enqueue
+// directly so their mail can be captured with FakeMailer.
+type Mailer interface {
+	Send(to, subject, body string) error
+	// Enqueue queues the email and returns without waiting for it. sent, when
+	// not nil, is called with the result once the email has been tried.
+	Enqueue(to, subject, body string, sent func(error)) error
+}
+
+type smtpMailer struct{}
+
+func (smtpMailer) Send(to, subject, body string) error {
+	return SendEmail(to, subject, body)
+}
+
+func (smtpMailer) Enqueue(to, subject, body string, sent func(error)) error {
+	return Enqueue(to, subject, body, sent)
+}
+
+// SendAsync sends through m in the background for notifications the caller
+// shouldn't wait on. Failures are only logged.
+func SendAsync(m Mailer, to, subject, body string) {
+	go func() {
+		err := m.Send(to, subject, body)
+		if err != nil {
+			slog.Error("failed to send email", "to", to, "subject", subject, "error", err)
+		}
+	}()
+}
@@ -0,0 +1,67 @@
+package mail
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const queueSize = 1000
+
+var ErrQueueFull = errors.New("mail queue is full")
+
+type queuedEmail struct {
+	to      string
+	subject string
+	message string
+	sent    func(error)
+}
+
+var (
+	queue     chan queuedEmail
+	queueOnce sync.Once
+)
+
+// Enqueue schedules an email on the shared queue, which a single worker sends
+// at no more than MAIL_SEND_RATE_PER_MINUTE messages (default 60) so bulk mail
+// doesn't trip the SMTP provider's limits. It returns ErrQueueFull instead of
+// blocking when the queue has no room. Failures are logged and, when sent is not
+// nil, passed to it; sent runs on the worker, so it should return quickly.
+func Enqueue(to, subject, message string, sent func(error)) error {
+	queueOnce.Do(startQueue)
+
+	select {
+	case queue <- queuedEmail{to: to, subject: subject, message: message, sent: sent}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func startQueue() {
+	queue = make(chan queuedEmail, queueSize)
+
+	rate := smtpConfig.SendRatePerMinute
+	if rate < 1 {
+		rate = 1
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute / time.Duration(rate))
+		defer ticker.Stop()
+
+		for email := range queue {
+			<-ticker.C
+
+			err := SendEmail(email.to, email.subject, email.message)
+			if err != nil {
+				slog.Error("failed to send queued email", "to", email.to, "subject", email.subject, "error", err)
+			}
+
+			if email.sent != nil {
+				email.sent(err)
+			}
+		}
+	}()
+}
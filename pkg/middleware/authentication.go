@@ -1,39 +1,93 @@
 package middleware
 
 import (
+	"errors"
 	"itfest-2025/model"
 	"itfest-2025/pkg/response"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-func (m *middleware) AuthenticateUser(c *gin.Context) {
+// Keys under which Authenticate stores the caller in the gin context.
+const (
+	UserKey   = "user"
+	UserIDKey = "user_id"
+	RoleKey   = "role_id"
+)
+
+// GetUserID returns the ID of the user Authenticate let through. Like
+// c.MustGet, it panics on a route that doesn't run Authenticate.
+func GetUserID(c *gin.Context) uuid.UUID {
+	return c.MustGet(UserIDKey).(uuid.UUID)
+}
+
+// Authenticate validates the bearer token, loads its user and stores the user,
+// their ID and their role ID in the context. Requests without a valid token for
+// an existing user are aborted with 401, and those of an account that hasn't
+// verified its email with 403.
+func (m *middleware) Authenticate() gin.HandlerFunc {
+	return m.authenticate
+}
+
+// OptionalAuthenticateUser lets anonymous requests through, but still rejects a
+// request that sends an invalid token.
+func (m *middleware) OptionalAuthenticateUser(c *gin.Context) {
+	if c.GetHeader("Authorization") == "" {
+		c.Next()
+		return
+	}
+
+	m.authenticate(c)
+}
+
+func (m *middleware) authenticate(c *gin.Context) {
 	bearer := c.GetHeader("Authorization")
 	if bearer == "" {
-		response.Error(c, http.StatusUnauthorized, "empty token", nil)
-		c.Abort()
+		unauthorized(c, "empty token", errors.New("authorization header is missing"))
 		return
 	}
 
-	token := strings.Split(bearer, " ")[1]
-	userID, err := m.jwtAuth.ValidateToken(token)
+	parts := strings.Fields(bearer)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		unauthorized(c, "invalid token format", errors.New("authorization header must be \"Bearer <token>\""))
+		return
+	}
+
+	claims, err := m.jwtAuth.ValidateToken(parts[1])
 	if err != nil {
-		response.Error(c, http.StatusUnauthorized, "failed to validate token", err)
-		c.Abort()
+		unauthorized(c, "failed to validate token", err)
 		return
 	}
 
-	user, err := m.service.UserService.GetUser(model.UserParam{
-		UserID: userID,
+	user, err := m.service.UserService.GetUser(c.Request.Context(), model.UserParam{
+		UserID: claims.UserID,
 	})
 	if err != nil {
-		response.Error(c, http.StatusUnauthorized, "failed to get user", err)
+		unauthorized(c, "failed to get user", err)
+		return
+	}
+
+	if user.TokensRevokedAt != nil && claims.IssuedBefore(*user.TokensRevokedAt) {
+		unauthorized(c, "token has been revoked", errors.New("token was issued before the account revoked its tokens"))
+		return
+	}
+
+	if user.StatusAccount != "active" {
+		response.Error(c, http.StatusForbidden, "account is not verified", model.ErrAccountNotVerified)
 		c.Abort()
 		return
 	}
 
-	c.Set("user", user)
+	c.Set(UserKey, user)
+	c.Set(UserIDKey, user.UserID)
+	c.Set(RoleKey, user.RoleID)
 	c.Next()
 }
+
+func unauthorized(c *gin.Context, message string, err error) {
+	response.Error(c, http.StatusUnauthorized, message, err)
+	c.Abort()
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"itfest-2025/entity"
+	"itfest-2025/internal/service"
+	"itfest-2025/model"
+	"itfest-2025/pkg/config"
+	"itfest-2025/pkg/jwt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeUserService struct {
+	service.IUserService
+
+	user *entity.User
+}
+
+func (s *fakeUserService) GetUser(ctx context.Context, param model.UserParam) (*entity.User, error) {
+	if s.user == nil || s.user.UserID != param.UserID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return s.user, nil
+}
+
+func TestAuthenticateAccountStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		status string
+		want   int
+	}{
+		{name: "active", status: "active", want: http.StatusOK},
+		{name: "inactive", status: "inactive", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &entity.User{
+				UserID:        uuid.New(),
+				StatusAccount: tt.status,
+				RoleID:        entity.RoleUser,
+			}
+			jwtAuth := jwt.Init(config.JWT{SecretKey: "test-secret", ExpiredTime: time.Hour})
+			m := Init(&service.Service{UserService: &fakeUserService{user: user}}, jwtAuth, time.Second)
+
+			token, err := jwtAuth.CreateJWTToken(user.UserID, entity.RoleNameUser)
+			if err != nil {
+				t.Fatalf("CreateJWTToken() error = %v", err)
+			}
+
+			router := gin.New()
+			router.GET("/me", m.Authenticate(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -1,25 +1,30 @@
 package middleware
 
 import (
-	"errors"
+	"fmt"
 	"itfest-2025/pkg/response"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-func (m *middleware) OnlyAdmin(c *gin.Context) {
-	user, err := m.jwtAuth.GetLoginUser(c)
-	if err != nil {
-		response.Error(c, http.StatusForbidden, "failed to get login user", err)
-		c.Abort()
-		return
-	}
+// RequirePermission only lets through users whose role grants permission. It
+// runs after Authenticate, so the role is the one stored now rather than the
+// one in the token.
+func (m *middleware) RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, err := m.service.UserService.HasPermission(c.Request.Context(), c.GetInt(RoleKey), permission)
+		if err != nil {
+			response.Error(c, http.StatusInternalServerError, "failed to check permission", err)
+			c.Abort()
+			return
+		}
 
-	if user.RoleID != 1 {
-		response.Error(c, http.StatusForbidden, "this endpoint cannot be access", errors.New("user dont have access"))
-		c.Abort()
-		return
+		if !allowed {
+			response.Error(c, http.StatusForbidden, "this endpoint cannot be access", fmt.Errorf("role does not have the %s permission", permission))
+			c.Abort()
+			return
+		}
+		c.Next()
 	}
-	c.Next()
 }
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"itfest-2025/pkg/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the count, duration and in-flight number of requests. They
+// are labelled with the route pattern rather than the path, so IDs in the URL
+// don't create a series per request; requests that match no route share the
+// "unmatched" label.
+func (m *middleware) Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		method := c.Request.Method
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -3,25 +3,32 @@ package middleware
 import (
 	"itfest-2025/internal/service"
 	"itfest-2025/pkg/jwt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Interface interface {
-	AuthenticateUser(c *gin.Context)
-	OnlyAdmin(c *gin.Context)
+	Authenticate() gin.HandlerFunc
+	OptionalAuthenticateUser(c *gin.Context)
+	RequirePermission(permission string) gin.HandlerFunc
 	Timeout() gin.HandlerFunc
+	TimeoutWithDuration(d time.Duration) gin.HandlerFunc
 	Cors() gin.HandlerFunc
+	RateLimit(limit int, window time.Duration) gin.HandlerFunc
+	Metrics() gin.HandlerFunc
 }
 
 type middleware struct {
-	service *service.Service
-	jwtAuth jwt.Interface
+	service        *service.Service
+	jwtAuth        jwt.Interface
+	requestTimeout time.Duration
 }
 
-func Init(service *service.Service, jwtAuth jwt.Interface) Interface {
+func Init(service *service.Service, jwtAuth jwt.Interface, requestTimeout time.Duration) Interface {
 	return &middleware{
-		service: service,
-		jwtAuth: jwtAuth,
+		service:        service,
+		jwtAuth:        jwtAuth,
+		requestTimeout: requestTimeout,
 	}
 }
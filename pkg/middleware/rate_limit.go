@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"errors"
+	"itfest-2025/pkg/response"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RateLimit allows at most limit requests per window for each client. Clients
+// are told apart by user ID when authenticated and by IP address otherwise, so
+// it should run after the authentication middleware.
+func (m *middleware) RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	limiter := newFixedWindowLimiter(limit, window)
+
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if userID, ok := c.Get(UserIDKey); ok {
+			key = "user:" + userID.(uuid.UUID).String()
+		}
+
+		retryAfter, ok := limiter.allow(key, time.Now())
+		if !ok {
+			response.TooManyRequests(c, "too many requests, please try again later", errors.New("rate limit exceeded"), int((retryAfter+time.Second-1)/time.Second))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+type fixedWindowLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]*rateWindow
+	swept   time.Time
+}
+
+func newFixedWindowLimiter(limit int, window time.Duration) *fixedWindowLimiter {
+	return &fixedWindowLimiter{
+		limit:   limit,
+		window:  window,
+		windows: map[string]*rateWindow{},
+	}
+}
+
+// allow counts a request for key and reports whether it is within the limit.
+// When it isn't, it also returns how long until the key's window resets.
+func (l *fixedWindowLimiter) allow(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.swept) >= l.window {
+		for k, w := range l.windows {
+			if now.Sub(w.start) >= l.window {
+				delete(l.windows, k)
+			}
+		}
+		l.swept = now
+	}
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+
+	if w.count >= l.limit {
+		return w.start.Add(l.window).Sub(now), false
+	}
+
+	w.count++
+	return 0, true
+}
@@ -1,11 +1,10 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"itfest-2025/pkg/response"
 	"net/http"
-	"os"
-	"strconv"
 	"time"
 
 	"github.com/gin-contrib/timeout"
@@ -13,11 +12,25 @@ import (
 )
 
 func (m *middleware) Timeout() gin.HandlerFunc {
-	timeLimit, _ := strconv.Atoi(os.Getenv("TIME_OUT_LIMIT"))
+	return m.TimeoutWithDuration(m.requestTimeout)
+}
 
+// TimeoutWithDuration also puts the deadline on the request context, so a handler
+// that is still running after the response was sent has its queries and open
+// transaction cancelled instead of committing in the background.
+func (m *middleware) TimeoutWithDuration(d time.Duration) gin.HandlerFunc {
 	return timeout.New(
-		timeout.WithTimeout(time.Duration(timeLimit)*time.Second),
+		timeout.WithTimeout(d),
 		timeout.WithHandler(func(c *gin.Context) {
+			if d <= 0 {
+				c.Next()
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+			defer cancel()
+
+			c.Request = c.Request.WithContext(ctx)
 			c.Next()
 		}),
 		timeout.WithResponse(timeoutResponse),
@@ -25,5 +38,5 @@ func (m *middleware) Timeout() gin.HandlerFunc {
 }
 
 func timeoutResponse(c *gin.Context) {
-	response.Error(c, http.StatusRequestTimeout, "the request take to much time", errors.New(""))
+	response.Error(c, http.StatusServiceUnavailable, "the request took too much time", errors.New("request exceeded the server time limit"))
 }
@@ -1,36 +1,61 @@
 package response
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+	"strconv"
 
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDKey is the gin context key a request ID middleware stores the ID
+// under. When it is set, every response carries the ID in request_id.
+const RequestIDKey = "request_id"
+
+// Response is the envelope of every API response. The HTTP status code is the
+// only place the status is reported.
 type Response struct {
-	Status  Status      `json:"status"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data"`
+	RequestID string      `json:"request_id,omitempty"`
 }
 
-type Status struct {
-	Code      int  `json:"code"`
-	IsSuccess bool `json:"isSuccess"`
+func Success(ctx *gin.Context, code int, message string, data any) {
+	write(ctx, code, true, message, data)
 }
 
-func Success(ctx *gin.Context, code int, message string, data any) {
-	ctx.JSON(code, Response{
-		Status: Status{
-			Code:      code,
-			IsSuccess: true,
-		},
-		Message: message,
-		Data:    data,
+func Error(ctx *gin.Context, code int, message string, err error) {
+	write(ctx, code, false, message, err.Error())
+}
+
+func ValidationError(ctx *gin.Context, code int, message string, fields map[string]string) {
+	write(ctx, code, false, message, fields)
+}
+
+// ErrorWithData reports a failure the client can act on, with data telling it
+// how next to the error.
+func ErrorWithData(ctx *gin.Context, code int, message string, err error, data gin.H) {
+	body := gin.H{"error": err.Error()}
+	for k, v := range data {
+		body[k] = v
+	}
+
+	write(ctx, code, false, message, body)
+}
+
+func TooManyRequests(ctx *gin.Context, message string, err error, retryAfterSeconds int) {
+	ctx.Header("Retry-After", strconv.Itoa(retryAfterSeconds))
+	write(ctx, http.StatusTooManyRequests, false, message, gin.H{
+		"error":               err.Error(),
+		"retry_after_seconds": retryAfterSeconds,
 	})
 }
 
-func Error(ctx *gin.Context, code int, message string, err error) {
+func write(ctx *gin.Context, code int, success bool, message string, data any) {
 	ctx.JSON(code, Response{
-		Status: Status{
-			Code:      code,
-			IsSuccess: false,
-		},
-		Message: message,
-		Data:    err.Error(),
+		Success:   success,
+		Message:   message,
+		Data:      data,
+		RequestID: ctx.GetString(RequestIDKey),
 	})
 }
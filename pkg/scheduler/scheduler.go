@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs job once right away and then every interval until ctx is
+// cancelled. Errors are logged and don't stop later runs.
+func Every(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := job(ctx)
+		if err != nil {
+			log.Printf("scheduled job %q failed: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
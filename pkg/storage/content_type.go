@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// sniffLen is how many bytes http.DetectContentType looks at.
+const sniffLen = 512
+
+// extensions maps the content types uploads are stored as to the extension
+// their object gets.
+var extensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// OpenUpload opens an uploaded file and detects its content type from its
+// first bytes, ignoring the Content-Type the client sent. The returned file is
+// rewound to the start, so reading it gives the whole content. The caller
+// closes it.
+func OpenUpload(file *multipart.FileHeader) (multipart.File, string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, "", err
+	}
+
+	buffer := make([]byte, sniffLen)
+	n, err := io.ReadFull(src, buffer)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		src.Close()
+		return nil, "", err
+	}
+
+	_, err = src.Seek(0, io.SeekStart)
+	if err != nil {
+		src.Close()
+		return nil, "", err
+	}
+
+	return src, http.DetectContentType(buffer[:n]), nil
+}
+
+// Extension returns the extension a file of contentType is stored with, or ""
+// for types uploads don't use.
+func Extension(contentType string) string {
+	return extensions[contentType]
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"testing"
+)
+
+// fileHeader returns the header of a file uploaded as filename with the given
+// Content-Type, the way gin hands it to a handler.
+func fileHeader(t *testing.T, filename, contentType string, content []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = part.Write(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		form.RemoveAll()
+	})
+
+	return form.File["file"][0]
+}
+
+func TestOpenUpload(t *testing.T) {
+	jpeg := append([]byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"), bytes.Repeat([]byte{0}, 600)...)
+	png := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), bytes.Repeat([]byte{0}, 600)...)
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+
+	tests := []struct {
+		name          string
+		filename      string
+		contentType   string
+		content       []byte
+		wantType      string
+		wantExtension string
+	}{
+		{name: "jpeg", filename: "photo.jpg", contentType: "image/jpeg", content: jpeg, wantType: "image/jpeg", wantExtension: ".jpg"},
+		{name: "png", filename: "photo.png", contentType: "image/png", content: png, wantType: "image/png", wantExtension: ".png"},
+		{name: "pdf", filename: "proof.pdf", contentType: "application/pdf", content: pdf, wantType: "application/pdf", wantExtension: ".pdf"},
+		{name: "png labeled as pdf", filename: "proof.pdf", contentType: "application/pdf", content: png, wantType: "image/png", wantExtension: ".png"},
+		{name: "text labeled as jpeg", filename: "photo.jpg", contentType: "image/jpeg", content: []byte("not an image"), wantType: "text/plain; charset=utf-8", wantExtension: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, contentType, err := OpenUpload(fileHeader(t, tt.filename, tt.contentType, tt.content))
+			if err != nil {
+				t.Fatalf("OpenUpload() error = %v", err)
+			}
+			defer file.Close()
+
+			if contentType != tt.wantType {
+				t.Errorf("content type = %q, want %q", contentType, tt.wantType)
+			}
+			if got := Extension(contentType); got != tt.wantExtension {
+				t.Errorf("Extension(%q) = %q, want %q", contentType, got, tt.wantExtension)
+			}
+
+			data, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, tt.content) {
+				t.Error("the file was not rewound to its start")
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"itfest-2025/pkg/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// local keeps files in a directory on disk. The API serves that directory at
+// /files, so like the Supabase bucket every file is public.
+type local struct {
+	dir string
+	url string
+}
+
+func newLocal(cfg config.LocalStorage) (Interface, error) {
+	err := os.MkdirAll(cfg.Dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return local{
+		dir: cfg.Dir,
+		url: cfg.URL,
+	}, nil
+}
+
+func (l local) UploadFile(data []byte, filename string) (string, error) {
+	return l.UploadFileContext(context.Background(), data, filename)
+}
+
+// UploadFileContext only checks ctx before writing; a local write is too
+// quick to be worth interrupting.
+func (l local) UploadFileContext(ctx context.Context, data []byte, filename string) (string, error) {
+	if ctx.Err() != nil {
+		return "", canceled(ctx, ctx.Err())
+	}
+
+	name := uuid.NewString() + filepath.Ext(filename)
+
+	err := os.WriteFile(filepath.Join(l.dir, name), data, 0o644)
+	if err != nil {
+		return "", err
+	}
+
+	return l.url + "/" + name, nil
+}
+
+func (l local) DeleteFile(fileURL string) error {
+	name, err := l.name(fileURL)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(filepath.Join(l.dir, name))
+}
+
+// GetSignedURL returns fileURL itself, since local files are already public.
+func (l local) GetSignedURL(fileURL string, expiry time.Duration) (string, error) {
+	_, err := l.name(fileURL)
+	if err != nil {
+		return "", err
+	}
+
+	return fileURL, nil
+}
+
+// name returns the file name of a URL from UploadFile, refusing anything that
+// would reach outside the directory.
+func (l local) name(fileURL string) (string, error) {
+	name, ok := strings.CutPrefix(fileURL, l.url+"/")
+	if !ok || name == "" || strings.ContainsAny(name, `/\`) || name == ".." {
+		return "", fmt.Errorf("%q is not a file in %s", fileURL, l.dir)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"itfest-2025/pkg/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const localTestURL = "http://localhost:8080/files"
+
+func newLocalForTest(t *testing.T) (local, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	storage, err := newLocal(config.LocalStorage{Dir: dir, URL: localTestURL})
+	if err != nil {
+		t.Fatalf("newLocal() error = %v", err)
+	}
+
+	return storage.(local), dir
+}
+
+func TestLocalUploadAndDelete(t *testing.T) {
+	storage, dir := newLocalForTest(t)
+
+	fileURL, err := storage.UploadFile([]byte("receipt"), "payment.pdf")
+	if err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+
+	if !strings.HasPrefix(fileURL, localTestURL+"/") || !strings.HasSuffix(fileURL, ".pdf") {
+		t.Fatalf("UploadFile() URL = %q, want a .pdf under %s", fileURL, localTestURL)
+	}
+
+	path := filepath.Join(dir, strings.TrimPrefix(fileURL, localTestURL+"/"))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("uploaded file is missing: %v", err)
+	}
+	if string(data) != "receipt" {
+		t.Errorf("uploaded file holds %q, want %q", data, "receipt")
+	}
+
+	err = storage.DeleteFile(fileURL)
+	if err != nil {
+		t.Fatalf("DeleteFile() error = %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still exists after DeleteFile(), stat error = %v", err)
+	}
+}
+
+func TestLocalUploadCanceled(t *testing.T) {
+	storage, _ := newLocalForTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := storage.UploadFileContext(ctx, []byte("receipt"), "payment.pdf")
+	if !errors.Is(err, ErrUploadCanceled) {
+		t.Errorf("UploadFileContext() error = %v, want %v", err, ErrUploadCanceled)
+	}
+}
+
+func TestLocalName(t *testing.T) {
+	storage, _ := newLocalForTest(t)
+
+	tests := []struct {
+		name    string
+		fileURL string
+		want    string
+		wantErr bool
+	}{
+		{name: "uploaded file", fileURL: localTestURL + "/abc.pdf", want: "abc.pdf"},
+		{name: "parent directory", fileURL: localTestURL + "/..", wantErr: true},
+		{name: "path traversal", fileURL: localTestURL + "/../secret.txt", wantErr: true},
+		{name: "backslash traversal", fileURL: localTestURL + `/..\secret.txt`, wantErr: true},
+		{name: "nested path", fileURL: localTestURL + "/dir/abc.pdf", wantErr: true},
+		{name: "empty name", fileURL: localTestURL + "/", wantErr: true},
+		{name: "other prefix", fileURL: "http://example.com/files/abc.pdf", wantErr: true},
+		{name: "prefix without slash", fileURL: localTestURL + "abc.pdf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := storage.name(tt.fileURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("name(%q) error = %v, wantErr %v", tt.fileURL, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("name(%q) = %q, want %q", tt.fileURL, got, tt.want)
+			}
+		})
+	}
+}
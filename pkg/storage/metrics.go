@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"itfest-2025/pkg/metrics"
+	"time"
+)
+
+// timed records how long uploads to the wrapped backend take.
+type timed struct {
+	Interface
+	backend string
+}
+
+func withMetrics(s Interface, backend string) Interface {
+	return &timed{
+		Interface: s,
+		backend:   backend,
+	}
+}
+
+func (t *timed) UploadFile(data []byte, filename string) (string, error) {
+	return t.UploadFileContext(context.Background(), data, filename)
+}
+
+func (t *timed) UploadFileContext(ctx context.Context, data []byte, filename string) (string, error) {
+	start := time.Now()
+	url, err := t.Interface.UploadFileContext(ctx, data, filename)
+	metrics.UploadDuration.WithLabelValues(t.backend, metrics.Result(err)).Observe(time.Since(start).Seconds())
+
+	return url, err
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"time"
+)
+
+// retrying retries UploadFile when the backend fails in a way that may not
+// happen again. DeleteFile and GetSignedURL are passed through unchanged.
+type retrying struct {
+	Interface
+	retries int
+	backoff time.Duration
+}
+
+// WithRetry makes UploadFile try again up to retries times, waiting backoff
+// before the first retry and doubling it after each one. Only network errors
+// and 5xx responses are retried; anything else, such as 413 or 401, is
+// returned straight away.
+func WithRetry(s Interface, retries int, backoff time.Duration) Interface {
+	if retries <= 0 {
+		return s
+	}
+
+	return &retrying{
+		Interface: s,
+		retries:   retries,
+		backoff:   backoff,
+	}
+}
+
+func (r *retrying) UploadFile(data []byte, filename string) (string, error) {
+	return r.UploadFileContext(context.Background(), data, filename)
+}
+
+func (r *retrying) UploadFileContext(ctx context.Context, data []byte, filename string) (string, error) {
+	backoff := r.backoff
+	for attempt := 0; ; attempt++ {
+		url, err := r.Interface.UploadFileContext(ctx, data, filename)
+		if err == nil || attempt == r.retries || !retryable(err) {
+			return url, err
+		}
+
+		log.Printf("upload of %s failed, retrying in %s: %v", filename, backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", canceled(ctx, ctx.Err())
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether err came from the network or from a 5xx response.
+func retryable(err error) bool {
+	if errors.Is(err, ErrUploadCanceled) {
+		return false
+	}
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Status >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// scriptedBackend fails uploads with errs in turn, then succeeds.
+type scriptedBackend struct {
+	Interface
+	errs  []error
+	calls int
+}
+
+func (b *scriptedBackend) UploadFileContext(ctx context.Context, data []byte, filename string) (string, error) {
+	b.calls++
+	if b.calls <= len(b.errs) {
+		return "", b.errs[b.calls-1]
+	}
+
+	return "https://files.example.com/" + filename, nil
+}
+
+func TestWithRetry(t *testing.T) {
+	unavailable := &statusError{Status: 503, Body: "unavailable"}
+	tooLarge := &statusError{Status: 413, Body: "payload too large"}
+	unauthorized := &statusError{Status: 401, Body: "invalid token"}
+	reset := &net.OpError{Op: "write", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "5xx then success", errs: []error{unavailable}, wantCalls: 2},
+		{name: "network error then success", errs: []error{reset}, wantCalls: 2},
+		{name: "unexpected EOF then success", errs: []error{io.ErrUnexpectedEOF}, wantCalls: 2},
+		{name: "5xx until retries run out", errs: []error{unavailable, unavailable, unavailable}, wantCalls: 3, wantErr: unavailable},
+		{name: "413 is not retried", errs: []error{tooLarge}, wantCalls: 1, wantErr: tooLarge},
+		{name: "401 is not retried", errs: []error{unauthorized}, wantCalls: 1, wantErr: unauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &scriptedBackend{errs: tt.errs}
+
+			_, err := WithRetry(backend, 2, time.Millisecond).UploadFile([]byte("receipt"), "payment.pdf")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UploadFile() error = %v, want %v", err, tt.wantErr)
+			}
+			if backend.calls != tt.wantCalls {
+				t.Errorf("backend called %d times, want %d", backend.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithRetryStopsWhenCanceled(t *testing.T) {
+	backend := &scriptedBackend{errs: []error{&statusError{Status: 502}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := WithRetry(backend, 2, time.Hour).UploadFileContext(ctx, []byte("receipt"), "payment.pdf")
+	if !errors.Is(err, ErrUploadCanceled) {
+		t.Fatalf("UploadFileContext() error = %v, want %v", err, ErrUploadCanceled)
+	}
+	if backend.calls != 1 {
+		t.Errorf("backend called %d times, want 1", backend.calls)
+	}
+}
+
+func TestWithRetryDisabled(t *testing.T) {
+	backend := &scriptedBackend{}
+
+	if got := WithRetry(backend, 0, time.Millisecond); got != Interface(backend) {
+		t.Error("WithRetry() with no retries should return the backend unchanged")
+	}
+}
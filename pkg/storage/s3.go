@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"itfest-2025/pkg/config"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	s3Algorithm = "AWS4-HMAC-SHA256"
+	// s3MaxPresignExpiry is the longest lifetime S3 accepts for a presigned URL.
+	s3MaxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// s3 stores files in an S3 bucket, signing requests with AWS Signature
+// Version 4. Without a custom endpoint it talks to AWS using virtual-hosted
+// addresses; with one it uses path-style addresses, which S3-compatible
+// services such as MinIO expect.
+type s3 struct {
+	base      *url.URL
+	region    string
+	accessKey string
+	secretKey string
+	publicURL string
+	client    *http.Client
+}
+
+func newS3(cfg config.S3) (Interface, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", cfg.Bucket, cfg.Region)
+	} else {
+		endpoint += "/" + cfg.Bucket
+	}
+
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+
+	publicURL := cfg.PublicURL
+	if publicURL == "" {
+		publicURL = base.String()
+	}
+
+	return &s3{
+		base:      base,
+		region:    cfg.Region,
+		accessKey: cfg.AccessKeyID,
+		secretKey: cfg.SecretAccessKey,
+		publicURL: publicURL,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+func (s *s3) UploadFile(data []byte, filename string) (string, error) {
+	return s.UploadFileContext(context.Background(), data, filename)
+}
+
+func (s *s3) UploadFileContext(ctx context.Context, data []byte, filename string) (string, error) {
+	key := uuid.NewString() + filepath.Ext(filename)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", http.DetectContentType(data))
+
+	err = s.do(req, data)
+	if err != nil {
+		return "", canceled(ctx, err)
+	}
+
+	return s.publicURL + "/" + key, nil
+}
+
+func (s *s3) DeleteFile(fileURL string) error {
+	key, err := s.key(fileURL)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+
+	return s.do(req, nil)
+}
+
+// GetSignedURL returns a presigned GET URL, capped at the seven days S3 allows.
+func (s *s3) GetSignedURL(fileURL string, expiry time.Duration) (string, error) {
+	key, err := s.key(fileURL)
+	if err != nil {
+		return "", err
+	}
+
+	if expiry > s3MaxPresignExpiry {
+		expiry = s3MaxPresignExpiry
+	}
+
+	return s.presign(key, expiry, time.Now().UTC())
+}
+
+func (s *s3) presign(key string, expiry time.Duration, now time.Time) (string, error) {
+	signed, err := url.Parse(s.objectURL(key))
+	if err != nil {
+		return "", err
+	}
+
+	scope := s.scope(now)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	signed.RawQuery = query.Encode()
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		signed.EscapedPath(),
+		signed.RawQuery,
+		"host:" + signed.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(now, scope, canonical))
+	signed.RawQuery = query.Encode()
+
+	return signed.String(), nil
+}
+
+// do signs req, whose body is payload, and sends it.
+func (s *s3) do(req *http.Request, payload []byte) error {
+	now := time.Now().UTC()
+	scope := s.scope(now)
+	payloadHash := sha256Hex(payload)
+	amzDate := now.Format("20060102T150405Z")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm,
+		s.accessKey,
+		scope,
+		signedHeaders,
+		s.signature(now, scope, canonical),
+	))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return checkStatus(res)
+}
+
+func (s *s3) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+func (s *s3) signature(t time.Time, scope, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		t.Format("20060102T150405Z"),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s *s3) objectURL(key string) string {
+	return s.base.String() + "/" + key
+}
+
+// key returns the object key of a URL from UploadFile.
+func (s *s3) key(fileURL string) (string, error) {
+	key, ok := strings.CutPrefix(fileURL, s.publicURL+"/")
+	if !ok || key == "" {
+		return "", fmt.Errorf("%q is not a file in %s", fileURL, s.publicURL)
+	}
+
+	return key, nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"itfest-2025/pkg/config"
+	"net/http"
+	"time"
+)
+
+// ErrUploadCanceled is returned when the context of an upload ends before the
+// file is stored, usually because the client went away.
+var ErrUploadCanceled = errors.New("upload canceled")
+
+// Interface is where uploaded files are kept. Files are identified by the URL
+// UploadFile returned for them.
+type Interface interface {
+	UploadFile(data []byte, filename string) (string, error)
+	// UploadFileContext is UploadFile that gives up when ctx is done,
+	// returning an error wrapping ErrUploadCanceled.
+	UploadFileContext(ctx context.Context, data []byte, filename string) (string, error)
+	DeleteFile(fileURL string) error
+	// GetSignedURL returns a link to the file that stops working after expiry.
+	GetSignedURL(fileURL string, expiry time.Duration) (string, error)
+}
+
+// Init returns the backend chosen by STORAGE_BACKEND, with upload retries. The
+// time uploads take, retries included, is recorded in the metrics.
+func Init(cfg config.Storage) (Interface, error) {
+	var (
+		backend Interface
+		err     error
+	)
+
+	switch cfg.Backend {
+	case config.StorageSupabase:
+		backend = newSupabase(cfg.Supabase)
+	case config.StorageS3:
+		backend, err = newS3(cfg.S3)
+	case config.StorageLocal:
+		backend, err = newLocal(cfg.Local)
+	default:
+		err = fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return withMetrics(WithRetry(backend, cfg.UploadRetries, cfg.UploadBackoff), cfg.Backend), nil
+}
+
+// statusError is a storage API response outside the 2xx range.
+type statusError struct {
+	Status int
+	Body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("storage responded with status %d: %s", e.Status, e.Body)
+}
+
+func checkStatus(res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return &statusError{Status: res.StatusCode, Body: string(body)}
+}
+
+// canceled reports err as ErrUploadCanceled when it was caused by ctx ending.
+func canceled(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", ErrUploadCanceled, ctx.Err())
+	}
+
+	return err
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"itfest-2025/pkg/config"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	storage_go "github.com/supabase-community/storage-go"
+)
+
+// supabase stores files in a public Supabase Storage bucket.
+type supabase struct {
+	client     storage_go.Client
+	httpClient *http.Client
+	url        string
+	token      string
+	bucket     string
+}
+
+func newSupabase(cfg config.Supabase) Interface {
+	client := storage_go.NewClient(cfg.URL+"/storage/v1", cfg.Token, nil)
+
+	return supabase{
+		client:     *client,
+		httpClient: &http.Client{},
+		url:        cfg.URL,
+		token:      cfg.Token,
+		bucket:     cfg.Bucket,
+	}
+}
+
+// UploadFile stores data under a random name that keeps filename's extension
+// and returns its public URL.
+func (s supabase) UploadFile(data []byte, filename string) (string, error) {
+	return s.UploadFileContext(context.Background(), data, filename)
+}
+
+// UploadFileContext calls the Storage API itself rather than going through
+// storage-go, whose requests can't be cancelled.
+func (s supabase) UploadFileContext(ctx context.Context, data []byte, filename string) (string, error) {
+	path := uuid.NewString() + filepath.Ext(filename)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/storage/v1/object/"+s.bucket+"/"+path, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Content-Type", http.DetectContentType(data))
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", canceled(ctx, err)
+	}
+	defer res.Body.Close()
+
+	err = checkStatus(res)
+	if err != nil {
+		return "", err
+	}
+
+	return s.publicURL(path), nil
+}
+
+// DeleteFile removes a file previously returned by UploadFile, given its
+// public URL.
+func (s supabase) DeleteFile(fileURL string) error {
+	path, err := s.path(fileURL)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.RemoveFile(s.bucket, []string{path})
+	return err
+}
+
+func (s supabase) GetSignedURL(fileURL string, expiry time.Duration) (string, error) {
+	path, err := s.path(fileURL)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := s.client.CreateSignedUrl(s.bucket, path, int(expiry.Seconds()))
+	if err != nil {
+		return "", err
+	}
+
+	return res.SignedURL, nil
+}
+
+// path returns the object path of a public URL from UploadFile.
+func (s supabase) path(fileURL string) (string, error) {
+	path, ok := strings.CutPrefix(fileURL, s.publicURL(""))
+	if !ok || path == "" {
+		return "", fmt.Errorf("%q is not a file in bucket %s", fileURL, s.bucket)
+	}
+
+	return path, nil
+}
+
+func (s supabase) publicURL(path string) string {
+	return fmt.Sprintf("%s/storage/v1/object/public/%s/%s",
+		s.url,
+		s.bucket,
+		path,
+	)
+}
@@ -0,0 +1,100 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Receipt is the content of a payment receipt. Amounts are in rupiah.
+type Receipt struct {
+	Number          string
+	TeamName        string
+	LeaderName      string
+	LeaderEmail     string
+	CompetitionName string
+	Fee             int
+	Discount        int
+	CouponCode      string
+	Amount          int
+	ApprovedAt      *time.Time
+	IssuedAt        time.Time
+}
+
+// RenderReceipt lays the receipt out on a single A4 page and returns the PDF.
+func RenderReceipt(r Receipt) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetTitle("Kwitansi Pembayaran "+r.Number, true)
+	pdf.SetCreator("IT Fest 2025", true)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, "KWITANSI PEMBAYARAN", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 6, "IT Fest 2025", "", 1, "C", false, 0, "")
+	pdf.Ln(8)
+
+	row := func(label, value string) {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(55, 8, label, "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.MultiCell(0, 8, tr(value), "", "L", false)
+	}
+
+	row("Nomor Kwitansi", r.Number)
+	row("Tanggal Terbit", formatReceiptDate(&r.IssuedAt))
+	row("Nama Tim", r.TeamName)
+	row("Ketua Tim", r.LeaderName)
+	row("Email", r.LeaderEmail)
+	row("Kompetisi", r.CompetitionName)
+	pdf.Ln(4)
+
+	row("Biaya Pendaftaran", formatRupiah(r.Fee))
+	if r.Discount > 0 {
+		row("Potongan ("+r.CouponCode+")", "- "+formatRupiah(r.Discount))
+	}
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(55, 10, "Total Dibayar", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 10, formatRupiah(r.Amount), "T", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	row("Status", "Lunas")
+	row("Tanggal Disetujui", formatReceiptDate(r.ApprovedAt))
+
+	var buf bytes.Buffer
+	err := pdf.Output(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render receipt %s: %w", r.Number, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func formatReceiptDate(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.Format("02 January 2006 15:04 MST")
+}
+
+// formatRupiah formats an amount the Indonesian way, like "Rp 150.000".
+func formatRupiah(amount int) string {
+	digits := strconv.Itoa(amount)
+
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+
+	return "Rp " + b.String()
+}
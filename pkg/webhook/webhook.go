@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"itfest-2025/pkg/config"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the
+	// request body, keyed with WEBHOOK_SECRET.
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	// DeliveryHeader carries an ID that stays the same across the retries of one
+	// delivery, so receivers can ignore a payload they already handled.
+	DeliveryHeader = "X-Webhook-Delivery"
+
+	maxAttempts    = 5
+	initialBackoff = time.Second
+)
+
+type Interface interface {
+	Send(event string, payload interface{})
+}
+
+type sender struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+type disabled struct{}
+
+// Init returns a sender that does nothing when WEBHOOK_URL or WEBHOOK_SECRET
+// is not set.
+func Init(cfg config.Webhook) Interface {
+	if cfg.URL == "" || cfg.Secret == "" {
+		return disabled{}
+	}
+
+	return &sender{
+		url:    cfg.URL,
+		secret: []byte(cfg.Secret),
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Send posts payload as JSON in the background, retrying with exponential
+// backoff until the receiver answers 2xx or maxAttempts is reached. Failures
+// are only logged.
+func (s *sender) Send(event string, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("failed to encode %s webhook: %v", event, err)
+		return
+	}
+
+	deliveryID := uuid.NewString()
+
+	go func() {
+		backoff := initialBackoff
+		for attempt := 1; ; attempt++ {
+			err := s.post(event, deliveryID, body)
+			if err == nil {
+				return
+			}
+			if attempt == maxAttempts {
+				log.Printf("giving up on %s webhook %s after %d attempts: %v", event, deliveryID, attempt, err)
+				return
+			}
+
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}()
+}
+
+func (s *sender) post(event string, deliveryID string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, deliveryID)
+	req.Header.Set(SignatureHeader, "sha256="+s.sign(body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook receiver responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *sender) sign(body []byte) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (disabled) Send(event string, payload interface{}) {}
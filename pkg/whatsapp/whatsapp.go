@@ -0,0 +1,80 @@
+package whatsapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"itfest-2025/pkg/config"
+	"net/http"
+	"time"
+)
+
+type Interface interface {
+	Notify(phone, message string) error
+}
+
+type gateway struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+type disabled struct{}
+
+type notifyRequest struct {
+	Phone   string `json:"phone"`
+	Message string `json:"message"`
+}
+
+// Init returns a notifier that does nothing when WHATSAPP_API_URL or
+// WHATSAPP_API_TOKEN is not set, so local setups don't need a gateway account.
+func Init(cfg config.WhatsApp) Interface {
+	if cfg.URL == "" || cfg.Token == "" {
+		return disabled{}
+	}
+
+	return &gateway{
+		url:   cfg.URL,
+		token: cfg.Token,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (g *gateway) Notify(phone, message string) error {
+	if phone == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(notifyRequest{
+		Phone:   phone,
+		Message: message,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("whatsapp gateway responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+func (disabled) Notify(phone, message string) error {
+	return nil
+}